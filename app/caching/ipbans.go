@@ -0,0 +1,52 @@
+package caching
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+/*IPBanNetwork represents a banned ip address or network of a customer*/
+type IPBanNetwork struct {
+	Network *net.IPNet
+	EndsOn  *time.Time
+}
+
+var ipBans = map[int][]IPBanNetwork{}
+var ipBansMutex sync.RWMutex
+
+/*GetIPBans returns the cached ip bans of the customer. Returns false if they are not cached.*/
+func GetIPBans(customerID int) ([]IPBanNetwork, bool) {
+	ipBansMutex.RLock()
+	defer ipBansMutex.RUnlock()
+	bans, ok := ipBans[customerID]
+	return bans, ok
+}
+
+/*SetIPBans caches the ip bans of the customer*/
+func SetIPBans(customerID int, bans []IPBanNetwork) {
+	ipBansMutex.Lock()
+	defer ipBansMutex.Unlock()
+	ipBans[customerID] = bans
+}
+
+/*DeleteIPBans removes the cached ip bans of the customer so that they are read again on the next request*/
+func DeleteIPBans(customerID int) {
+	ipBansMutex.Lock()
+	defer ipBansMutex.Unlock()
+	delete(ipBans, customerID)
+}
+
+/*IsIPBanned checks whether the ip address is in one of the networks which are banned now*/
+func IsIPBanned(bans []IPBanNetwork, ip net.IP) bool {
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.EndsOn != nil && now.After(*ban.EndsOn) {
+			continue
+		}
+		if ban.Network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
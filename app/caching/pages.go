@@ -0,0 +1,50 @@
+package caching
+
+import (
+	"sync"
+	"time"
+)
+
+type cachedPage struct {
+	body      []byte
+	expiresOn time.Time
+}
+
+var pages = map[int]map[string]*cachedPage{}
+var pagesMutex sync.RWMutex
+
+/*GetPage returns the cached html of the customer's page. Returns false if it is not cached or expired.*/
+func GetPage(customerID int, key string) ([]byte, bool) {
+	pagesMutex.RLock()
+	defer pagesMutex.RUnlock()
+	page, ok := pages[customerID][key]
+	if !ok || time.Now().After(page.expiresOn) {
+		return nil, false
+	}
+	return page.body, true
+}
+
+/*SetPage caches the html of the customer's page for ttl*/
+func SetPage(customerID int, key string, body []byte, ttl time.Duration) {
+	pagesMutex.Lock()
+	defer pagesMutex.Unlock()
+	customerPages, ok := pages[customerID]
+	if !ok {
+		customerPages = map[string]*cachedPage{}
+		pages[customerID] = customerPages
+	}
+	now := time.Now()
+	for key, page := range customerPages {
+		if now.After(page.expiresOn) {
+			delete(customerPages, key)
+		}
+	}
+	customerPages[key] = &cachedPage{body: body, expiresOn: now.Add(ttl)}
+}
+
+/*InvalidatePages removes all cached pages of the customer*/
+func InvalidatePages(customerID int) {
+	pagesMutex.Lock()
+	defer pagesMutex.Unlock()
+	delete(pages, customerID)
+}
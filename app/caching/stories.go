@@ -0,0 +1,62 @@
+package caching
+
+import (
+	"sync"
+	"time"
+)
+
+/*RankedStoriesSnapshotCount represents how many of the latest ranked story ids of a customer are kept for the paging cursors which refer to them*/
+const RankedStoriesSnapshotCount = 3
+
+/*RankedStories represents the ranked story ids of a customer which front page lists*/
+type RankedStories struct {
+	IDs        []int
+	Count      int
+	ComputedOn time.Time
+}
+
+var rankedStories = map[int]*RankedStories{}
+var rankedStoriesSnapshots = map[int][]*RankedStories{}
+var rankedStoriesMutex sync.RWMutex
+
+/*GetRankedStories returns the ranked story ids of the customer. Returns nil if they are not cached or older than ttl.*/
+func GetRankedStories(customerID int, ttl time.Duration) *RankedStories {
+	rankedStoriesMutex.RLock()
+	defer rankedStoriesMutex.RUnlock()
+	stories, ok := rankedStories[customerID]
+	if !ok || time.Since(stories.ComputedOn) > ttl {
+		return nil
+	}
+	return stories
+}
+
+/*GetRankedStoriesSnapshot returns the ranked story ids of the customer which are computed at given time. Returns nil if they are not kept anymore.*/
+func GetRankedStoriesSnapshot(customerID int, computedOn time.Time) *RankedStories {
+	rankedStoriesMutex.RLock()
+	defer rankedStoriesMutex.RUnlock()
+	for _, snapshot := range rankedStoriesSnapshots[customerID] {
+		if snapshot.ComputedOn.Equal(computedOn) {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+/*SetRankedStories caches the ranked story ids of the customer. Previous ids are kept as snapshots.*/
+func SetRankedStories(customerID int, stories *RankedStories) {
+	rankedStoriesMutex.Lock()
+	defer rankedStoriesMutex.Unlock()
+	rankedStories[customerID] = stories
+	snapshots := append(rankedStoriesSnapshots[customerID], stories)
+	if len(snapshots) > RankedStoriesSnapshotCount {
+		snapshots = snapshots[len(snapshots)-RankedStoriesSnapshotCount:]
+	}
+	rankedStoriesSnapshots[customerID] = snapshots
+}
+
+/*DeleteRankedStories removes the ranked story ids of the customer from cache. Snapshots are kept so that the pages which are being read stay stable.*/
+func DeleteRankedStories(customerID int) {
+	rankedStoriesMutex.Lock()
+	defer rankedStoriesMutex.Unlock()
+	delete(rankedStories, customerID)
+}
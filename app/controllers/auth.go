@@ -3,16 +3,19 @@ package controllers
 import (
 	"fmt"
 	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/screening"
 	"linkwind/app/shared"
 	"linkwind/app/templates"
+	"linkwind/app/webhooks"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 )
 
 const authExpirationMinutes = 1440
 const userNameMaxCharCount = 15
+const maxUserAgentLength = 255
 
 /*SignInViewModel represents the data which is needed on sigin UI.*/
 type SignInViewModel struct {
@@ -23,11 +26,14 @@ type SignInViewModel struct {
 
 /*SignUpViewModel represents the data which is needed on sigup UI.*/
 type SignUpViewModel struct {
-	UserName   string
-	Email      string
-	Password   string
-	InviteCode string
-	Errors     map[string]string
+	UserName       string
+	Email          string
+	Password       string
+	InviteCode     string
+	InviteLink     string
+	Notice         string
+	SuccessMessage string
+	Errors         map[string]string
 }
 
 /*ResetPasswordViewModel represents the data which is needed on reset password UI*/
@@ -226,6 +232,51 @@ func handleSignInPOST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	banMessage, err := userBanMessage(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	if banMessage != "" {
+		model.Errors["General"] = banMessage
+		err = templates.RenderFile(w, "/layouts/users/signin.html", model)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	isPending, err := data.IsSignupPending(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	if isPending {
+		model.Errors["General"] = "Your account is waiting for the approval of an admin."
+		err = templates.RenderFile(w, "/layouts/users/signin.html", model)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	isUnconfirmed, err := data.IsEmailConfirmationPending(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	if isUnconfirmed {
+		model.Errors["General"] = "Please confirm your email address with the link which is sent to you."
+		err = templates.RenderFile(w, "/layouts/users/signin.html", model)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	signIn(w, r, user)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// signIn sets the auth cookie of the user and records the sign in to be listed to the admins
+func signIn(w http.ResponseWriter, r *http.Request, user *data.User) {
 	// Declare the expiration time of the token
 	// here, we have kept it as 5 minutes
 	expirationTime := time.Now().Add(authExpirationMinutes * time.Minute)
@@ -235,7 +286,21 @@ func handleSignInPOST(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 	shared.SetAuthCookie(w, token, expirationTime)
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	userAgent := []rune(r.UserAgent())
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	err = data.CreateUserSession(&data.UserSession{
+		UserID:     user.ID,
+		CustomerID: user.CustomerID,
+		SignedInOn: time.Now(),
+		ExpiresOn:  expirationTime,
+		IPAddress:  shared.GetIPAddress(r),
+		UserAgent:  string(userAgent),
+	})
+	if err != nil {
+		panic(err)
+	}
 }
 
 type findUser func(userNameOrEmail, password string) (*data.User, error)
@@ -269,32 +334,42 @@ func SignUpHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleSignUpGET(w http.ResponseWriter, r *http.Request) {
-	// Only invited users can create an account
+	// Only invited users can create an account unless the customer opens the signup
 	inviteCode := r.URL.Query().Get("invitecode")
-	if strings.TrimSpace(inviteCode) == "" {
-		templates.RenderFile(w, "layouts/users/forbidden-signup.html", &SignUpViewModel{})
-		return
-	}
-	invideCodeInfo, err := data.GetInviteCodeInfoByCode(inviteCode)
-	if err != nil {
-		panic(err)
-	}
-	if invideCodeInfo == nil {
-		http.Error(w, "Invite code could not be found!", http.StatusBadRequest)
-		panic(nil)
-	}
-	if invideCodeInfo.Used {
-		http.Error(w, "The invite code is already used!", http.StatusBadRequest)
-		return
+	inviteLink := r.URL.Query().Get("invitelink")
+	model := &SignUpViewModel{}
+	switch {
+	case strings.TrimSpace(inviteCode) != "":
+		invideCodeInfo, err := data.GetInviteCodeInfoByCode(inviteCode)
+		if err != nil {
+			panic(err)
+		}
+		if invideCodeInfo == nil {
+			http.Error(w, "Invite code could not be found!", http.StatusBadRequest)
+			return
+		}
+		if message := inviteCodeMessage(invideCodeInfo); message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		model.InviteCode = invideCodeInfo.Code
+		model.Email = invideCodeInfo.InvitedEmailAddress
+	case strings.TrimSpace(inviteLink) != "":
+		link, message := checkInviteLink(r, inviteLink)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		model.InviteLink = link.Code
+	default:
+		settings := getSignupSettings(r)
+		if settings.SignupMode != data.SignupAllowedDomains && settings.SignupMode != data.SignupApproval {
+			templates.RenderFile(w, "layouts/users/forbidden-signup.html", &SignUpViewModel{})
+			return
+		}
+		model.Notice = signupNotice(settings)
 	}
-	templates.RenderFile(
-		w,
-		"layouts/users/signup.html",
-		SignUpViewModel{
-			InviteCode: invideCodeInfo.Code,
-			Email:      invideCodeInfo.InvitedEmailAddress,
-		},
-	)
+	templates.RenderFile(w, "layouts/users/signup.html", model)
 }
 
 func handleSignUpPOST(w http.ResponseWriter, r *http.Request) {
@@ -307,34 +382,67 @@ func handleSignUpPOST(w http.ResponseWriter, r *http.Request) {
 		Email:      r.FormValue("email"),
 		Password:   r.FormValue("password"),
 		InviteCode: r.FormValue("inviteCode"),
+		InviteLink: r.FormValue("inviteLink"),
+	}
+	settings := getSignupSettings(r)
+	if model.InviteCode == "" && model.InviteLink == "" {
+		model.Notice = signupNotice(settings)
 	}
 	if model.Validate() == false {
 		templates.RenderFile(w, signUpHTMLPath, model)
 		return
 	}
-	if strings.TrimSpace(model.InviteCode) == "" {
+	// the customer of the new user is the customer of the inviter for invites and the requested customer otherwise
+	customerID := shared.GetCustomerFromContext(r).ID
+	isPending := false
+	// accounts which join because of their email domain must prove that they own the email address
+	needsConfirmation := false
+	var invitedCodeInfo *data.InviteCodeInfo
+	var inviteLink *data.InviteLink
+	switch {
+	case strings.TrimSpace(model.InviteCode) != "":
+		var err error
+		invitedCodeInfo, err = data.GetInviteCodeInfoByCode(model.InviteCode)
+		if err != nil {
+			panic(err)
+		}
+		if invitedCodeInfo == nil {
+			model.Errors["General"] = "Invite code could not be found. Please make sure that you have a valid invite code."
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+		if message := inviteCodeMessage(invitedCodeInfo); message != "" {
+			model.Errors["General"] = message
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+		if invitedCodeInfo.InvitedEmailAddress != model.Email {
+			model.Errors["General"] = "The email address you entered does not match the invited email address."
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+	case strings.TrimSpace(model.InviteLink) != "":
+		var message string
+		inviteLink, message = checkInviteLink(r, model.InviteLink)
+		if message != "" {
+			model.Errors["General"] = message
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+	case settings.SignupMode == data.SignupAllowedDomains:
+		if screening.MatchDomain(emailDomain(model.Email), settings.AllowedDomains) == "" {
+			model.Errors["Email"] = "Please sign up with an email address of " + strings.Join(settings.AllowedDomains, ", ") + "."
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+		needsConfirmation = true
+	case settings.SignupMode == data.SignupApproval:
+		isPending = true
+	default:
 		model.Errors["General"] = "Missing invite code!"
 		templates.RenderFile(w, signUpHTMLPath, model)
 		return
 	}
-	invitedCodeInfo, err := data.GetInviteCodeInfoByCode(model.InviteCode)
-	if err != nil {
-		panic(err)
-	}
-	if invitedCodeInfo == nil {
-		model.Errors["General"] = "Invite code could not be found. Please make sure that you have a valid invite code."
-		templates.RenderFile(w, signUpHTMLPath, model)
-		return
-	}
-	if invitedCodeInfo.Used {
-		model.Errors["General"] = "The invite code is already used!"
-		return
-	}
-	if invitedCodeInfo.InvitedEmailAddress != model.Email {
-		model.Errors["General"] = "The email address you entered does not match the invited email address."
-		templates.RenderFile(w, signUpHTMLPath, model)
-		return
-	}
 	exists, err := data.ExistsUserByUserName(model.UserName)
 	if err != nil {
 		panic(err)
@@ -353,14 +461,30 @@ func handleSignUpPOST(w http.ResponseWriter, r *http.Request) {
 		templates.RenderFile(w, signUpHTMLPath, model)
 		return
 	}
-	inviterUser, err := data.GetUserByID(invitedCodeInfo.InviterUserID)
-	if err != nil {
-		panic(err)
+	if invitedCodeInfo != nil {
+		inviterUser, err := data.GetUserByID(invitedCodeInfo.InviterUserID)
+		if err != nil {
+			panic(err)
+		}
+		if inviterUser == nil {
+			model.Errors["General"] = "The inviter user could not be found!"
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+		customerID = inviterUser.CustomerID
 	}
-	if inviterUser == nil {
-		model.Errors["General"] = "The inviter user could not be found!"
-		templates.RenderFile(w, signUpHTMLPath, model)
-		return
+	if inviteLink != nil {
+		used, err := data.UseInviteLink(inviteLink.Code)
+		if err != nil {
+			panic(err)
+		}
+		if !used {
+			model.Errors["General"] = "The invite link is not valid anymore."
+			templates.RenderFile(w, signUpHTMLPath, model)
+			return
+		}
+		customerID = inviteLink.CustomerID
+		model.InviteCode = inviteLink.Code
 	}
 	var user data.User
 	user.UserName = model.UserName
@@ -368,29 +492,171 @@ func handleSignUpPOST(w http.ResponseWriter, r *http.Request) {
 	user.Password = model.Password
 	user.Karma = 0
 	user.RegisteredOn = time.Now()
-	user.CustomerID = inviterUser.CustomerID
+	user.CustomerID = customerID
 	user.InviteCode = model.InviteCode
+	if invitedCodeInfo != nil {
+		user.FullName = invitedCodeInfo.FullName
+	}
 	userID, err := data.CreateUser(&user)
 	if err != nil {
 		panic(err)
 	}
 	user.ID = *userID
-	err = data.MarkInviteCodeAsUsed(model.InviteCode)
+	if invitedCodeInfo != nil {
+		err = data.MarkInviteCodeAsUsed(model.InviteCode)
+		if err != nil {
+			panic(err)
+		}
+		// imported invites may grant a role other than member
+		if invitedCodeInfo.Role != data.RoleMember {
+			err = data.SetUserRole(user.ID, invitedCodeInfo.Role)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	if isPending {
+		err = data.AddSignupRequest(customerID, user.ID)
+		if err != nil {
+			panic(err)
+		}
+		templates.RenderFile(w, signUpHTMLPath, &SignUpViewModel{
+			SuccessMessage: "Your account is created. You can sign in once an admin approves it.",
+		})
+		return
+	}
+	if needsConfirmation {
+		sendEmailConfirmationMail(r, &user)
+		templates.RenderFile(w, signUpHTMLPath, &SignUpViewModel{
+			SuccessMessage: "Your account is created. Please confirm your email address with the link which is sent to " + user.Email + ".",
+		})
+		return
+	}
+	webhooks.Emit(user.CustomerID, enums.UserJoined, webhooks.NewUserPayload(&user))
+	signIn(w, r, &user)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// sendEmailConfirmationMail mails the new user the link which activates the account
+func sendEmailConfirmationMail(r *http.Request, user *data.User) {
+	token, err := shared.GenerateEmailConfirmationToken()
 	if err != nil {
 		panic(err)
 	}
-	// Declare the expiration time of the token
-	// here, we have kept it as 5 minutes
-	expirationTime := time.Now().Add(authExpirationMinutes * time.Minute)
+	err = data.AddEmailConfirmation(user.ID, token)
+	if err != nil {
+		panic(err)
+	}
+	domain, err := data.GetCustomerDomainByUserName(user.UserName)
+	if err != nil {
+		panic(err)
+	}
+	err = shared.SendEmailConfirmationMail(shared.EmailConfirmationMailInfo{
+		Email:    user.Email,
+		UserName: user.UserName,
+		Domain:   domain,
+		Platform: shared.GetCustomerFromContext(r).Platform,
+		Token:    token,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
 
-	token, err := shared.GenerateAuthToken(user, expirationTime)
+/*ConfirmEmailHandler activates the account whose email confirmation link is clicked and signs it in*/
+func ConfirmEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if strings.TrimSpace(token) == "" {
+		http.Error(w, "Missing Token! ", http.StatusBadRequest)
+		return
+	}
+	userID, err := data.ConfirmEmail(token)
 	if err != nil {
 		panic(err)
 	}
-	shared.SetAuthCookie(w, token, expirationTime)
+	if userID == nil {
+		http.Error(w, "Token is not valid! ", http.StatusBadRequest)
+		return
+	}
+	user, err := data.GetUserByID(*userID)
+	if err != nil {
+		panic(err)
+	}
+	if user == nil {
+		http.Error(w, "Token is not valid! ", http.StatusBadRequest)
+		return
+	}
+	webhooks.Emit(user.CustomerID, enums.UserJoined, webhooks.NewUserPayload(user))
+	// the account is confirmed on every domain but only signed in on its own platform
+	if user.CustomerID != shared.GetCustomerFromContext(r).ID {
+		http.Redirect(w, r, "/signin", http.StatusSeeOther)
+		return
+	}
+	signIn(w, r, user)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// getSignupSettings returns the invite settings of the requested customer which decide how people can sign up
+func getSignupSettings(r *http.Request) *data.InviteSettings {
+	settings, err := data.GetInviteSettings(shared.GetCustomerFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	return settings
+}
+
+// signupNotice explains who can sign up without an invite. Returns empty string if the signup is invite only.
+func signupNotice(settings *data.InviteSettings) string {
+	switch settings.SignupMode {
+	case data.SignupAllowedDomains:
+		return "You can sign up with an email address of " + strings.Join(settings.AllowedDomains, ", ") + "."
+	case data.SignupApproval:
+		return "New accounts can sign in once an admin approves them."
+	}
+	return ""
+}
+
+// checkInviteLink returns the invite link of the requested customer and the message which explains why it cannot be used. The message is empty if it can be used.
+func checkInviteLink(r *http.Request, code string) (*data.InviteLink, string) {
+	link, err := data.GetInviteLinkByCode(code)
+	if err != nil {
+		panic(err)
+	}
+	if link == nil || link.CustomerID != shared.GetCustomerFromContext(r).ID {
+		return nil, "Invite link could not be found!"
+	}
+	if getSignupSettings(r).SignupMode != data.SignupInviteLinks {
+		return nil, "Invite links are disabled. Please ask for a new invite."
+	}
+	switch link.Status() {
+	case data.InviteUsed:
+		return nil, "The invite link reached its maximum number of uses!"
+	case data.InviteExpired:
+		return nil, "The invite link is expired. Please ask for a new invite."
+	case data.InviteRevoked:
+		return nil, "The invite link is revoked!"
+	}
+	return link, ""
+}
+
+// emailDomain returns the lowercased domain of the email address
+func emailDomain(email string) string {
+	return strings.ToLower(strings.TrimSpace(email[strings.LastIndex(email, "@")+1:]))
+}
+
+// inviteCodeMessage explains why the invite code cannot be used. Returns empty string if it is pending.
+func inviteCodeMessage(info *data.InviteCodeInfo) string {
+	switch info.Status() {
+	case data.InviteUsed:
+		return "The invite code is already used!"
+	case data.InviteExpired:
+		return "The invite code is expired. Please ask for a new invite."
+	case data.InviteRevoked:
+		return "The invite code is revoked!"
+	}
+	return ""
+}
+
 /*SignOutHandler handles user singout operations.*/
 func SignOutHandler(w http.ResponseWriter, r *http.Request) {
 	shared.SetAuthCookie(w, "", time.Now())
@@ -465,6 +731,15 @@ func handleResetPasswordPOST(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	sendResetPasswordMail(r, user.ID, userName, email)
+	err = templates.RenderFile(w, "layouts/users/reset-password.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sendResetPasswordMail mails the user a link which lets them set a new password
+func sendResetPasswordMail(r *http.Request, userID int, userName, email string) {
 	domain, err := data.GetCustomerDomainByUserName(userName)
 	if err != nil {
 		panic(err)
@@ -486,8 +761,7 @@ func handleResetPasswordPOST(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	data.SaveResetPasswordToken(token, user.ID)
-	err = templates.RenderFile(w, "layouts/users/reset-password.html", model)
+	err = data.SaveResetPasswordToken(token, userID)
 	if err != nil {
 		panic(err)
 	}
@@ -637,19 +911,6 @@ func handleChangePasswordPOST(w http.ResponseWriter, r *http.Request, userID int
 	}
 }
 
-/*GenerateInviteCodeHandler generate the invite code to invite an user to join the system*/
-func GenerateInviteCodeHandler(w http.ResponseWriter, r *http.Request) {
-	inviterUserID, _ := strconv.Atoi(r.URL.Query().Get("userid"))
-	invitedEmail := r.URL.Query().Get("invitedemail")
-	inviteCode, err := data.CreateInviteCode(inviterUserID, invitedEmail)
-	if err != nil {
-		panic(err)
-	}
-	w.WriteHeader(200)
-	w.Header().Add("Content-Type", "text/plain")
-	w.Write([]byte(inviteCode))
-}
-
 // SetAuthTokenHandler sets the auth cookie and redirect user to main page
 func SetAuthTokenHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
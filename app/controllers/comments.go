@@ -3,11 +3,19 @@ package controllers
 import (
 	"encoding/json"
 	"fmt"
+	"linkwind/app/caching"
 	"linkwind/app/data"
 	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/ranking"
+	"linkwind/app/screening"
 	"linkwind/app/shared"
 	"linkwind/app/templates"
+	"linkwind/app/webhooks"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,7 +26,6 @@ import (
 /*CommentVoteModel represents the data in http request body to upvote comment.*/
 type CommentVoteModel struct {
 	CommentID int
-	UserID    int
 	VoteType  enums.VoteType
 }
 
@@ -35,6 +42,9 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Only POST method is supported.", http.StatusMethodNotAllowed)
 		return
 	}
+	if !ensureNotBanned(w, r) {
+		return
+	}
 	signedInUser := shared.GetUser(r)
 	commentText := r.FormValue("comment")
 	strStoryID := r.FormValue("storyID")
@@ -49,6 +59,10 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		sentry.CaptureException(err)
 		panic(err)
 	}
+	if message := checkCommentRules(r, commentText); message != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
 	comment := &data.Comment{
 		StoryID:     storyID,
 		UserID:      signedInUser.ID,
@@ -60,11 +74,22 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		Comment:     commentText,
 		CommentedOn: time.Now(),
 	}
-	_, err = data.WriteComment(comment)
+	content := &screening.Content{Type: data.ReportTargetComment, Text: comment.Comment}
+	verdict := screenContent(r, content)
+	comment.Hidden = verdict.Held()
+	commentID, err := data.WriteComment(comment)
 	if err != nil {
 		sentry.CaptureException(err)
 		panic(err)
 	}
+	comment.ID = *commentID
+	customerCtx := shared.GetCustomerFromContext(r)
+	caching.InvalidatePages(customerCtx.ID)
+	if comment.Hidden {
+		holdContent(content, verdict, comment.ID, comment.StoryID)
+	} else {
+		emitContentCreated(customerCtx.ID, comment.UserID, enums.CommentCreated, webhooks.NewCommentPayload(comment))
+	}
 	http.Redirect(w, r, storyURL, http.StatusSeeOther)
 }
 
@@ -75,6 +100,9 @@ func ReplyToCommentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unsupported method. Only post method is supported.", http.StatusMethodNotAllowed)
 		return
 	}
+	if !ensureNotBanned(w, r) {
+		return
+	}
 	var model ReplyModel
 	err := json.NewDecoder(r.Body).Decode(&model)
 	if err != nil {
@@ -82,6 +110,10 @@ func ReplyToCommentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Cannot parse json.", http.StatusBadRequest)
 		return
 	}
+	if message := checkCommentRules(r, model.ReplyText); message != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
 	comment := &data.Comment{
 		UserID:      user.ID,
 		UserName:    user.UserName,
@@ -93,6 +125,9 @@ func ReplyToCommentHandler(w http.ResponseWriter, r *http.Request) {
 		DownVotes:   0,
 		ReplyCount:  0,
 	}
+	content := &screening.Content{Type: data.ReportTargetComment, Text: comment.Comment}
+	verdict := screenContent(r, content)
+	comment.Hidden = verdict.Held()
 	commentID, err := data.WriteComment(comment)
 	if err != nil {
 		sentry.CaptureException(err)
@@ -100,8 +135,15 @@ func ReplyToCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	comment.ID = *commentID
+	customerCtx := shared.GetCustomerFromContext(r)
+	caching.InvalidatePages(customerCtx.ID)
+	if comment.Hidden {
+		holdContent(content, verdict, comment.ID, comment.StoryID)
+	} else {
+		emitContentCreated(customerCtx.ID, comment.UserID, enums.CommentCreated, webhooks.NewCommentPayload(comment))
+	}
 	output, err := templates.RenderAsString("partials/comment.html", "comment",
-		mapCommentToCommentViewModel(comment, user))
+		mapCommentToCommentViewModel(comment, user, nil))
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Cannot render comment template. Error: %v", err), http.StatusInternalServerError)
@@ -125,8 +167,20 @@ func VoteCommentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	userID := shared.GetUserFromContext(r).ID
 
-	voteType, err := data.GetCommentVoteByUser(model.UserID, model.CommentID)
+	customerCtx := shared.GetCustomerFromContext(r)
+	message, err := checkVoteRules(customerCtx.ID, userID)
+	if err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("Error occured while checking vote rules. UserID: %d, Error : %v", userID, err), http.StatusInternalServerError)
+		return
+	}
+	if message != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+	voteType, err := data.GetCommentVoteByUser(userID, model.CommentID)
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while checking if user already upvoted. Error : %v", err), http.StatusInternalServerError)
@@ -143,18 +197,19 @@ func VoteCommentHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		//Remove comment's previous vote given by user to make sure that there can be only one type of vote at a time
-		err = data.RemoveCommentVote(model.UserID, model.CommentID, *voteType)
+		err = data.RemoveCommentVote(userID, model.CommentID, *voteType)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, CommentID: %d, VoteType: %d,  Error : %v", model.UserID, model.CommentID, *voteType, err), http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, CommentID: %d, VoteType: %d,  Error : %v", userID, model.CommentID, *voteType, err), http.StatusInternalServerError)
 			return
 		}
 	}
-	err = data.VoteComment(model.UserID, model.CommentID, model.VoteType)
+	err = data.VoteComment(userID, model.CommentID, model.VoteType, shared.GetIPAddress(r))
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while upvoting story. Error : %v", err), http.StatusInternalServerError)
 		return
 	}
+	webhooks.Emit(customerCtx.ID, enums.VoteCast, webhooks.NewVotePayload("comment", model.CommentID, userID, model.VoteType))
 	res, _ := json.Marshal(&JSONResponse{
 		Result: "Voted",
 	})
@@ -176,7 +231,8 @@ func RemoveCommentVoteHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	voteType, err := data.GetCommentVoteByUser(model.UserID, model.CommentID)
+	userID := shared.GetUserFromContext(r).ID
+	voteType, err := data.GetCommentVoteByUser(userID, model.CommentID)
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while checking user story vote. Error : %v", err), http.StatusInternalServerError)
@@ -192,7 +248,7 @@ func RemoveCommentVoteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = data.RemoveCommentVote(model.UserID, model.CommentID, model.VoteType)
+	err = data.RemoveCommentVote(userID, model.CommentID, *voteType)
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while unvoting story. Error : %v", err), http.StatusInternalServerError)
@@ -205,3 +261,208 @@ func RemoveCommentVoteHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(res)
 }
+
+// commentTree assembles the comments of a story which are loaded in one query
+type commentTree struct {
+	comments   map[int]*data.Comment
+	children   map[int][]*data.Comment
+	votes      map[int]enums.VoteType
+	userClaims *shared.SignedInUserClaims
+	maxDepth   int
+	lastVisit  *time.Time
+	newCount   int
+}
+
+func newCommentTree(comments *[]data.Comment, userClaims *shared.SignedInUserClaims, maxDepth int) *commentTree {
+	tree := &commentTree{
+		comments:   make(map[int]*data.Comment, len(*comments)),
+		children:   make(map[int][]*data.Comment),
+		userClaims: userClaims,
+		maxDepth:   maxDepth,
+	}
+	ids := make([]int, 0, len(*comments))
+	shadowbanned := map[int]bool{}
+	for i := range *comments {
+		comment := &(*comments)[i]
+		// comments of shadowbanned users are left out with their replies for everyone but their authors
+		if shadowbanned[comment.ParentID] || (comment.AuthorShadowbanned && (userClaims == nil || userClaims.ID != comment.UserID)) {
+			shadowbanned[comment.ID] = true
+			continue
+		}
+		tree.comments[comment.ID] = comment
+		tree.children[comment.ParentID] = append(tree.children[comment.ParentID], comment)
+		ids = append(ids, comment.ID)
+	}
+	if userClaims != nil && len(ids) > 0 {
+		votes, err := data.GetCommentVotesByUser(userClaims.ID, ids)
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+		tree.votes = votes
+	}
+	return tree
+}
+
+// sort orders the replies of every comment in place
+func (tree *commentTree) sort(commentSort enums.CommentSort) {
+	for _, comments := range tree.children {
+		sortComments(comments, commentSort)
+	}
+}
+
+// markNewSince marks the comments of the other users which are written after the last visit. Nothing is new on the first visit.
+func (tree *commentTree) markNewSince(lastVisit *time.Time) {
+	tree.lastVisit = lastVisit
+	tree.newCount = 0
+	for _, comment := range tree.comments {
+		if tree.isNew(comment) {
+			tree.newCount++
+		}
+	}
+}
+
+func (tree *commentTree) isNew(comment *data.Comment) bool {
+	if tree.lastVisit == nil || tree.userClaims == nil || comment.UserID == tree.userClaims.ID {
+		return false
+	}
+	return comment.CommentedOn.After(*tree.lastVisit)
+}
+
+// countReplies returns the number of all replies under the comment
+func (tree *commentTree) countReplies(commentID int) int {
+	count := 0
+	for _, reply := range tree.children[commentID] {
+		count += 1 + tree.countReplies(reply.ID)
+	}
+	return count
+}
+
+func (tree *commentTree) find(commentID int) *data.Comment {
+	return tree.comments[commentID]
+}
+
+// build maps the comments and their replies to view models. Replies deeper than the max depth are replaced with a continue this thread link.
+func (tree *commentTree) build(comments []*data.Comment, depth int) *[]models.CommentViewModel {
+	viewModels := []models.CommentViewModel{}
+	for _, comment := range comments {
+		var voteType *enums.VoteType
+		if vote, ok := tree.votes[comment.ID]; ok {
+			voteType = &vote
+		}
+		viewModel := mapCommentToCommentViewModel(comment, tree.userClaims, voteType)
+		viewModel.Depth = depth
+		viewModel.IsRoot = depth == 0
+		viewModel.IsNew = tree.isNew(comment)
+		if comment.UpVotes-comment.DownVotes <= CollapsedCommentScore {
+			viewModel.IsCollapsed = true
+			viewModel.HiddenReplyCount = tree.countReplies(comment.ID)
+		}
+		replies := tree.children[comment.ID]
+		if len(replies) > 0 {
+			if depth+1 < tree.maxDepth {
+				viewModel.ChildComments = *tree.build(replies, depth+1)
+			} else {
+				viewModel.ContinueThreadURL = commentThreadURL(comment.StoryID, comment.ID)
+			}
+		}
+		viewModels = append(viewModels, *viewModel)
+	}
+	return &viewModels
+}
+
+// commentThreadURL returns the url of the story page which shows only given comment and its replies
+func commentThreadURL(storyID, commentID int) string {
+	if commentID == data.CommentRootID {
+		return fmt.Sprintf("/stories/detail?id=%d", storyID)
+	}
+	return fmt.Sprintf("/stories/detail?id=%d&thread=%d", storyID, commentID)
+}
+
+func getMaxCommentDepth() int {
+	depth, err := strconv.Atoi(os.Getenv("MAX_COMMENT_DEPTH"))
+	if err != nil || depth < 1 {
+		return DefaultMaxCommentDepth
+	}
+	return depth
+}
+
+func sortComments(comments []*data.Comment, commentSort enums.CommentSort) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		switch commentSort {
+		case enums.NewestComments:
+			return a.CommentedOn.After(b.CommentedOn)
+		case enums.OldestComments:
+			return a.CommentedOn.Before(b.CommentedOn)
+		case enums.ControversialComments:
+			return ranking.Controversy(a.UpVotes, a.DownVotes) > ranking.Controversy(b.UpVotes, b.DownVotes)
+		default:
+			return ranking.WilsonLowerBound(a.UpVotes, a.DownVotes) > ranking.WilsonLowerBound(b.UpVotes, b.DownVotes)
+		}
+	})
+}
+
+func isValidCommentSort(commentSort enums.CommentSort) bool {
+	for _, available := range enums.CommentSorts {
+		if available == commentSort {
+			return true
+		}
+	}
+	return false
+}
+
+// getCommentSort returns the order in the query string and remembers it for signed in users. Falls back to the order which user selected last.
+func getCommentSort(r *http.Request, userClaims *shared.SignedInUserClaims) enums.CommentSort {
+	selected := enums.CommentSort(r.URL.Query().Get("sort"))
+	if isValidCommentSort(selected) {
+		if userClaims != nil {
+			err := data.SaveCommentSort(userClaims.ID, selected)
+			if err != nil {
+				sentry.CaptureException(err)
+			}
+		}
+		return selected
+	}
+	if userClaims == nil {
+		return enums.BestComments
+	}
+	commentSort, err := data.GetCommentSortByUserID(userClaims.ID)
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+	if !isValidCommentSort(commentSort) {
+		return enums.BestComments
+	}
+	return commentSort
+}
+
+// getLastStoryVisit returns the previous visit of the user to the story page and records the current one
+func getLastStoryVisit(userID, storyID int) *time.Time {
+	lastVisit, err := data.GetStoryVisit(userID, storyID)
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+	err = data.SaveStoryVisit(userID, storyID, time.Now())
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+	return lastVisit
+}
+
+func newCommentSortViewModels(storyID int, threadID string, selected enums.CommentSort) []models.CommentSortViewModel {
+	viewModels := []models.CommentSortViewModel{}
+	for _, commentSort := range enums.CommentSorts {
+		query := url.Values{}
+		query.Set("id", strconv.Itoa(storyID))
+		if threadID != "" {
+			query.Set("thread", threadID)
+		}
+		query.Set("sort", string(commentSort))
+		viewModels = append(viewModels, models.CommentSortViewModel{
+			Name:       string(commentSort),
+			URL:        "/stories/detail?" + query.Encode(),
+			IsSelected: commentSort == selected,
+		})
+	}
+	return viewModels
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"html/template"
 	"io/ioutil"
 	cache "linkwind/app/caching"
 	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/jobs"
 	"linkwind/app/models"
 	"linkwind/app/shared"
 	"linkwind/app/templates"
@@ -200,11 +203,13 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleInviteUserGET(w http.ResponseWriter, r *http.Request) {
+	model := &models.InviteUserViewModel{}
+	model.Restriction, model.Remaining = newInviteCheck(r).quota()
 	err := templates.RenderInLayout(
 		w,
 		r,
 		"invite.html",
-		&models.InviteUserViewModel{},
+		model,
 	)
 	if err != nil {
 		panic(err)
@@ -218,6 +223,15 @@ func handleInviteUserPOST(w http.ResponseWriter, r *http.Request) {
 	}
 
 	inviteHTMLPath := "invite.html"
+	check := newInviteCheck(r)
+	model.Restriction, model.Remaining = check.quota()
+	if model.Restriction != "" {
+		err := templates.RenderInLayout(w, r, inviteHTMLPath, model)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
 	isValid, err := model.Validate(model.EmailAddress)
 	if err != nil {
 		panic(err)
@@ -231,31 +245,14 @@ func handleInviteUserPOST(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := shared.GetUserFromContext(r)
-	customer := shared.GetCustomerFromContext(r)
-
-	inviteCode, err := data.CreateInviteCode(user.ID, model.EmailAddress)
-	if err != nil {
-		panic(err)
-	}
-
-	domain, err := data.GetCustomerDomainByUserName(user.UserName)
-	if err != nil {
-		panic(err)
-	}
 
-	m := shared.InviteMailInfo{
-		Domain:     domain,
-		InviteCode: inviteCode,
-		Email:      model.EmailAddress,
-		UserName:   user.UserName,
-		Memo:       model.Memo,
-		Platform:   customer.Platform,
-	}
-	err = shared.SendEmailInvitation(m)
+	inviteCode, err := data.CreateInviteCode(user.ID, model.EmailAddress, check.expiresOn())
 	if err != nil {
 		panic(err)
 	}
+	sendInviteMail(r, inviteCode, model.EmailAddress, model.Memo)
 	model.SuccessMessage = "Inivitation mail successfully sent to " + model.EmailAddress
+	model.Restriction, model.Remaining = check.quota()
 	err = templates.RenderInLayout(w, r, inviteHTMLPath, model)
 	if err != nil {
 		panic(err)
@@ -529,3 +526,62 @@ func getImage(customer *data.Customer, r *http.Request) string {
 	}
 	return logoImageAsBase64
 }
+
+/*DigestPreviewHandler handles showing the digest mail which will be sent to the subscribers*/
+func DigestPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	customer, err := data.GetCustomerByID(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+
+	frequency := enums.DigestDaily
+	if r.URL.Query().Get("period") == "weekly" {
+		frequency = enums.DigestWeekly
+	}
+	stories, err := jobs.GetDigestStories(customer.ID, frequency, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	var domain *string
+	if customer.Domain != data.CustomerDefaultDomain {
+		domain = &customer.Domain
+	}
+	periodName, _ := jobs.DigestPeriod(frequency)
+	content := shared.GenerateDigestMailBody(shared.DigestMailInfo{
+		UserName:   user.UserName,
+		Domain:     domain,
+		Platform:   customer.Name,
+		PeriodName: periodName,
+		Stories:    *stories,
+	})
+	model := &models.DigestPreviewViewModel{
+		PeriodName: periodName,
+		Content:    template.HTML(content),
+	}
+	err = templates.RenderInLayout(w, r, "digest-preview.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ensureAdmin checks whether signed in user is admin of the customer. If not, it renders the error page and returns false.
+func ensureAdmin(w http.ResponseWriter, r *http.Request) bool {
+	user := shared.GetUserFromContext(r)
+	isAdmin, err := data.IsUserAdmin(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	if !isAdmin {
+		err = templates.RenderFile(w, "errors/500.html", nil)
+		if err != nil {
+			panic(err)
+		}
+		return false
+	}
+	return true
+}
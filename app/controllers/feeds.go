@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"linkwind/app/data"
+	"linkwind/app/feeds"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	/*FeedItemCount represents the number of items listed in a feed*/
+	FeedItemCount = 30
+	feedMaxAge    = 300
+)
+
+/*StoriesFeedHandler handles the feed of the popular published stories*/
+func StoriesFeedHandler(w http.ResponseWriter, r *http.Request) {
+	writeStoriesFeed("Stories", "/", data.GetStories, w, r)
+}
+
+/*RecentStoriesFeedHandler handles the feed of recently published stories*/
+func RecentStoriesFeedHandler(w http.ResponseWriter, r *http.Request) {
+	writeStoriesFeed("Recent Stories", "/recent", data.GetRecentStories, w, r)
+}
+
+func writeStoriesFeed(title, path string, fnGetStories getStoriesPaged, w http.ResponseWriter, r *http.Request) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	stories, err := fnGetStories(customerCtx.ID, 1, FeedItemCount)
+	if err != nil {
+		panic(err)
+	}
+	feed := newStoriesFeed(r, fmt.Sprintf("%s | %s", title, strings.Title(customerCtx.Platform)), path, stories)
+	writeFeed(w, r, feed)
+}
+
+/*UserStoriesFeedHandler handles the feed of the stories submitted by a user*/
+func UserStoriesFeedHandler(w http.ResponseWriter, r *http.Request) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	userID, err := strconv.Atoi(r.URL.Query().Get("userid"))
+	if err != nil {
+		renderNotFound(w)
+		return
+	}
+	exists, err := data.ExistsUserInCustomer(customerCtx.ID, userID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	user, err := data.GetUserByID(userID)
+	if err != nil {
+		panic(err)
+	}
+	stories, err := data.GetUserSubmittedStories(userID, 0, 1, FeedItemCount)
+	if err != nil {
+		panic(err)
+	}
+	feed := newStoriesFeed(
+		r,
+		fmt.Sprintf("Stories by %s | %s", user.UserName, strings.Title(customerCtx.Platform)),
+		fmt.Sprintf("/users/stories/submitted?userid=%d", userID),
+		stories)
+	writeFeed(w, r, feed)
+}
+
+/*StoryCommentsFeedHandler handles the feed of the comments written for a story*/
+func StoryCommentsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	storyID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		renderNotFound(w)
+		return
+	}
+	exists, err := data.ExistsStoryInCustomer(customerCtx.ID, storyID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	story, err := data.GetStoryByID(storyID)
+	if err != nil {
+		panic(err)
+	}
+	// feeds are read without signing in, so hidden stories are not served to anyone
+	if story.Hidden || story.AuthorShadowbanned {
+		renderNotFound(w)
+		return
+	}
+	comments, err := data.GetRecentCommentsByStoryID(storyID, FeedItemCount)
+	if err != nil {
+		panic(err)
+	}
+	baseURL := getBaseURL(r)
+	storyLink := fmt.Sprintf("%s/stories/detail?id=%d", baseURL, story.ID)
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("Comments on %s | %s", story.Title, strings.Title(customerCtx.Platform)),
+		Description: fmt.Sprintf("Latest comments on %s", story.Title),
+		Link:        storyLink,
+		SelfLink:    baseURL + r.URL.RequestURI(),
+		Updated:     story.SubmittedOn,
+	}
+	for _, comment := range *comments {
+		if comment.CommentedOn.After(feed.Updated) {
+			feed.Updated = comment.CommentedOn
+		}
+		feed.Items = append(feed.Items, feeds.Item{
+			ID:          fmt.Sprintf("%s/comments/%d", baseURL, comment.ID),
+			Title:       fmt.Sprintf("%s on %s", comment.UserName, story.Title),
+			Link:        storyLink,
+			Description: strings.Replace(html.EscapeString(comment.Comment), "\n", "<br>", -1),
+			Author:      comment.UserName,
+			Published:   comment.CommentedOn,
+		})
+	}
+	writeFeed(w, r, feed)
+}
+
+func newStoriesFeed(r *http.Request, title, path string, stories *[]data.Story) *feeds.Feed {
+	baseURL := getBaseURL(r)
+	feed := &feeds.Feed{
+		Title:       title,
+		Description: title,
+		Link:        baseURL + path,
+		SelfLink:    baseURL + r.URL.RequestURI(),
+	}
+	for _, story := range *stories {
+		detailLink := fmt.Sprintf("%s/stories/detail?id=%d", baseURL, story.ID)
+		link := story.URL
+		if link == "" {
+			link = detailLink
+		}
+		description := html.EscapeString(story.Text)
+		if description != "" {
+			description += "<br><br>"
+		}
+		description += fmt.Sprintf(`<a href="%s">%d comments</a>`, detailLink, story.CommentCount)
+		if story.SubmittedOn.After(feed.Updated) {
+			feed.Updated = story.SubmittedOn
+		}
+		feed.Items = append(feed.Items, feeds.Item{
+			ID:          detailLink,
+			Title:       story.Title,
+			Link:        link,
+			Description: description,
+			Author:      story.UserName,
+			Published:   story.SubmittedOn,
+		})
+	}
+	return feed
+}
+
+func writeFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed) {
+	var body []byte
+	var err error
+	contentType := feeds.RSSContentType
+	if r.URL.Query().Get("format") == "atom" {
+		contentType = feeds.AtomContentType
+		body, err = feed.Atom()
+	} else {
+		body, err = feed.RSS()
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(body))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", feedMaxAge))
+	if !feed.Updated.IsZero() {
+		w.Header().Set("Last-Modified", feed.Updated.UTC().Format(http.TimeFormat))
+	}
+	if isFeedNotModified(r, etag, feed.Updated) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+func isFeedNotModified(r *http.Request, etag string, updated time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || updated.IsZero() {
+		return false
+	}
+	return !updated.Truncate(time.Second).After(since)
+}
+
+func getBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func newFeedViewModel(title, feedPath string) *models.FeedViewModel {
+	separator := "?"
+	if strings.Contains(feedPath, "?") {
+		separator = "&"
+	}
+	return &models.FeedViewModel{
+		Title:   title,
+		RSSURL:  feedPath,
+		AtomURL: feedPath + separator + "format=atom",
+	}
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseFormCount parses the number which is typed into a settings form. Returns -1 for invalid values so that validation fails.
+func parseFormCount(value string) int {
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return -1
+	}
+	return count
+}
@@ -0,0 +1,459 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"linkwind/app/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+var signupModes = []string{data.SignupInviteOnly, data.SignupInviteLinks, data.SignupAllowedDomains, data.SignupApproval}
+
+const (
+	inviteQuotaWindow    = 30 * 24 * time.Hour
+	inviteResendInterval = time.Hour
+	inviteListSize       = 100
+)
+
+/*MyInvitesHandler handles the invites which the signed in user sent. Pending and expired invites can be sent again and pending ones can be revoked.*/
+func MyInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := &models.MyInvitesViewModel{}
+	if r.Method == "POST" {
+		err := r.ParseForm()
+		if err != nil {
+			panic(err)
+		}
+		info, err := data.GetInviteCodeInfoByCode(r.FormValue("code"))
+		if err != nil {
+			panic(err)
+		}
+		if info == nil || info.InviterUserID != user.ID {
+			renderNotFound(w)
+			return
+		}
+		switch r.FormValue("action") {
+		case "resend":
+			model.SuccessMessage, model.ErrorMessage = resendInvite(r, info)
+		case "revoke":
+			model.SuccessMessage, model.ErrorMessage = revokeInvite(info)
+		default:
+			http.Error(w, "Invalid invite action.", http.StatusBadRequest)
+			return
+		}
+	}
+	invites, err := data.GetInviteCodesByInviter(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	model.Invites = mapInvitesToInviteViewModels(invites)
+	model.Restriction, model.Remaining = newInviteCheck(r).quota()
+	err = templates.RenderInLayout(w, r, "invites.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*InviteSettingsHandler handles how people can sign up to the customer, the invite expiry and quotas, the invites which its members sent, the invite links and the signup requests*/
+func InviteSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		renderInviteSettings(w, r, newInviteSettingsViewModel(r))
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	switch r.FormValue("action") {
+	case "revoke":
+		handleAdminRevokeInvitePOST(w, r)
+	case "createlink":
+		handleCreateInviteLinkPOST(w, r)
+	case "revokelink":
+		err = data.RevokeInviteLink(user.CustomerID, r.FormValue("code"))
+		if err != nil {
+			panic(err)
+		}
+		model := newInviteSettingsViewModel(r)
+		model.SuccessMessage = "The invite link is revoked."
+		renderInviteSettings(w, r, model)
+	case "approve", "reject":
+		handleReviewSignupPOST(w, r)
+	default:
+		handleInviteSettingsPOST(w, r)
+	}
+}
+
+func handleInviteSettingsPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := newInviteSettingsViewModel(r)
+	model.ExpiryDays = parseFormCount(r.FormValue("expirydays"))
+	model.MemberInvitesPerMonth = parseFormCount(r.FormValue("memberinvitespermonth"))
+	model.AdminInvitesPerMonth = parseFormCount(r.FormValue("admininvitespermonth"))
+	model.MinInviteKarma = parseFormCount(r.FormValue("mininvitekarma"))
+	model.KarmaPerExtraInvite = parseFormCount(r.FormValue("karmaperextrainvite"))
+	model.SignupMode = r.FormValue("signupmode")
+	model.AllowedDomains = r.FormValue("alloweddomains")
+	if model.Validate() == false {
+		renderInviteSettings(w, r, model)
+		return
+	}
+	err := data.SaveInviteSettings(&data.InviteSettings{
+		CustomerID:            user.CustomerID,
+		ExpiryDays:            model.ExpiryDays,
+		MemberInvitesPerMonth: model.MemberInvitesPerMonth,
+		AdminInvitesPerMonth:  model.AdminInvitesPerMonth,
+		MinInviteKarma:        model.MinInviteKarma,
+		KarmaPerExtraInvite:   model.KarmaPerExtraInvite,
+		SignupMode:            model.SignupMode,
+		AllowedDomains:        model.DomainList(),
+		UpdatedOn:             time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	model = newInviteSettingsViewModel(r)
+	model.SuccessMessage = "Invite settings are saved."
+	renderInviteSettings(w, r, model)
+}
+
+func handleAdminRevokeInvitePOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	info, err := data.GetInviteCodeInfoByCode(r.FormValue("code"))
+	if err != nil {
+		panic(err)
+	}
+	if info == nil {
+		renderNotFound(w)
+		return
+	}
+	exists, err := data.ExistsUserInCustomer(user.CustomerID, info.InviterUserID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	successMessage, errorMessage := revokeInvite(info)
+	model := newInviteSettingsViewModel(r)
+	model.SuccessMessage = successMessage
+	if errorMessage != "" {
+		model.Errors["General"] = errorMessage
+	}
+	renderInviteSettings(w, r, model)
+}
+
+func handleCreateInviteLinkPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := newInviteSettingsViewModel(r)
+	model.LinkMaxUses = parseFormCount(r.FormValue("linkmaxuses"))
+	model.LinkExpiryDays = parseFormCount(r.FormValue("linkexpirydays"))
+	if model.ValidateInviteLink() == false {
+		renderInviteSettings(w, r, model)
+		return
+	}
+	link := &data.InviteLink{
+		CustomerID: user.CustomerID,
+		CreatedBy:  user.ID,
+		CreatedOn:  time.Now(),
+		MaxUses:    model.LinkMaxUses,
+	}
+	if model.LinkExpiryDays > 0 {
+		expiresOn := time.Now().Add(time.Duration(model.LinkExpiryDays) * 24 * time.Hour)
+		link.ExpiresOn = &expiresOn
+	}
+	err := data.CreateInviteLink(link)
+	if err != nil {
+		panic(err)
+	}
+	model = newInviteSettingsViewModel(r)
+	model.SuccessMessage = "The invite link is created."
+	if model.SignupMode != data.SignupInviteLinks {
+		model.SuccessMessage += " Select the invite links signup mode to let people join with it."
+	}
+	renderInviteSettings(w, r, model)
+}
+
+func handleReviewSignupPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	userID, _ := strconv.Atoi(r.FormValue("userid"))
+	exists, err := data.ExistsUserInCustomer(user.CustomerID, userID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	requester, err := data.GetUserByID(userID)
+	if err != nil {
+		panic(err)
+	}
+	var reviewed bool
+	var message string
+	entry := &data.ModerationLogEntry{}
+	if r.FormValue("action") == "approve" {
+		reviewed, err = data.ApproveSignupRequest(user.CustomerID, requester.ID)
+		message = fmt.Sprintf("%s can sign in now.", requester.UserName)
+		entry.Action = data.ModerationSignupApproved
+		entry.TargetUserID = requester.ID
+	} else {
+		reviewed, err = data.RejectSignupRequest(user.CustomerID, requester.ID)
+		message = fmt.Sprintf("The account of %s is deleted.", requester.UserName)
+		// the rejected account is deleted so the log keeps its name only
+		entry.Action = data.ModerationSignupRejected
+		entry.Target = requester.UserName
+	}
+	if err != nil {
+		panic(err)
+	}
+	if !reviewed {
+		renderNotFound(w)
+		return
+	}
+	logModeration(r, entry)
+	if entry.Action == data.ModerationSignupApproved {
+		webhooks.Emit(requester.CustomerID, enums.UserJoined, webhooks.NewUserPayload(requester))
+		notifyApprovedUser(r, requester)
+	}
+	model := newInviteSettingsViewModel(r)
+	model.SuccessMessage = message
+	renderInviteSettings(w, r, model)
+}
+
+// notifyApprovedUser mails the user whose signup request is approved. A failed mail is reported to sentry and does not fail the approval.
+func notifyApprovedUser(r *http.Request, requester *data.User) {
+	domain, err := data.GetCustomerDomainByUserName(requester.UserName)
+	if err != nil {
+		panic(err)
+	}
+	err = shared.SendSignupApprovedMail(shared.SignupApprovedMailInfo{
+		Email:    requester.Email,
+		UserName: requester.UserName,
+		Domain:   domain,
+		Platform: shared.GetCustomerFromContext(r).Platform,
+	})
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+}
+
+func newInviteSettingsViewModel(r *http.Request) *models.InviteSettingsViewModel {
+	user := shared.GetUserFromContext(r)
+	settings, err := data.GetInviteSettings(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	invites, err := data.GetInviteCodesByCustomer(user.CustomerID, inviteListSize)
+	if err != nil {
+		panic(err)
+	}
+	links, err := data.GetInviteLinks(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	requests, err := data.GetSignupRequests(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	signupURL := getSignupURL(r)
+	linkViewModels := []models.InviteLinkViewModel{}
+	for _, link := range *links {
+		viewModel := models.InviteLinkViewModel{
+			Code:          link.Code,
+			URL:           signupURL + "?invitelink=" + link.Code,
+			CreatedByName: link.CreatedByName,
+			CreatedOn:     link.CreatedOn.Format(postingTimeLayout),
+			MaxUses:       link.MaxUses,
+			UseCount:      link.UseCount,
+			Status:        link.Status(),
+			CanRevoke:     link.Status() == data.InvitePending,
+		}
+		if link.ExpiresOn != nil {
+			viewModel.ExpiresOn = link.ExpiresOn.Format(postingTimeLayout)
+		}
+		linkViewModels = append(linkViewModels, viewModel)
+	}
+	return &models.InviteSettingsViewModel{
+		ExpiryDays:            settings.ExpiryDays,
+		MemberInvitesPerMonth: settings.MemberInvitesPerMonth,
+		AdminInvitesPerMonth:  settings.AdminInvitesPerMonth,
+		MinInviteKarma:        settings.MinInviteKarma,
+		KarmaPerExtraInvite:   settings.KarmaPerExtraInvite,
+		SignupMode:            settings.SignupMode,
+		SignupModes:           signupModes,
+		AllowedDomains:        strings.Join(settings.AllowedDomains, "\n"),
+		Invites:               mapInvitesToInviteViewModels(invites),
+		InviteLinks:           linkViewModels,
+		SignupRequests:        *requests,
+		Errors:                make(map[string]string),
+	}
+}
+
+// getSignupURL returns the address of the signup page of the signed in user's customer
+func getSignupURL(r *http.Request) string {
+	domain, err := data.GetCustomerDomainByUserName(shared.GetUserFromContext(r).UserName)
+	if err != nil {
+		panic(err)
+	}
+	host := shared.GetCustomerFromContext(r).Platform + ".linkwind.co"
+	if domain != nil {
+		host = *domain
+	}
+	return "https://" + host + "/signup"
+}
+
+func renderInviteSettings(w http.ResponseWriter, r *http.Request, model *models.InviteSettingsViewModel) {
+	err := templates.RenderInLayout(w, r, "invite-settings.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func mapInvitesToInviteViewModels(invites *[]data.InviteCodeInfo) []models.InviteViewModel {
+	viewModels := []models.InviteViewModel{}
+	for _, invite := range *invites {
+		viewModel := models.InviteViewModel{
+			Code:            invite.Code,
+			Email:           invite.InvitedEmailAddress,
+			InviterUserName: invite.InviterUserName,
+			Status:          invite.Status(),
+			CreatedOn:       invite.CreatedOn.Format(postingTimeLayout),
+		}
+		if invite.ExpiresOn != nil {
+			viewModel.ExpiresOn = invite.ExpiresOn.Format(postingTimeLayout)
+		}
+		viewModel.CanResend = viewModel.Status == data.InvitePending || viewModel.Status == data.InviteExpired
+		viewModel.CanRevoke = viewModel.Status == data.InvitePending
+		viewModels = append(viewModels, viewModel)
+	}
+	return viewModels
+}
+
+// resendInvite mails the pending or expired invite again and renews its expiry. Returns the success message or the message which explains why it cannot be sent.
+func resendInvite(r *http.Request, info *data.InviteCodeInfo) (string, string) {
+	status := info.Status()
+	if status != data.InvitePending && status != data.InviteExpired {
+		return "", fmt.Sprintf("The invite of %s is %s.", info.InvitedEmailAddress, status)
+	}
+	if info.LastSentOn != nil && time.Since(*info.LastSentOn) < inviteResendInterval {
+		return "", fmt.Sprintf("The invite of %s can be sent again after %s.", info.InvitedEmailAddress,
+			info.LastSentOn.Add(inviteResendInterval).Format(postingTimeLayout))
+	}
+	err := data.RenewInviteCode(info.Code, newInviteCheck(r).expiresOn())
+	if err != nil {
+		panic(err)
+	}
+	sendInviteMail(r, info.Code, info.InvitedEmailAddress, "")
+	return "The invite is sent again to " + info.InvitedEmailAddress + ".", ""
+}
+
+// revokeInvite revokes the pending invite. Returns the success message or the message which explains why it cannot be revoked.
+func revokeInvite(info *data.InviteCodeInfo) (string, string) {
+	if status := info.Status(); status != data.InvitePending {
+		return "", fmt.Sprintf("The invite of %s is %s.", info.InvitedEmailAddress, status)
+	}
+	err := data.RevokeInviteCode(info.Code)
+	if err != nil {
+		panic(err)
+	}
+	return "The invite of " + info.InvitedEmailAddress + " is revoked.", ""
+}
+
+// sendInviteMail mails the invite code on behalf of the signed in user
+func sendInviteMail(r *http.Request, inviteCode, email, memo string) {
+	user := shared.GetUserFromContext(r)
+	customer := shared.GetCustomerFromContext(r)
+	domain, err := data.GetCustomerDomainByUserName(user.UserName)
+	if err != nil {
+		panic(err)
+	}
+	err = shared.SendEmailInvitation(shared.InviteMailInfo{
+		Domain:     domain,
+		InviteCode: inviteCode,
+		Email:      email,
+		UserName:   user.UserName,
+		Memo:       memo,
+		Platform:   customer.Platform,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// inviteCheck checks the invite settings of the customer for the signed in user
+type inviteCheck struct {
+	user     *data.User
+	isAdmin  bool
+	settings *data.InviteSettings
+}
+
+func newInviteCheck(r *http.Request) *inviteCheck {
+	user, err := data.GetUserByID(shared.GetUserFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	isAdmin, err := data.IsUserAdmin(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	settings, err := data.GetInviteSettings(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	return &inviteCheck{user, isAdmin, settings}
+}
+
+// limit returns the number of invites the user can send in a month. Admins have their own quota and members get extra invites for their karma. 0 means unlimited.
+func (check *inviteCheck) limit() int {
+	if check.isAdmin {
+		return check.settings.AdminInvitesPerMonth
+	}
+	base := check.settings.MemberInvitesPerMonth
+	if base == 0 || check.settings.KarmaPerExtraInvite == 0 || check.user.Karma <= 0 {
+		return base
+	}
+	return base + check.user.Karma/check.settings.KarmaPerExtraInvite
+}
+
+// quota returns the message which explains why the user cannot invite and the number of invites the user can still send this month. The number is -1 if it is unlimited.
+func (check *inviteCheck) quota() (string, int) {
+	if !check.isAdmin && check.user.Karma < check.settings.MinInviteKarma {
+		return fmt.Sprintf("You can invite new members once you have %d karma.", check.settings.MinInviteKarma), 0
+	}
+	limit := check.limit()
+	if limit == 0 {
+		return "", -1
+	}
+	sent, err := data.CountInviteCodesSince(check.user.ID, time.Now().Add(-inviteQuotaWindow))
+	if err != nil {
+		panic(err)
+	}
+	if sent >= limit {
+		return fmt.Sprintf("You can send at most %d invites in 30 days.", limit), 0
+	}
+	return "", limit - sent
+}
+
+// expiresOn returns the expiry of an invite which is sent now. Returns nil if the invites do not expire.
+func (check *inviteCheck) expiresOn() *time.Time {
+	if check.settings.ExpiryDays == 0 {
+		return nil
+	}
+	expiresOn := time.Now().Add(time.Duration(check.settings.ExpiryDays) * 24 * time.Hour)
+	return &expiresOn
+}
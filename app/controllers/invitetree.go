@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"sort"
+)
+
+const topInviterCount = 10
+
+/*InviteTreeHandler shows who invited whom in the customer. Admins also see the bans in the tree and the invite chain statistics.*/
+func InviteTreeHandler(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	isAdmin, err := data.IsUserAdmin(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	tree := newInviteTree(user.CustomerID)
+	model := &models.InviteTreeViewModel{
+		Rows:        []models.InviteTreeRow{},
+		IsAdmin:     isAdmin,
+		MemberCount: len(tree.members),
+	}
+	tree.walk(0, 0, func(member *data.InviteTreeMember, depth int) {
+		row := models.InviteTreeRow{
+			UserName:     member.UserName,
+			Karma:        member.Karma,
+			RegisteredOn: shared.DateToString(member.RegisteredOn),
+			Depth:        depth,
+			ViaLink:      member.ViaLink,
+			InviteeCount: len(tree.invitees[member.UserID]),
+		}
+		// shadowbans must not be told to the members
+		if isAdmin {
+			row.BanKinds = member.BanKinds
+		}
+		model.Rows = append(model.Rows, row)
+	})
+	if isAdmin {
+		setInviteStats(model, tree, user.CustomerID)
+	}
+	err = templates.RenderInLayout(w, r, "invite-tree.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setInviteStats(model *models.InviteTreeViewModel, tree *inviteTree, customerID int) {
+	var err error
+	model.InvitesSent, model.InvitesUsed, err = data.GetInviteCodeCounts(customerID)
+	if err != nil {
+		panic(err)
+	}
+	model.RootCount = len(tree.invitees[0])
+	for _, row := range model.Rows {
+		if row.Depth > model.MaxDepth {
+			model.MaxDepth = row.Depth
+		}
+	}
+	inviters := []models.InviterStats{}
+	for _, member := range tree.members {
+		if member.ViaLink {
+			model.LinkCount++
+		} else if tree.inviters[member.UserID] != 0 {
+			model.InvitedCount++
+		}
+		invitees := tree.invitees[member.UserID]
+		if len(invitees) == 0 {
+			continue
+		}
+		stats := models.InviterStats{
+			UserName:     member.UserName,
+			InviteeCount: len(invitees),
+		}
+		karma := 0
+		for _, inviteeID := range invitees {
+			karma += tree.members[inviteeID].Karma
+		}
+		stats.AverageKarma = karma / len(invitees)
+		tree.walk(member.UserID, 0, func(invitee *data.InviteTreeMember, depth int) {
+			stats.SubtreeSize++
+			if len(invitee.BanKinds) > 0 {
+				stats.BannedCount++
+			}
+		})
+		inviters = append(inviters, stats)
+	}
+	sort.Slice(inviters, func(i, j int) bool {
+		if inviters[i].SubtreeSize != inviters[j].SubtreeSize {
+			return inviters[i].SubtreeSize > inviters[j].SubtreeSize
+		}
+		return inviters[i].UserName < inviters[j].UserName
+	})
+	if len(inviters) > topInviterCount {
+		inviters = inviters[:topInviterCount]
+	}
+	model.TopInviters = inviters
+}
+
+// inviteTree links the members of a customer to the members whom they invited. The members without an inviter in the customer are the invitees of 0.
+type inviteTree struct {
+	members  map[int]*data.InviteTreeMember
+	inviters map[int]int
+	invitees map[int][]int
+}
+
+func newInviteTree(customerID int) *inviteTree {
+	members, err := data.GetInviteTreeMembers(customerID)
+	if err != nil {
+		panic(err)
+	}
+	tree := &inviteTree{
+		members:  make(map[int]*data.InviteTreeMember),
+		inviters: make(map[int]int),
+		invitees: make(map[int][]int),
+	}
+	// members are in the order of registration, so an inviter is always added before the members they invited
+	for i := range *members {
+		member := &(*members)[i]
+		inviterID := member.InviterUserID
+		if _, exists := tree.members[inviterID]; !exists {
+			inviterID = 0
+		}
+		tree.members[member.UserID] = member
+		tree.inviters[member.UserID] = inviterID
+		tree.invitees[inviterID] = append(tree.invitees[inviterID], member.UserID)
+	}
+	return tree
+}
+
+// walk visits the members whom the inviter invited directly or indirectly, every member before the members they invited
+func (tree *inviteTree) walk(inviterID, depth int, visit func(member *data.InviteTreeMember, depth int)) {
+	for _, userID := range tree.invitees[inviterID] {
+		visit(tree.members[userID], depth)
+		tree.walk(userID, depth+1, visit)
+	}
+}
+
+// subtree returns the members whom the user invited directly or indirectly
+func (tree *inviteTree) subtree(userID int) []*data.InviteTreeMember {
+	members := []*data.InviteTreeMember{}
+	tree.walk(userID, 0, func(member *data.InviteTreeMember, depth int) {
+		members = append(members, member)
+	})
+	return members
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*KarmaHistoryPageSize represents the number of karma entries listed per page*/
+const KarmaHistoryPageSize = 30
+
+/*KarmaHistoryHandler handles showing where the karma of a user came from. Admins can adjust the karma of the users with a reason.*/
+func KarmaHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handleKarmaHistoryGET(w, r)
+	case "POST":
+		handleKarmaHistoryPOST(w, r)
+	default:
+		handleKarmaHistoryGET(w, r)
+	}
+}
+
+func handleKarmaHistoryGET(w http.ResponseWriter, r *http.Request) {
+	user := getKarmaHistoryUser(r, r.URL.Query().Get("user"))
+	if user == nil {
+		renderNotFound(w)
+		return
+	}
+	renderKarmaHistory(w, r, user, &models.KarmaHistoryViewModel{Source: enums.AdjustmentKarma})
+}
+
+func handleKarmaHistoryPOST(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	user := getKarmaHistoryUser(r, r.FormValue("user"))
+	if user == nil {
+		renderNotFound(w)
+		return
+	}
+	points, _ := strconv.Atoi(strings.TrimSpace(r.FormValue("points")))
+	model := &models.KarmaHistoryViewModel{
+		Source: enums.KarmaSource(r.FormValue("source")),
+		Points: points,
+		Reason: r.FormValue("reason"),
+	}
+	if model.Validate() == false {
+		renderKarmaHistory(w, r, user, model)
+		return
+	}
+	err = data.AddKarmaEntry(&data.KarmaEntry{
+		UserID:      user.ID,
+		Source:      model.Source,
+		Points:      model.Points,
+		ActorUserID: shared.GetUserFromContext(r).ID,
+		Reason:      model.Reason,
+		CreatedOn:   time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	user.Karma += model.Points
+	renderKarmaHistory(w, r, user, &models.KarmaHistoryViewModel{
+		Source:         enums.AdjustmentKarma,
+		SuccessMessage: fmt.Sprintf("Karma of %s is adjusted by %+d.", user.UserName, model.Points),
+	})
+}
+
+// getKarmaHistoryUser returns the user of the customer by user name or the signed in user if user name is empty. Returns nil if the user is not found.
+func getKarmaHistoryUser(r *http.Request, userName string) *data.User {
+	userCtx := shared.GetUserFromContext(r)
+	if strings.TrimSpace(userName) == "" {
+		userName = userCtx.UserName
+	}
+	user, err := data.GetUserByUserName(userName)
+	if err != nil {
+		panic(err)
+	}
+	if user == nil || user.CustomerID != userCtx.CustomerID {
+		return nil
+	}
+	return user
+}
+
+func renderKarmaHistory(w http.ResponseWriter, r *http.Request, user *data.User, model *models.KarmaHistoryViewModel) {
+	isAdmin, err := data.IsUserAdmin(shared.GetUserFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	// one more entry than the page size is fetched to find out whether there is a next page
+	entries, err := data.GetKarmaHistory(user.ID, page, KarmaHistoryPageSize+1)
+	if err != nil {
+		panic(err)
+	}
+	model.UserName = user.UserName
+	model.Karma = user.Karma
+	model.IsAdmin = isAdmin
+	model.Sources = enums.KarmaAdjustmentSources
+	model.Entries = []models.KarmaEntryViewModel{}
+	for i, entry := range *entries {
+		if i == KarmaHistoryPageSize {
+			model.NextPageURL = karmaHistoryPageURL(user.UserName, page+1)
+			break
+		}
+		model.Entries = append(model.Entries, *mapKarmaEntryToViewModel(&entry))
+	}
+	if page > 1 {
+		model.PreviousPageURL = karmaHistoryPageURL(user.UserName, page-1)
+	}
+	err = templates.RenderInLayout(w, r, "karma.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func mapKarmaEntryToViewModel(entry *data.KarmaEntry) *models.KarmaEntryViewModel {
+	viewModel := &models.KarmaEntryViewModel{
+		Source:        entry.Source,
+		Points:        entry.Points,
+		StoryID:       entry.StoryID,
+		StoryTitle:    entry.StoryTitle,
+		CommentID:     entry.CommentID,
+		CreatedOnText: shared.DateToString(entry.CreatedOn),
+	}
+	if entry.StoryID != 0 {
+		viewModel.URL = commentThreadURL(entry.StoryID, entry.CommentID)
+	}
+	switch entry.Source {
+	case enums.StoryVoteKarma:
+		viewModel.Description = "Story upvoted"
+		if entry.Points < 0 {
+			viewModel.Description = "Story upvote removed"
+		}
+	case enums.CommentVoteKarma:
+		viewModel.Description = "Comment upvoted"
+		if entry.Points < 0 {
+			viewModel.Description = "Comment upvote removed"
+		}
+	case enums.BonusKarma:
+		viewModel.Description = "Bonus"
+		viewModel.Reason = entry.Reason
+		viewModel.AdminUserName = entry.ActorUserName
+	default:
+		viewModel.Description = "Moderation adjustment"
+		viewModel.Reason = entry.Reason
+		viewModel.AdminUserName = entry.ActorUserName
+	}
+	return viewModel
+}
+
+func karmaHistoryPageURL(userName string, page int) string {
+	values := url.Values{}
+	values.Set("user", userName)
+	values.Set("page", strconv.Itoa(page))
+	return "/users/karma?" + values.Encode()
+}
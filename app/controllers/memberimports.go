@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/jobs"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	memberImportListSize  = 20
+	maxMemberImportSize   = 1 << 20
+	maxImportFileNameSize = 255
+)
+
+/*MemberImportsHandler handles the csv files of addresses which admins upload to invite many members at once, their previews and statuses*/
+func MemberImportsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		if r.URL.Query().Get("id") != "" {
+			handleMemberImportGET(w, r)
+			return
+		}
+		renderMemberImports(w, r, newMemberImportsViewModel(user.CustomerID))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxMemberImportSize+1024)
+	switch r.FormValue("action") {
+	case "upload":
+		handleUploadMemberImportPOST(w, r)
+	case "send":
+		handleSendMemberImportPOST(w, r)
+	case "cancel":
+		handleCancelMemberImportPOST(w, r)
+	default:
+		renderMemberImports(w, r, newMemberImportsViewModel(user.CustomerID))
+	}
+}
+
+func handleMemberImportGET(w http.ResponseWriter, r *http.Request) {
+	importID, _ := strconv.Atoi(r.URL.Query().Get("id"))
+	model := newMemberImportViewModel(r, importID)
+	if model == nil {
+		renderNotFound(w)
+		return
+	}
+	renderMemberImport(w, r, model)
+}
+
+func handleUploadMemberImportPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := newMemberImportsViewModel(user.CustomerID)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		model.Errors["File"] = "Please choose a csv file which is smaller than 1 MB."
+		renderMemberImports(w, r, model)
+		return
+	}
+	defer file.Close()
+	rows, message, err := models.ReadMemberImportRows(file, user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	if message != "" {
+		model.Errors["File"] = message
+		renderMemberImports(w, r, model)
+		return
+	}
+	fileName := header.Filename
+	if len(fileName) > maxImportFileNameSize {
+		fileName = fileName[:maxImportFileNameSize]
+	}
+	memberImport := &data.MemberImport{
+		CustomerID: user.CustomerID,
+		CreatedBy:  user.ID,
+		CreatedOn:  time.Now(),
+		FileName:   fileName,
+	}
+	err = data.CreateMemberImport(memberImport, rows)
+	if err != nil {
+		panic(err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/imports?id=%d", memberImport.ID), http.StatusSeeOther)
+}
+
+func handleSendMemberImportPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	importID, _ := strconv.Atoi(r.FormValue("id"))
+	model := newMemberImportViewModel(r, importID)
+	if model == nil {
+		renderNotFound(w)
+		return
+	}
+	model.Memo = strings.TrimSpace(r.FormValue("memo"))
+	if model.Validate() == false {
+		renderMemberImport(w, r, model)
+		return
+	}
+	queued, err := data.QueueMemberImport(importID, user.ID, model.Memo, newInviteCheck(r).expiresOn())
+	if err != nil {
+		panic(err)
+	}
+	jobs.NotifyMemberImportQueued()
+	model = newMemberImportViewModel(r, importID)
+	model.SuccessMessage = fmt.Sprintf("%d invites are created and their mails are being sent.", queued)
+	renderMemberImport(w, r, model)
+}
+
+func handleCancelMemberImportPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	importID, _ := strconv.Atoi(r.FormValue("id"))
+	deleted, err := data.DeleteMemberImport(user.CustomerID, importID)
+	if err != nil {
+		panic(err)
+	}
+	if !deleted {
+		renderNotFound(w)
+		return
+	}
+	model := newMemberImportsViewModel(user.CustomerID)
+	model.SuccessMessage = "The import is cancelled."
+	renderMemberImports(w, r, model)
+}
+
+/*MemberImportReportHandler downloads the result of every row of a member import as a csv file*/
+func MemberImportReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	importID, _ := strconv.Atoi(r.URL.Query().Get("id"))
+	memberImport, err := data.GetMemberImport(user.CustomerID, importID)
+	if err != nil {
+		panic(err)
+	}
+	if memberImport == nil {
+		renderNotFound(w)
+		return
+	}
+	rows, err := data.GetMemberImportRows(importID)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"member-import-%d.csv\"", importID))
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "email", "name", "role", "status", "message"})
+	for _, row := range *rows {
+		writer.Write([]string{
+			strconv.Itoa(row.LineNumber),
+			csvField(row.Email),
+			csvField(row.FullName),
+			row.Role,
+			row.Status,
+			row.Message,
+		})
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		panic(err)
+	}
+}
+
+// csvField keeps the spreadsheet applications from running the user given value as a formula
+func csvField(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func newMemberImportsViewModel(customerID int) *models.MemberImportsViewModel {
+	memberImports, err := data.GetMemberImports(customerID, memberImportListSize)
+	if err != nil {
+		panic(err)
+	}
+	return &models.MemberImportsViewModel{
+		Imports: *memberImports,
+		MaxRows: models.MaxMemberImportRows,
+		Errors:  make(map[string]string),
+	}
+}
+
+// newMemberImportViewModel returns nil if the customer of the signed in user has no such import
+func newMemberImportViewModel(r *http.Request, importID int) *models.MemberImportViewModel {
+	user := shared.GetUserFromContext(r)
+	memberImport, err := data.GetMemberImport(user.CustomerID, importID)
+	if err != nil {
+		panic(err)
+	}
+	if memberImport == nil {
+		return nil
+	}
+	rows, err := data.GetMemberImportRows(importID)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.MemberImportViewModel{
+		Import: *memberImport,
+		Rows:   *rows,
+		Memo:   memberImport.Memo,
+		Errors: make(map[string]string),
+	}
+	model.Restriction, model.Remaining = newInviteCheck(r).quota()
+	return model
+}
+
+func renderMemberImports(w http.ResponseWriter, r *http.Request, model *models.MemberImportsViewModel) {
+	err := templates.RenderInLayout(w, r, "member-imports.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func renderMemberImport(w http.ResponseWriter, r *http.Request, model *models.MemberImportViewModel) {
+	err := templates.RenderInLayout(w, r, "member-import.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
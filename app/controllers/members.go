@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	memberPageSize      = 50
+	memberRecentSize    = 10
+	memberSessionsSize  = 20
+	memberExportTimeFmt = "2006-01-02 15:04"
+)
+
+/*MembersHandler handles the member directory of the customer, the admin page of a member and the actions which admins take on members*/
+func MembersHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		if r.URL.Query().Get("user") != "" {
+			handleMemberGET(w, r)
+			return
+		}
+		renderMembers(w, r, newMembersViewModel(r))
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	userID, _ := strconv.Atoi(r.FormValue("user"))
+	model := newMemberViewModel(r, userID)
+	if model == nil {
+		renderNotFound(w)
+		return
+	}
+	switch r.FormValue("action") {
+	case "role":
+		handleChangeRolePOST(w, r, model)
+	case "resetpassword":
+		handleSendPasswordResetPOST(w, r, model)
+	case "suspend":
+		handleSuspendMemberPOST(w, r, model)
+	case "delete":
+		handleDeleteMemberPOST(w, r, model)
+	default:
+		http.Error(w, "Invalid member action.", http.StatusBadRequest)
+	}
+}
+
+func handleMemberGET(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.URL.Query().Get("user"))
+	model := newMemberViewModel(r, userID)
+	if model == nil {
+		renderNotFound(w)
+		return
+	}
+	renderMember(w, r, model)
+}
+
+func handleChangeRolePOST(w http.ResponseWriter, r *http.Request, model *models.MemberViewModel) {
+	model.Role = r.FormValue("role")
+	if model.ValidateRole() == false {
+		renderMember(w, r, model)
+		return
+	}
+	err := data.SetUserRole(model.Member.ID, model.Role)
+	if err != nil {
+		panic(err)
+	}
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       data.ModerationRoleChanged,
+		TargetUserID: model.Member.ID,
+		Details:      model.Role,
+	})
+	model = newMemberViewModel(r, model.Member.ID)
+	model.SuccessMessage = fmt.Sprintf("%s is %s now.", model.Member.UserName, model.Member.Role)
+	renderMember(w, r, model)
+}
+
+func handleSendPasswordResetPOST(w http.ResponseWriter, r *http.Request, model *models.MemberViewModel) {
+	if model.ValidatePasswordReset() == false {
+		renderMember(w, r, model)
+		return
+	}
+	sendResetPasswordMail(r, model.Member.ID, model.Member.UserName, model.Member.Email)
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       data.ModerationPasswordResetSent,
+		TargetUserID: model.Member.ID,
+	})
+	model.SuccessMessage = fmt.Sprintf("A reset password link is sent to %s.", model.Member.Email)
+	renderMember(w, r, model)
+}
+
+func handleSuspendMemberPOST(w http.ResponseWriter, r *http.Request, model *models.MemberViewModel) {
+	model.Days = parseFormCount(r.FormValue("days"))
+	model.Reason = strings.TrimSpace(r.FormValue("reason"))
+	if model.ValidateSuspension() == false {
+		renderMember(w, r, model)
+		return
+	}
+	endsOn := time.Now().Add(time.Duration(model.Days) * 24 * time.Hour)
+	banUser(r, &data.UserBan{
+		UserID:   model.Member.ID,
+		UserName: model.Member.UserName,
+		Kind:     data.UserBanSuspension,
+		Reason:   model.Reason,
+		EndsOn:   &endsOn,
+	})
+	days := model.Days
+	model = newMemberViewModel(r, model.Member.ID)
+	model.SuccessMessage = fmt.Sprintf("%s is suspended for %d days.", model.Member.UserName, days)
+	renderMember(w, r, model)
+}
+
+func handleDeleteMemberPOST(w http.ResponseWriter, r *http.Request, model *models.MemberViewModel) {
+	user := shared.GetUserFromContext(r)
+	model.Confirm = strings.TrimSpace(r.FormValue("confirm"))
+	if model.ValidateDelete() == false {
+		renderMember(w, r, model)
+		return
+	}
+	deleted, err := data.DeleteMember(user.CustomerID, model.Member.ID)
+	if err != nil {
+		panic(err)
+	}
+	if !deleted {
+		renderNotFound(w)
+		return
+	}
+	// the deleted member is kept in the log by name only
+	logModeration(r, &data.ModerationLogEntry{
+		Action: data.ModerationMemberDeleted,
+		Target: model.Member.UserName,
+	})
+	invalidateListings(user.CustomerID)
+	members := newMembersViewModel(r)
+	members.SuccessMessage = fmt.Sprintf("%s and their stories, comments and votes are deleted.", model.Member.UserName)
+	renderMembers(w, r, members)
+}
+
+/*MembersExportHandler downloads the members of the customer which match the search of the member directory as a csv file*/
+func MembersExportHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	search, sort := getMemberFilter(r)
+	memberCount, err := data.GetMemberCount(user.CustomerID, search)
+	if err != nil {
+		panic(err)
+	}
+	members, err := data.GetMembers(user.CustomerID, search, sort, memberCount, 0)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"members.csv\"")
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"username", "name", "email", "role", "karma", "joined", "last active", "status"})
+	for _, member := range *members {
+		lastActiveOn := ""
+		if member.LastActiveOn != nil {
+			lastActiveOn = member.LastActiveOn.Format(memberExportTimeFmt)
+		}
+		writer.Write([]string{
+			member.UserName,
+			csvField(member.FullName),
+			csvField(member.Email),
+			member.Role,
+			strconv.Itoa(member.Karma),
+			member.RegisteredOn.Format(memberExportTimeFmt),
+			lastActiveOn,
+			memberStatus(&member),
+		})
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		panic(err)
+	}
+}
+
+// memberStatus returns whether the member waits for approval, their active bans or active
+func memberStatus(member *data.Member) string {
+	if member.IsPending {
+		return "pending"
+	}
+	if len(member.BanKinds) > 0 {
+		return strings.Join(member.BanKinds, " ")
+	}
+	return "active"
+}
+
+// getMemberFilter returns the search text and the order of the member directory. Members are listed by join date unless another known order is requested.
+func getMemberFilter(r *http.Request) (string, string) {
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	sort := r.URL.Query().Get("sort")
+	for _, memberSort := range models.MemberSorts {
+		if sort == memberSort {
+			return search, sort
+		}
+	}
+	return search, data.MemberSortJoined
+}
+
+func newMembersViewModel(r *http.Request) *models.MembersViewModel {
+	user := shared.GetUserFromContext(r)
+	search, sort := getMemberFilter(r)
+	memberCount, err := data.GetMemberCount(user.CustomerID, search)
+	if err != nil {
+		panic(err)
+	}
+	pageCount := int(math.Ceil(float64(memberCount) / float64(memberPageSize)))
+	page := getPage(r)
+	if page < 1 || page > pageCount {
+		page = 1
+	}
+	members, err := data.GetMembers(user.CustomerID, search, sort, memberPageSize, (page-1)*memberPageSize)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.MembersViewModel{
+		Members:     *members,
+		MemberCount: memberCount,
+		Search:      search,
+		Sort:        sort,
+		Sorts:       models.MemberSorts,
+		Paging:      &models.Paging{CurrentPage: page, TotalPageCount: pageCount},
+		ExportURL:   "/admin/members/export?" + memberFilterQuery(search, sort).Encode(),
+	}
+	if page > 1 {
+		model.Paging.PreviousPage = page - 1
+		model.Paging.PreviousURL = memberPageURL(search, sort, page-1)
+	}
+	if page < pageCount {
+		model.Paging.NextPage = page + 1
+		model.Paging.NextURL = memberPageURL(search, sort, page+1)
+	} else {
+		model.Paging.IsFinalPage = true
+	}
+	return model
+}
+
+func memberFilterQuery(search, sort string) url.Values {
+	query := url.Values{}
+	if search != "" {
+		query.Set("q", search)
+	}
+	query.Set("sort", sort)
+	return query
+}
+
+// memberPageURL returns the url of a page of the member directory. The list is also rendered after a member is deleted, so the url is not taken from the request.
+func memberPageURL(search, sort string, page int) string {
+	query := memberFilterQuery(search, sort)
+	query.Set("page", strconv.Itoa(page))
+	return "/admin/members?" + query.Encode()
+}
+
+// newMemberViewModel returns nil if the customer of the signed in admin has no such member
+func newMemberViewModel(r *http.Request, userID int) *models.MemberViewModel {
+	user := shared.GetUserFromContext(r)
+	member, err := data.GetMember(user.CustomerID, userID)
+	if err != nil {
+		panic(err)
+	}
+	if member == nil {
+		return nil
+	}
+	activity, err := data.GetMemberActivity(userID)
+	if err != nil {
+		panic(err)
+	}
+	// admins see every story of the member as the member sees them
+	stories, err := data.GetUserSubmittedStories(userID, userID, 1, memberRecentSize)
+	if err != nil {
+		panic(err)
+	}
+	comments, err := data.GetRecentCommentsByUserID(userID, memberRecentSize)
+	if err != nil {
+		panic(err)
+	}
+	invites, err := data.GetInviteCodesByInviter(userID)
+	if err != nil {
+		panic(err)
+	}
+	sessions, err := data.GetUserSessions(userID, memberSessionsSize)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.MemberViewModel{
+		Member:    *member,
+		Activity:  *activity,
+		Stories:   *stories,
+		Comments:  *comments,
+		Invites:   *invites,
+		Sessions:  *sessions,
+		Roles:     models.MemberRoles,
+		CanManage: !member.IsOwner && member.ID != user.ID,
+		Role:      member.Role,
+		Errors:    make(map[string]string),
+	}
+	model.InvitedBy, model.InvitedViaLink, err = data.GetInviter(userID)
+	if err != nil {
+		panic(err)
+	}
+	return model
+}
+
+func renderMembers(w http.ResponseWriter, r *http.Request, model *models.MembersViewModel) {
+	err := templates.RenderInLayout(w, r, "members.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func renderMember(w http.ResponseWriter, r *http.Request, model *models.MemberViewModel) {
+	err := templates.RenderInLayout(w, r, "member.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"linkwind/app/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const moderationLogSize = 100
+
+var userBanKinds = []string{data.UserBanPermanent, data.UserBanSuspension, data.UserBanShadow}
+
+var userBanActions = map[string]string{
+	data.UserBanPermanent:  data.ModerationUserBanned,
+	data.UserBanSuspension: data.ModerationUserSuspended,
+	data.UserBanShadow:     data.ModerationUserShadowbanned,
+}
+
+/*ModerationHandler handles the bans, suspensions and shadowbans of the members, the ip bans and the moderation log of the customer*/
+func ModerationHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		renderModeration(w, r, newModerationViewModel(user.CustomerID))
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	switch r.FormValue("action") {
+	case "ban":
+		handleBanUserPOST(w, r)
+	case "lift":
+		handleLiftUserBanPOST(w, r)
+	case "ipban":
+		handleBanIPPOST(w, r)
+	case "liftip":
+		handleLiftIPBanPOST(w, r)
+	default:
+		http.Error(w, "Invalid moderation action.", http.StatusBadRequest)
+	}
+}
+
+func newModerationViewModel(customerID int) *models.ModerationViewModel {
+	bans, err := data.GetUserBans(customerID)
+	if err != nil {
+		panic(err)
+	}
+	ipBans, err := data.GetIPBans(customerID)
+	if err != nil {
+		panic(err)
+	}
+	entries, err := data.GetModerationLog(customerID, moderationLogSize)
+	if err != nil {
+		panic(err)
+	}
+	return &models.ModerationViewModel{
+		Bans:   *bans,
+		IPBans: *ipBans,
+		Log:    *entries,
+		Kinds:  userBanKinds,
+		Kind:   data.UserBanPermanent,
+		Errors: make(map[string]string),
+	}
+}
+
+func handleBanUserPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := newModerationViewModel(user.CustomerID)
+	model.UserName = strings.TrimSpace(r.FormValue("username"))
+	model.Kind = r.FormValue("kind")
+	model.Days = parseFormCount(r.FormValue("days"))
+	model.Reason = strings.TrimSpace(r.FormValue("reason"))
+	model.Subtree = r.FormValue("subtree") == "on"
+	if model.ValidateUserBan() == false {
+		renderModeration(w, r, model)
+		return
+	}
+	exists, err := data.ExistsUserByUserName(model.UserName)
+	if err != nil {
+		panic(err)
+	}
+	var target *data.User
+	if exists {
+		target, err = data.GetUserByUserName(model.UserName)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if target == nil || target.CustomerID != user.CustomerID {
+		model.Errors["UserName"] = "User does not exist"
+		renderModeration(w, r, model)
+		return
+	}
+	isAdmin, err := data.IsUserAdmin(target.ID)
+	if err != nil {
+		panic(err)
+	}
+	if isAdmin {
+		model.Errors["UserName"] = "Admins cannot be banned"
+		renderModeration(w, r, model)
+		return
+	}
+	ban := &data.UserBan{
+		UserID:   target.ID,
+		UserName: target.UserName,
+		Kind:     model.Kind,
+		Reason:   model.Reason,
+	}
+	if model.Days > 0 {
+		endsOn := time.Now().Add(time.Duration(model.Days) * 24 * time.Hour)
+		ban.EndsOn = &endsOn
+	}
+	banUser(r, ban)
+	message := fmt.Sprintf("%s is %s.", target.UserName, userBanActions[ban.Kind])
+	if model.Subtree {
+		invitees := 0
+		for _, invitee := range newInviteTree(user.CustomerID).subtree(target.ID) {
+			isAdmin, err := data.IsUserAdmin(invitee.UserID)
+			if err != nil {
+				panic(err)
+			}
+			if isAdmin {
+				continue
+			}
+			inviteeBan := *ban
+			inviteeBan.UserID = invitee.UserID
+			inviteeBan.UserName = invitee.UserName
+			banUser(r, &inviteeBan)
+			invitees++
+		}
+		message = fmt.Sprintf("%s and %d members they invited directly or indirectly are %s.", target.UserName, invitees, userBanActions[ban.Kind])
+	}
+	model = newModerationViewModel(user.CustomerID)
+	model.SuccessMessage = message
+	renderModeration(w, r, model)
+}
+
+func handleLiftUserBanPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	userID, _ := strconv.Atoi(r.FormValue("userid"))
+	exists, err := data.ExistsUserInCustomer(user.CustomerID, userID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	target, err := data.GetUserByID(userID)
+	if err != nil {
+		panic(err)
+	}
+	kind := r.FormValue("kind")
+	liftUserBan(r, target.ID, kind)
+	message := fmt.Sprintf("The %s of %s is lifted.", kind, target.UserName)
+	if r.FormValue("subtree") == "on" {
+		invitees := 0
+		for _, invitee := range newInviteTree(user.CustomerID).subtree(target.ID) {
+			if !hasBanKind(invitee, kind) {
+				continue
+			}
+			liftUserBan(r, invitee.UserID, kind)
+			invitees++
+		}
+		message = fmt.Sprintf("The %s of %s and %d members they invited directly or indirectly is lifted.", kind, target.UserName, invitees)
+	}
+	if kind == data.UserBanShadow {
+		invalidateListings(user.CustomerID)
+	}
+	model := newModerationViewModel(user.CustomerID)
+	model.SuccessMessage = message
+	renderModeration(w, r, model)
+}
+
+// liftUserBan lifts the ban of the user on behalf of the signed in moderator and records it in the moderation log
+func liftUserBan(r *http.Request, userID int, kind string) {
+	err := data.LiftUserBan(userID, kind)
+	if err != nil {
+		panic(err)
+	}
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       data.ModerationBanLifted,
+		TargetUserID: userID,
+		Details:      kind,
+	})
+}
+
+func hasBanKind(member *data.InviteTreeMember, kind string) bool {
+	for _, banKind := range member.BanKinds {
+		if banKind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func handleBanIPPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := newModerationViewModel(user.CustomerID)
+	model.CIDR = strings.TrimSpace(r.FormValue("cidr"))
+	model.IPDays = parseFormCount(r.FormValue("ipdays"))
+	model.IPReason = strings.TrimSpace(r.FormValue("ipreason"))
+	if model.ValidateIPBan() == false {
+		renderModeration(w, r, model)
+		return
+	}
+	ban := &data.IPBan{
+		CustomerID: user.CustomerID,
+		CIDR:       model.Network(),
+		Reason:     model.IPReason,
+		BannedBy:   user.ID,
+		BannedOn:   time.Now(),
+	}
+	if model.IPDays > 0 {
+		endsOn := time.Now().Add(time.Duration(model.IPDays) * 24 * time.Hour)
+		ban.EndsOn = &endsOn
+	}
+	err := data.AddIPBan(ban)
+	if err != nil {
+		panic(err)
+	}
+	caching.DeleteIPBans(user.CustomerID)
+	logModeration(r, &data.ModerationLogEntry{
+		Action:  data.ModerationIPBanned,
+		Target:  ban.CIDR,
+		Details: ban.Reason,
+	})
+	model = newModerationViewModel(user.CustomerID)
+	model.SuccessMessage = fmt.Sprintf("%s is banned.", ban.CIDR)
+	renderModeration(w, r, model)
+}
+
+func handleLiftIPBanPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	ban, err := data.DeleteIPBan(user.CustomerID, id)
+	if err != nil {
+		panic(err)
+	}
+	if ban == nil {
+		renderNotFound(w)
+		return
+	}
+	caching.DeleteIPBans(user.CustomerID)
+	logModeration(r, &data.ModerationLogEntry{
+		Action: data.ModerationIPBanLifted,
+		Target: ban.CIDR,
+	})
+	model := newModerationViewModel(user.CustomerID)
+	model.SuccessMessage = fmt.Sprintf("The ban of %s is lifted.", ban.CIDR)
+	renderModeration(w, r, model)
+}
+
+// banUser bans the user on behalf of the signed in moderator and records it in the moderation log
+func banUser(r *http.Request, ban *data.UserBan) {
+	user := shared.GetUserFromContext(r)
+	ban.CustomerID = user.CustomerID
+	ban.BannedBy = user.ID
+	ban.BannedOn = time.Now()
+	err := data.BanUser(ban)
+	if err != nil {
+		panic(err)
+	}
+	if ban.Kind == data.UserBanShadow {
+		invalidateListings(user.CustomerID)
+	}
+	details := ban.Reason
+	if ban.EndsOn != nil {
+		details = strings.TrimSpace(fmt.Sprintf("until %s %s", ban.EndsOn.Format(postingTimeLayout), details))
+	}
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       userBanActions[ban.Kind],
+		TargetUserID: ban.UserID,
+		Details:      details,
+	})
+}
+
+// invalidateListings clears the cached listings after the stories and comments of a user are hidden or shown
+func invalidateListings(customerID int) {
+	caching.DeleteRankedStories(customerID)
+	caching.InvalidatePages(customerID)
+}
+
+// logModeration records the action of the signed in moderator
+func logModeration(r *http.Request, entry *data.ModerationLogEntry) {
+	user := shared.GetUserFromContext(r)
+	entry.CustomerID = user.CustomerID
+	entry.ActorUserID = user.ID
+	entry.CreatedOn = time.Now()
+	err := data.AddModerationLogEntry(entry)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// emitContentCreated emits the created event of the story or comment unless its author is shadowbanned, since their posts are shown to nobody else
+func emitContentCreated(customerID, authorID int, event enums.WebhookEvent, payload interface{}) {
+	shadowbanned, err := data.IsUserShadowbanned(authorID)
+	if err != nil {
+		panic(err)
+	}
+	if !shadowbanned {
+		webhooks.Emit(customerID, event, payload)
+	}
+}
+
+// userBanMessage returns the message which tells the user about the ban or suspension. Returns empty string if the user is not banned. Shadowbans are not told.
+func userBanMessage(userID int) (string, error) {
+	ban, err := data.GetActiveUserBan(userID)
+	if err != nil || ban == nil {
+		return "", err
+	}
+	message := "Your account is banned."
+	if ban.EndsOn != nil {
+		message = fmt.Sprintf("Your account is suspended until %s.", ban.EndsOn.Format("Jan 2, 2006 15:04"))
+	}
+	if ban.Reason != "" {
+		message += " Reason: " + ban.Reason
+	}
+	return message, nil
+}
+
+func renderModeration(w http.ResponseWriter, r *http.Request, model *models.ModerationViewModel) {
+	err := templates.RenderInLayout(w, r, "moderation.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// storyCursor is the opaque position of a listing page which is passed in after and before query parameters.
+// Keyset listings use the time and id of a story, ranked listings use the snapshot of ranked ids and an offset in it.
+type storyCursor struct {
+	Time     int64 `json:"t,omitempty"`
+	ID       int   `json:"i,omitempty"`
+	Snapshot int64 `json:"s,omitempty"`
+	Offset   int   `json:"o,omitempty"`
+}
+
+func encodeCursor(cursor *storyCursor) string {
+	value, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// decodeCursor returns nil if the value is empty or not a valid cursor
+func decodeCursor(value string) *storyCursor {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	cursor := &storyCursor{}
+	err = json.Unmarshal(decoded, cursor)
+	if err != nil {
+		return nil
+	}
+	return cursor
+}
+
+// pagingURL returns the current url whose paging parameters are replaced with the cursor
+func pagingURL(r *http.Request, param, cursor string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("after")
+	query.Del("before")
+	query.Set(param, cursor)
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// storyListing reads the page of a story listing which is requested by the paging parameters
+type storyListing func(customerID int, r *http.Request) (*[]data.Story, *models.Paging, error)
+
+type getStoriesByKeyset func(id int, keyset *data.Keyset) (*data.KeysetPage, error)
+
+// pagedListing lists the stories by page numbers
+func pagedListing(fnGetStories getStoriesPaged, fnCount getStoriesCount) storyListing {
+	return func(customerID int, r *http.Request) (*[]data.Story, *models.Paging, error) {
+		page := getPage(r)
+		stories, err := fnGetStories(customerID, page, DefaultPageSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		storiesCount, err := fnCount(customerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		pagingModel, err := setPagingViewModel(customerID, page, storiesCount)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stories, pagingModel, nil
+	}
+}
+
+// keysetListing lists the stories by the keys of the first and last stories of the page. Stories do not shift between pages when new ones are submitted.
+func keysetListing(fnGetStories getStoriesByKeyset) storyListing {
+	return func(customerID int, r *http.Request) (*[]data.Story, *models.Paging, error) {
+		return getKeysetStories(customerID, fnGetStories, r)
+	}
+}
+
+func getKeysetStories(id int, fnGetStories getStoriesByKeyset, r *http.Request) (*[]data.Story, *models.Paging, error) {
+	keyset := &data.Keyset{Limit: DefaultPageSize}
+	if cursor := decodeCursor(r.URL.Query().Get("after")); cursor != nil {
+		keyset.After = &data.StoryKey{Time: time.Unix(0, cursor.Time), ID: cursor.ID}
+	} else if cursor := decodeCursor(r.URL.Query().Get("before")); cursor != nil {
+		keyset.Before = &data.StoryKey{Time: time.Unix(0, cursor.Time), ID: cursor.ID}
+	}
+	page, err := fnGetStories(id, keyset)
+	if err != nil {
+		return nil, nil, err
+	}
+	pagingModel := &models.Paging{}
+	if len(page.Keys) > 0 {
+		if page.HasPrevious {
+			first := page.Keys[0]
+			setPreviousCursor(pagingModel, r, &storyCursor{Time: first.Time.UnixNano(), ID: first.ID})
+		}
+		if page.HasNext {
+			last := page.Keys[len(page.Keys)-1]
+			setNextCursor(pagingModel, r, &storyCursor{Time: last.Time.UnixNano(), ID: last.ID})
+		}
+	}
+	return &page.Stories, pagingModel, nil
+}
+
+// rankedListing lists the stories by the offsets in the snapshot of ranked ids. The snapshot in the cursor is used while it is kept so that stories do not shift between pages as ranks change.
+func rankedListing(customerID int, r *http.Request) (*[]data.Story, *models.Paging, error) {
+	rankedStories, err := getCachedRankedStories(customerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := 0
+	cursor := decodeCursor(r.URL.Query().Get("after"))
+	if cursor != nil {
+		start = cursor.Offset
+	} else if cursor = decodeCursor(r.URL.Query().Get("before")); cursor != nil {
+		start = cursor.Offset - DefaultPageSize
+	}
+	if start < 0 {
+		start = 0
+	}
+	if cursor != nil {
+		snapshot := caching.GetRankedStoriesSnapshot(customerID, time.Unix(0, cursor.Snapshot))
+		if snapshot != nil {
+			rankedStories = snapshot
+		}
+	}
+	stories, err := getRankedStories(customerID, rankedStories, start, DefaultPageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	pagingModel := &models.Paging{}
+	snapshot := rankedStories.ComputedOn.UnixNano()
+	if start > 0 {
+		setPreviousCursor(pagingModel, r, &storyCursor{Snapshot: snapshot, Offset: start})
+	}
+	end := start + len(*stories)
+	if len(*stories) == DefaultPageSize && end < rankedStories.Count {
+		setNextCursor(pagingModel, r, &storyCursor{Snapshot: snapshot, Offset: end})
+	}
+	return stories, pagingModel, nil
+}
+
+func setNextCursor(pagingModel *models.Paging, r *http.Request, cursor *storyCursor) {
+	pagingModel.NextCursor = encodeCursor(cursor)
+	pagingModel.NextURL = pagingURL(r, "after", pagingModel.NextCursor)
+}
+
+func setPreviousCursor(pagingModel *models.Paging, r *http.Request, cursor *storyCursor) {
+	pagingModel.PreviousCursor = encodeCursor(cursor)
+	pagingModel.PreviousURL = pagingURL(r, "before", pagingModel.PreviousCursor)
+}
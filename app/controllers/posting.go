@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/screening"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const postingTimeLayout = "Jan 2, 15:04"
+
+/*PostingRulesHandler handles the probation rules of new members and the posting limits of the customer*/
+func PostingRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		renderPostingRules(w, r, newPostingRulesViewModel(user.CustomerID))
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	model := &models.PostingRulesViewModel{
+		ProbationDays:     parseFormCount(r.FormValue("probationdays")),
+		ProbationKarma:    parseFormCount(r.FormValue("probationkarma")),
+		RestrictedDomains: r.FormValue("restricteddomains"),
+		StoriesPerHour:    parseFormCount(r.FormValue("storiesperhour")),
+		StoriesPerDay:     parseFormCount(r.FormValue("storiesperday")),
+		CommentsPerHour:   parseFormCount(r.FormValue("commentsperhour")),
+		CommentsPerDay:    parseFormCount(r.FormValue("commentsperday")),
+		KarmaPerExtraPost: parseFormCount(r.FormValue("karmaperextrapost")),
+	}
+	if model.Validate() == false {
+		renderPostingRules(w, r, model)
+		return
+	}
+	err = data.SavePostingRules(&data.PostingRules{
+		CustomerID:        user.CustomerID,
+		ProbationDays:     model.ProbationDays,
+		ProbationKarma:    model.ProbationKarma,
+		RestrictedDomains: model.DomainList(),
+		StoriesPerHour:    model.StoriesPerHour,
+		StoriesPerDay:     model.StoriesPerDay,
+		CommentsPerHour:   model.CommentsPerHour,
+		CommentsPerDay:    model.CommentsPerDay,
+		KarmaPerExtraPost: model.KarmaPerExtraPost,
+		UpdatedOn:         time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	model = newPostingRulesViewModel(user.CustomerID)
+	model.SuccessMessage = "Posting rules are saved."
+	renderPostingRules(w, r, model)
+}
+
+func newPostingRulesViewModel(customerID int) *models.PostingRulesViewModel {
+	rules, err := data.GetPostingRules(customerID)
+	if err != nil {
+		panic(err)
+	}
+	return &models.PostingRulesViewModel{
+		ProbationDays:     rules.ProbationDays,
+		ProbationKarma:    rules.ProbationKarma,
+		RestrictedDomains: strings.Join(rules.RestrictedDomains, "\n"),
+		StoriesPerHour:    rules.StoriesPerHour,
+		StoriesPerDay:     rules.StoriesPerDay,
+		CommentsPerHour:   rules.CommentsPerHour,
+		CommentsPerDay:    rules.CommentsPerDay,
+		KarmaPerExtraPost: rules.KarmaPerExtraPost,
+	}
+}
+
+func renderPostingRules(w http.ResponseWriter, r *http.Request, model *models.PostingRulesViewModel) {
+	err := templates.RenderInLayout(w, r, "posting.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// postingCheck checks the posting rules of the customer for the signed in user
+type postingCheck struct {
+	user  *data.User
+	rules *data.PostingRules
+}
+
+func newPostingCheck(r *http.Request) *postingCheck {
+	user, err := data.GetUserByID(shared.GetUserFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	rules, err := data.GetPostingRules(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	return &postingCheck{user, rules}
+}
+
+// onProbation checks whether the user is neither old enough nor has enough karma yet
+func (check *postingCheck) onProbation() bool {
+	rules := check.rules
+	if rules.ProbationDays == 0 && rules.ProbationKarma == 0 {
+		return false
+	}
+	if rules.ProbationDays > 0 && time.Now().After(check.probationEnd()) {
+		return false
+	}
+	if rules.ProbationKarma > 0 && check.user.Karma >= rules.ProbationKarma {
+		return false
+	}
+	return true
+}
+
+func (check *postingCheck) probationEnd() time.Time {
+	return check.user.RegisteredOn.Add(time.Duration(check.rules.ProbationDays) * 24 * time.Hour)
+}
+
+// probationMessage explains when the user leaves probation
+func (check *postingCheck) probationMessage(restriction string) string {
+	conditions := []string{}
+	if check.rules.ProbationDays > 0 {
+		conditions = append(conditions, "after "+check.probationEnd().Format(postingTimeLayout))
+	}
+	if check.rules.ProbationKarma > 0 {
+		conditions = append(conditions, fmt.Sprintf("once you have %d karma", check.rules.ProbationKarma))
+	}
+	return fmt.Sprintf("New members %s. You can %s.", restriction, strings.Join(conditions, " or "))
+}
+
+// limit returns the posting limit scaled by the karma of the user
+func (check *postingCheck) limit(base int) int {
+	if base == 0 || check.rules.KarmaPerExtraPost == 0 || check.user.Karma <= 0 {
+		return base
+	}
+	return base + check.user.Karma/check.rules.KarmaPerExtraPost
+}
+
+// throttleMessage explains when the user can post again if the user has reached a posting limit. Returns empty string otherwise.
+func (check *postingCheck) throttleMessage(kind string, perHour, perDay int, getTimes func(int, time.Time) ([]time.Time, error)) string {
+	periods := []struct {
+		name   string
+		window time.Duration
+		limit  int
+	}{
+		{"day", 24 * time.Hour, check.limit(perDay)},
+		{"hour", time.Hour, check.limit(perHour)},
+	}
+	for _, period := range periods {
+		if period.limit == 0 {
+			continue
+		}
+		times, err := getTimes(check.user.ID, time.Now().Add(-period.window))
+		if err != nil {
+			panic(err)
+		}
+		if len(times) < period.limit {
+			continue
+		}
+		// a post is allowed again once enough of the posts in the window get older than the window
+		nextPostOn := times[len(times)-period.limit].Add(period.window)
+		return fmt.Sprintf("You can post at most %d %s per %s. You can post again after %s.",
+			period.limit, kind, period.name, nextPostOn.Format(postingTimeLayout))
+	}
+	return ""
+}
+
+// storyThrottleMessage explains when the user can submit a story again. Returns empty string if the user can submit now.
+func (check *postingCheck) storyThrottleMessage() string {
+	return check.throttleMessage("stories", check.rules.StoriesPerHour, check.rules.StoriesPerDay, data.GetUserStoryTimesSince)
+}
+
+// commentThrottleMessage explains when the user can comment again. Returns empty string if the user can comment now.
+func (check *postingCheck) commentThrottleMessage() string {
+	return check.throttleMessage("comments", check.rules.CommentsPerHour, check.rules.CommentsPerDay, data.GetUserCommentTimesSince)
+}
+
+// linkNotice explains the link restrictions of the user on probation. Returns empty string if the user is not on probation.
+func (check *postingCheck) linkNotice() string {
+	if !check.onProbation() {
+		return ""
+	}
+	return check.probationMessage("can submit text stories but not links")
+}
+
+// restrictedDomainMessage explains why the user on probation cannot post the links. Returns empty string if the links are allowed.
+func (check *postingCheck) restrictedDomainMessage(content *screening.Content) string {
+	if len(check.rules.RestrictedDomains) == 0 || !check.onProbation() {
+		return ""
+	}
+	for _, domain := range content.Domains() {
+		if restricted := screening.MatchDomain(domain, check.rules.RestrictedDomains); restricted != "" {
+			return check.probationMessage("cannot link to " + restricted)
+		}
+	}
+	return ""
+}
+
+// checkStoryRules returns the errors of the submit form which the story breaks the posting rules with
+func checkStoryRules(r *http.Request, url, text string) map[string]string {
+	errors := map[string]string{}
+	check := newPostingCheck(r)
+	if message := check.storyThrottleMessage(); message != "" {
+		errors["General"] = message
+		return errors
+	}
+	if strings.TrimSpace(url) != "" && check.onProbation() {
+		errors["URL"] = check.probationMessage("cannot submit links")
+		return errors
+	}
+	if message := check.restrictedDomainMessage(&screening.Content{URL: url, Text: text}); message != "" {
+		errors["General"] = message
+	}
+	return errors
+}
+
+// checkCommentRules returns the message which explains why the user cannot post the comment. Returns empty string if the comment meets the posting rules.
+func checkCommentRules(r *http.Request, text string) string {
+	check := newPostingCheck(r)
+	if message := check.commentThrottleMessage(); message != "" {
+		return message
+	}
+	return check.restrictedDomainMessage(&screening.Content{Text: text})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/jobs"
+	"linkwind/app/models"
+	"linkwind/app/ranking"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*RankingHandler handles selecting and tuning the ranking algorithm of the customer*/
+func RankingHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		settings, err := data.GetRankingSettings(user.CustomerID)
+		if err != nil {
+			panic(err)
+		}
+		renderRanking(w, r, &models.RankingViewModel{
+			Algorithm:      string(settings.Algorithm),
+			Gravity:        settings.Gravity,
+			CommentPenalty: settings.CommentPenalty,
+		})
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	commentPenalty, err := strconv.Atoi(strings.TrimSpace(r.FormValue("commentpenalty")))
+	if err != nil {
+		commentPenalty = -1
+	}
+	model := &models.RankingViewModel{
+		Algorithm:      r.FormValue("algorithm"),
+		Gravity:        parseRankingParameter(r.FormValue("gravity")),
+		CommentPenalty: commentPenalty,
+	}
+	if model.Validate() == false {
+		renderRanking(w, r, model)
+		return
+	}
+	err = data.SaveRankingSettings(&data.RankingSettings{
+		CustomerID:     user.CustomerID,
+		Algorithm:      enums.RankingAlgorithm(model.Algorithm),
+		Gravity:        model.Gravity,
+		CommentPenalty: model.CommentPenalty,
+		UpdatedOn:      time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Ranks of all stories are recalculated so that old stories are ordered by the new algorithm too
+	err = ranking.RefreshCustomerRanks(user.CustomerID, time.Time{})
+	if err != nil {
+		panic(err)
+	}
+	model.SuccessMessage = "Ranking settings are saved."
+	renderRanking(w, r, model)
+}
+
+func renderRanking(w http.ResponseWriter, r *http.Request, model *models.RankingViewModel) {
+	model.Algorithms = enums.RankingAlgorithms
+	err := templates.RenderInLayout(w, r, "ranking.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// refreshRecentRanks recalculates the ranks of the stories which the ranking job refreshes after their ranking parameters change
+func refreshRecentRanks(customerID int) {
+	err := ranking.RefreshCustomerRanks(customerID, time.Now().Add(-jobs.RankRefreshWindow))
+	if err != nil {
+		panic(err)
+	}
+}
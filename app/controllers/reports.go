@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	/*MaxReportsPerDay represents the number of stories and comments a user can flag in a day*/
+	MaxReportsPerDay = 10
+
+	reportQueueSize = 100
+)
+
+/*FlagHandler handles flagging a story or comment of another user for moderators to review*/
+func FlagHandler(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	targetID, _ := strconv.Atoi(r.FormValue("id"))
+	target, err := data.GetReportTarget(user.CustomerID, r.FormValue("type"), targetID)
+	if err != nil {
+		panic(err)
+	}
+	if target == nil {
+		renderNotFound(w)
+		return
+	}
+	settings, err := data.GetReportSettings(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.FlagViewModel{
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		StoryID:    target.StoryID,
+		StoryTitle: target.StoryTitle,
+		Text:       shortenText(target.Text, 300),
+		Reasons:    settings.Reasons,
+		Errors:     make(map[string]string),
+	}
+	if r.Method == "POST" {
+		if !ensureNotBanned(w, r) {
+			return
+		}
+		model.Reason = r.FormValue("reason")
+		handleFlagPOST(user, target, settings, model)
+	}
+	err = templates.RenderInLayout(w, r, "flag.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func handleFlagPOST(user *shared.SignedInUserClaims, target *data.ReportTarget, settings *data.ReportSettings, model *models.FlagViewModel) {
+	if target.AuthorID == user.ID {
+		model.Errors["General"] = fmt.Sprintf("You cannot flag your own %s.", target.Type)
+		return
+	}
+	if model.Validate() == false {
+		return
+	}
+	reportCount, err := data.GetUserReportCountSince(user.ID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		panic(err)
+	}
+	if reportCount >= MaxReportsPerDay {
+		model.Errors["General"] = fmt.Sprintf("You can flag at most %d stories and comments a day.", MaxReportsPerDay)
+		return
+	}
+	saved, openCount, err := data.AddReport(&data.Report{
+		CustomerID: user.CustomerID,
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		StoryID:    target.StoryID,
+		UserID:     user.ID,
+		Reason:     model.Reason,
+		ReportedOn: time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	if !saved {
+		model.SuccessMessage = fmt.Sprintf("You have already flagged this %s.", target.Type)
+		return
+	}
+	if settings.HideThreshold > 0 && openCount >= settings.HideThreshold && !target.Hidden {
+		setReportTargetHidden(user.CustomerID, target, true)
+	}
+	model.SuccessMessage = fmt.Sprintf("Thank you. Moderators will review the %s.", target.Type)
+}
+
+// setReportTargetHidden hides or shows the story or comment and clears the cached listings which may contain it
+func setReportTargetHidden(customerID int, target *data.ReportTarget, hidden bool) {
+	err := data.SetReportTargetHidden(target.Type, target.ID, hidden)
+	if err != nil {
+		panic(err)
+	}
+	target.Hidden = hidden
+	if target.Type == data.ReportTargetStory {
+		caching.DeleteRankedStories(customerID)
+	}
+	caching.InvalidatePages(customerID)
+}
+
+/*ReportsHandler handles the report queue which moderators resolve the flagged stories and comments on, and the report settings of the customer*/
+func ReportsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		renderReports(w, r, newReportsViewModel(user.CustomerID))
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	switch r.FormValue("action") {
+	case data.ReportDismissed, data.ReportRemoved, data.ReportAuthorBanned:
+		handleResolveReportsPOST(w, r)
+	default:
+		handleReportSettingsPOST(w, r, user.CustomerID)
+	}
+}
+
+func newReportsViewModel(customerID int) *models.ReportsViewModel {
+	settings, err := data.GetReportSettings(customerID)
+	if err != nil {
+		panic(err)
+	}
+	return &models.ReportsViewModel{
+		Reasons:       strings.Join(settings.Reasons, "\n"),
+		HideThreshold: settings.HideThreshold,
+	}
+}
+
+func handleReportSettingsPOST(w http.ResponseWriter, r *http.Request, customerID int) {
+	hideThreshold, err := strconv.Atoi(strings.TrimSpace(r.FormValue("hidethreshold")))
+	if err != nil {
+		hideThreshold = -1
+	}
+	model := &models.ReportsViewModel{
+		Reasons:       r.FormValue("reasons"),
+		HideThreshold: hideThreshold,
+	}
+	if model.Validate() == false {
+		renderReports(w, r, model)
+		return
+	}
+	err = data.SaveReportSettings(&data.ReportSettings{
+		CustomerID:    customerID,
+		Reasons:       model.ReasonList(),
+		HideThreshold: model.HideThreshold,
+		UpdatedOn:     time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	model = newReportsViewModel(customerID)
+	model.SuccessMessage = "Report settings are saved."
+	renderReports(w, r, model)
+}
+
+func handleResolveReportsPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	targetID, _ := strconv.Atoi(r.FormValue("id"))
+	target, err := data.GetReportTarget(user.CustomerID, r.FormValue("type"), targetID)
+	if err != nil {
+		panic(err)
+	}
+	if target == nil {
+		renderNotFound(w)
+		return
+	}
+	status := r.FormValue("action")
+	model := newReportsViewModel(user.CustomerID)
+	switch status {
+	case data.ReportDismissed:
+		// only the items which the reports hid are shown again, the held ones wait for screening
+		if target.Hidden && !target.Held {
+			setReportTargetHidden(user.CustomerID, target, false)
+		}
+		model.SuccessMessage = fmt.Sprintf("Reports of the %s are dismissed.", target.Type)
+	case data.ReportRemoved:
+		if !target.Hidden {
+			setReportTargetHidden(user.CustomerID, target, true)
+		}
+		model.SuccessMessage = fmt.Sprintf("The %s is removed.", target.Type)
+	case data.ReportAuthorBanned:
+		if !target.Hidden {
+			setReportTargetHidden(user.CustomerID, target, true)
+		}
+		banUser(r, &data.UserBan{
+			UserID:   target.AuthorID,
+			UserName: target.AuthorName,
+			Kind:     data.UserBanPermanent,
+			Reason:   fmt.Sprintf("Reported %s #%d", target.Type, target.ID),
+		})
+		model.SuccessMessage = fmt.Sprintf("The %s is removed and %s is banned.", target.Type, target.AuthorName)
+	}
+	trainReportedContent(user.CustomerID, target, status != data.ReportDismissed)
+	reporters, err := data.ResolveReports(user.CustomerID, target.Type, target.ID, status, user.ID)
+	if err != nil {
+		panic(err)
+	}
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       data.ModerationReportsResolved,
+		TargetUserID: target.AuthorID,
+		Target:       fmt.Sprintf("%s #%d", target.Type, target.ID),
+		Details:      status,
+	})
+	notifyReporters(r, target, status, reporters)
+	renderReports(w, r, model)
+}
+
+// notifyReporters mails the decision to the users who reported the target. Failed mails are reported to sentry and do not fail the resolution.
+func notifyReporters(r *http.Request, target *data.ReportTarget, status string, reporters *[]data.Reporter) {
+	customer := shared.GetCustomerFromContext(r)
+	domain, err := data.GetCustomerDomainByUserName(shared.GetUserFromContext(r).UserName)
+	if err != nil {
+		panic(err)
+	}
+	for _, reporter := range *reporters {
+		err = shared.SendReportResolvedMail(shared.ReportResolvedMailInfo{
+			Email:      reporter.Email,
+			UserName:   reporter.UserName,
+			Domain:     domain,
+			Platform:   customer.Platform,
+			TargetType: target.Type,
+			StoryID:    target.StoryID,
+			StoryTitle: target.StoryTitle,
+			Status:     status,
+		})
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+}
+
+// ensureNotBanned responds with forbidden status if the signed in user is banned or suspended
+func ensureNotBanned(w http.ResponseWriter, r *http.Request) bool {
+	message, err := userBanMessage(shared.GetUserFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	if message != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func renderReports(w http.ResponseWriter, r *http.Request, model *models.ReportsViewModel) {
+	user := shared.GetUserFromContext(r)
+	items, err := data.GetReportQueue(user.CustomerID, reportQueueSize)
+	if err != nil {
+		panic(err)
+	}
+	model.Items = *items
+	err = templates.RenderInLayout(w, r, "reports.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/screening"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"linkwind/app/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const heldContentPageSize = 100
+
+/*ScreeningHandler handles the screening queue which moderators approve or reject the held stories and comments on, and the screening settings of the customer*/
+func ScreeningHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		model := newScreeningViewModel(user.CustomerID)
+		model.Status = r.URL.Query().Get("status")
+		renderScreening(w, r, model)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	switch r.FormValue("action") {
+	case data.HeldContentApproved, data.HeldContentRejected:
+		handleReviewHeldContentPOST(w, r, user.CustomerID)
+	default:
+		handleScreeningSettingsPOST(w, r, user.CustomerID)
+	}
+}
+
+func newScreeningViewModel(customerID int) *models.ScreeningViewModel {
+	settings, err := data.GetScreeningSettings(customerID)
+	if err != nil {
+		panic(err)
+	}
+	return &models.ScreeningViewModel{
+		BannedDomains:      strings.Join(settings.BannedDomains, "\n"),
+		BannedPhrases:      strings.Join(settings.BannedPhrases, "\n"),
+		NewAccountDays:     settings.NewAccountDays,
+		MaxNewAccountLinks: settings.MaxNewAccountLinks,
+		MinReputation:      settings.MinReputation,
+		SpamThreshold:      settings.SpamThreshold,
+	}
+}
+
+func handleScreeningSettingsPOST(w http.ResponseWriter, r *http.Request, customerID int) {
+	model := &models.ScreeningViewModel{
+		BannedDomains:      r.FormValue("banneddomains"),
+		BannedPhrases:      r.FormValue("bannedphrases"),
+		NewAccountDays:     parseFormCount(r.FormValue("newaccountdays")),
+		MaxNewAccountLinks: parseFormCount(r.FormValue("maxnewaccountlinks")),
+		MinReputation:      parseScreeningThreshold(r.FormValue("minreputation")),
+		SpamThreshold:      parseScreeningThreshold(r.FormValue("spamthreshold")),
+	}
+	if model.Validate() == false {
+		renderScreening(w, r, model)
+		return
+	}
+	err := data.SaveScreeningSettings(&data.ScreeningSettings{
+		CustomerID:         customerID,
+		BannedDomains:      model.DomainList(),
+		BannedPhrases:      model.PhraseList(),
+		NewAccountDays:     model.NewAccountDays,
+		MaxNewAccountLinks: model.MaxNewAccountLinks,
+		MinReputation:      model.MinReputation,
+		SpamThreshold:      model.SpamThreshold,
+		UpdatedOn:          time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	model = newScreeningViewModel(customerID)
+	model.SuccessMessage = "Screening settings are saved."
+	renderScreening(w, r, model)
+}
+
+func handleReviewHeldContentPOST(w http.ResponseWriter, r *http.Request, customerID int) {
+	user := shared.GetUserFromContext(r)
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid held content id.", http.StatusBadRequest)
+		return
+	}
+	item, err := data.GetHeldContentByID(customerID, id)
+	if err != nil {
+		panic(err)
+	}
+	if item == nil {
+		renderNotFound(w)
+		return
+	}
+	model := newScreeningViewModel(customerID)
+	if item.Status != data.HeldContentHeld {
+		model.SuccessMessage = fmt.Sprintf("The %s is already %s.", item.TargetType, item.Status)
+		renderScreening(w, r, model)
+		return
+	}
+	content := &screening.Content{
+		CustomerID: customerID,
+		Type:       item.TargetType,
+		UserID:     item.UserID,
+		Text:       item.Text,
+	}
+	var story *data.Story
+	if item.TargetType == data.ReportTargetStory {
+		story, err = data.GetStoryByID(item.TargetID)
+		if err != nil {
+			panic(err)
+		}
+		content.Title = story.Title
+		content.URL = story.URL
+	}
+	status := r.FormValue("action")
+	if status == data.HeldContentApproved {
+		publishHeldContent(customerID, item, story)
+		model.SuccessMessage = fmt.Sprintf("The %s of %s is published.", item.TargetType, item.UserName)
+	} else {
+		model.SuccessMessage = fmt.Sprintf("The %s of %s is rejected.", item.TargetType, item.UserName)
+	}
+	err = data.ReviewHeldContent(item.ID, status, user.ID)
+	if err != nil {
+		panic(err)
+	}
+	err = screening.Train(content, status == data.HeldContentRejected)
+	if err != nil {
+		panic(err)
+	}
+	logModeration(r, &data.ModerationLogEntry{
+		Action:       data.ModerationContentReviewed,
+		TargetUserID: item.UserID,
+		Target:       fmt.Sprintf("%s #%d", item.TargetType, item.TargetID),
+		Details:      status,
+	})
+	renderScreening(w, r, model)
+}
+
+// publishHeldContent shows the approved story or comment and emits the webhook event which is skipped while it is held
+func publishHeldContent(customerID int, item *data.HeldContent, story *data.Story) {
+	target, err := data.GetReportTarget(customerID, item.TargetType, item.TargetID)
+	if err != nil {
+		panic(err)
+	}
+	if target == nil {
+		return
+	}
+	setReportTargetHidden(customerID, target, false)
+	if story != nil {
+		refreshStoryRank(customerID, story.ID)
+		emitContentCreated(customerID, story.UserID, enums.StoryCreated, webhooks.NewStoryPayload(story, story.UserName))
+		return
+	}
+	comment, err := data.GetCommentByID(item.TargetID)
+	if err != nil {
+		panic(err)
+	}
+	if comment != nil {
+		emitContentCreated(customerID, comment.UserID, enums.CommentCreated, webhooks.NewCommentPayload(comment))
+	}
+}
+
+// screenContent runs the default screening pipeline on the story or comment of the signed in user before it is published
+func screenContent(r *http.Request, content *screening.Content) *screening.Verdict {
+	user, err := data.GetUserByID(shared.GetUserFromContext(r).ID)
+	if err != nil {
+		panic(err)
+	}
+	content.CustomerID = user.CustomerID
+	content.UserID = user.ID
+	content.UserRegisteredOn = user.RegisteredOn
+	verdict, err := screening.Default.Screen(content)
+	if err != nil {
+		panic(err)
+	}
+	return verdict
+}
+
+// holdContent adds the created story or comment which is held by screening to the screening queue
+func holdContent(content *screening.Content, verdict *screening.Verdict, targetID, storyID int) {
+	domain := ""
+	if content.Type == data.ReportTargetStory {
+		domain = shared.URLDomain(content.URL)
+	}
+	err := data.AddHeldContent(&data.HeldContent{
+		CustomerID: content.CustomerID,
+		TargetType: content.Type,
+		TargetID:   targetID,
+		StoryID:    storyID,
+		UserID:     content.UserID,
+		Domain:     domain,
+		Reasons:    verdict.Reasons,
+		SpamScore:  verdict.SpamScore,
+		HeldOn:     time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// trainReportedContent teaches the spam classifier the moderator decision on a reported story or comment
+func trainReportedContent(customerID int, target *data.ReportTarget, spam bool) {
+	content := &screening.Content{
+		CustomerID: customerID,
+		Type:       target.Type,
+		UserID:     target.AuthorID,
+		Text:       target.Text,
+	}
+	if target.Type == data.ReportTargetStory {
+		content.Title = target.StoryTitle
+	}
+	err := screening.Train(content, spam)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// parseScreeningThreshold returns -1 for invalid values so that validation fails
+func parseScreeningThreshold(value string) float64 {
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return -1
+	}
+	return threshold
+}
+
+func renderScreening(w http.ResponseWriter, r *http.Request, model *models.ScreeningViewModel) {
+	user := shared.GetUserFromContext(r)
+	model.Statuses = []string{data.HeldContentHeld, data.HeldContentApproved, data.HeldContentRejected}
+	if model.Status != data.HeldContentApproved && model.Status != data.HeldContentRejected {
+		model.Status = data.HeldContentHeld
+	}
+	items, err := data.GetHeldContents(user.CustomerID, model.Status, heldContentPageSize)
+	if err != nil {
+		panic(err)
+	}
+	model.Items = *items
+	classifier, err := data.GetSpamClassifier(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	model.SpamDocuments = classifier.SpamDocuments
+	model.HamDocuments = classifier.HamDocuments
+	err = templates.RenderInLayout(w, r, "screening.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
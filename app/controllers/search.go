@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"encoding/json"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/search"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	/*SearchPageSize represents the number of search results listed per page*/
+	SearchPageSize = 20
+
+	searchDateLayout = "2006-01-02"
+)
+
+/*SearchHandler handles searching the stories and comments of the customer*/
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	model := &models.SearchViewModel{
+		Query:  strings.TrimSpace(values.Get("q")),
+		Author: strings.TrimSpace(values.Get("author")),
+		Tag:    values.Get("tag"),
+		Kind:   values.Get("kind"),
+		Type:   values.Get("type"),
+		From:   values.Get("from"),
+		To:     values.Get("to"),
+		Tags:   *getCustomerTags(r),
+		Kinds:  *getCustomerStoryKinds(r),
+		Errors: make(map[string]string),
+	}
+	if model.Query != "" {
+		query, message := newSearchQuery(r)
+		if message != "" {
+			model.Errors["Query"] = message
+		} else {
+			results, hasNextPage := runSearch(query)
+			model.Searched = true
+			model.Results = results
+			if query.PageNumber > 1 {
+				model.PreviousPageURL = searchPageURL(values, query.PageNumber-1)
+			}
+			if hasNextPage {
+				model.NextPageURL = searchPageURL(values, query.PageNumber+1)
+			}
+		}
+	}
+	err := templates.RenderInLayout(w, r, "search.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*SearchAPIHandler handles searching the stories and comments of the customer and returns the results as json*/
+func SearchAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSpace(r.URL.Query().Get("q")) == "" {
+		http.Error(w, "Please enter a search query.", http.StatusBadRequest)
+		return
+	}
+	query, message := newSearchQuery(r)
+	if message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+	results, hasNextPage := runSearch(query)
+	response := &models.SearchResponse{
+		Results: results,
+		Page:    query.PageNumber,
+	}
+	if hasNextPage {
+		response.NextPage = query.PageNumber + 1
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// newSearchQuery creates the search query by the request parameters. Returns the error message if any of the filters is invalid.
+func newSearchQuery(r *http.Request) (*data.SearchQuery, string) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	values := r.URL.Query()
+	query := &data.SearchQuery{
+		CustomerID: customerCtx.ID,
+		Text:       strings.TrimSpace(values.Get("q")),
+		Author:     strings.TrimSpace(values.Get("author")),
+		Tag:        values.Get("tag"),
+		Kind:       values.Get("kind"),
+		Type:       values.Get("type"),
+		PageNumber: getPage(r),
+		PageSize:   SearchPageSize,
+	}
+	if query.PageNumber < 1 {
+		query.PageNumber = 1
+	}
+	if query.Type != "" && query.Type != data.SearchResultStory && query.Type != data.SearchResultComment {
+		return nil, "Type must be story or comment."
+	}
+	if from := values.Get("from"); from != "" {
+		date, err := time.Parse(searchDateLayout, from)
+		if err != nil {
+			return nil, "From date must be in yyyy-mm-dd format."
+		}
+		query.From = &date
+	}
+	if to := values.Get("to"); to != "" {
+		date, err := time.Parse(searchDateLayout, to)
+		if err != nil {
+			return nil, "To date must be in yyyy-mm-dd format."
+		}
+		// To date is inclusive
+		date = date.Add(24 * time.Hour)
+		query.To = &date
+	}
+	return query, ""
+}
+
+// runSearch fetches one more result than the page size to find out whether there is a next page
+func runSearch(query *data.SearchQuery) ([]models.SearchResultViewModel, bool) {
+	pageSize := query.PageSize
+	query.PageSize = pageSize + 1
+	results, err := search.Default.Search(query)
+	query.PageSize = pageSize
+	if err != nil {
+		panic(err)
+	}
+	hasNextPage := len(*results) > pageSize
+	viewModels := []models.SearchResultViewModel{}
+	for i, result := range *results {
+		if i == pageSize {
+			break
+		}
+		viewModels = append(viewModels, *mapSearchResultToViewModel(&result))
+	}
+	return viewModels, hasNextPage
+}
+
+func mapSearchResultToViewModel(result *data.SearchResult) *models.SearchResultViewModel {
+	viewModel := &models.SearchResultViewModel{
+		Type:            result.Type,
+		StoryID:         result.StoryID,
+		CommentID:       result.CommentID,
+		Title:           search.HighlightHTML(result.TitleHeadline),
+		Headline:        search.HighlightHTML(result.Headline),
+		URL:             result.URL,
+		UserName:        result.UserName,
+		Points:          result.Points,
+		CommentCount:    result.CommentCount,
+		CreatedOn:       result.CreatedOn,
+		SubmittedOnText: shared.DateToString(result.CreatedOn),
+	}
+	if uri, err := url.Parse(result.URL); err == nil {
+		viewModel.Host = uri.Hostname()
+	}
+	return viewModel
+}
+
+func searchPageURL(values url.Values, page int) string {
+	pageValues := url.Values{}
+	for key, value := range values {
+		pageValues[key] = value
+	}
+	pageValues.Set("page", strconv.Itoa(page))
+	return "/search?" + pageValues.Encode()
+}
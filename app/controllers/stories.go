@@ -1,14 +1,19 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"linkwind/app/caching"
 	"linkwind/app/data"
 	"linkwind/app/enums"
 	"linkwind/app/models"
+	"linkwind/app/ranking"
+	"linkwind/app/screening"
 	"linkwind/app/shared"
 	"linkwind/app/templates"
+	"linkwind/app/webhooks"
 	"math"
 	"net/http"
 	"net/url"
@@ -24,12 +29,23 @@ const (
 	DefaultPageSize = 15
 	/*MinKarmaToDownVote represents minimum number of karma a user needs to downvote a story or comment*/
 	MinKarmaToDownVote = 100
+	/*DuplicateStoryRedirectWindow represents the period which a duplicate submission is redirected to the existing story*/
+	DuplicateStoryRedirectWindow = 7 * 24 * time.Hour
+	/*DuplicateStoryPromptWindow represents the period which user is warned about the existing story before submitting the same url*/
+	DuplicateStoryPromptWindow = 365 * 24 * time.Hour
+	/*AnonymousPageTTL represents how long the story listings of anonymous users are cached*/
+	AnonymousPageTTL = 30 * time.Second
+	/*DefaultMaxCommentDepth represents how many levels of the comment tree are rendered unless MAX_COMMENT_DEPTH is set*/
+	DefaultMaxCommentDepth = 6
+	/*CollapsedCommentScore represents the score (upvotes - downvotes) which a comment and its replies are collapsed at or below*/
+	CollapsedCommentScore = -4
+
+	storyDescriptionLength = 300
 )
 
 /*StoryVoteModel represents the data in http request body to upvote story.*/
 type StoryVoteModel struct {
 	StoryID  int
-	UserID   int
 	VoteType enums.VoteType
 }
 
@@ -46,33 +62,156 @@ type JSONResponse struct {
 
 type getStoriesPaged func(customerID, pageNo, storyCountPerPage int) (*[]data.Story, error)
 
-/*StoriesHandler handles showing the popular published stories*/
+type getStoriesCount func(customerID int) (int, error)
+
+/*StoriesHandler handles showing the popular published stories. Stories tagged with signed in user's hidden tags are not listed.*/
 func StoriesHandler(w http.ResponseWriter, r *http.Request) {
-	renderStoriesPage("Stories", data.GetStories, w, r)
+	user := shared.GetUserFromContext(r)
+	if user != nil {
+		hiddenTags, err := data.GetHiddenTags(user.ID)
+		if err != nil {
+			panic(err)
+		}
+		if len(hiddenTags) > 0 {
+			fnGetStories := func(customerID, pageNo, storyCountPerPage int) (*[]data.Story, error) {
+				return data.GetStoriesExcludingTags(customerID, hiddenTags, pageNo, storyCountPerPage)
+			}
+			fnCount := func(customerID int) (int, error) {
+				return data.GetCustomerStoriesCountExcludingTags(customerID, hiddenTags)
+			}
+			renderStoriesPage("Stories", nil, pagedListing(fnGetStories, fnCount), w, r)
+			return
+		}
+	}
+	renderStoriesPage("Stories", nil, rankedListing, w, r)
+}
+
+// getRankedStories reads the stories by the ranked story ids starting from given offset. Stories after the cached ids are read from database.
+func getRankedStories(customerID int, rankedStories *caching.RankedStories, start, count int) (*[]data.Story, error) {
+	end := start + count
+	if end > len(rankedStories.IDs) && len(rankedStories.IDs) == ranking.MaxCachedRankedStories {
+		return data.GetStories(customerID, start/count+1, count)
+	}
+	if start >= len(rankedStories.IDs) {
+		return &[]data.Story{}, nil
+	}
+	if end > len(rankedStories.IDs) {
+		end = len(rankedStories.IDs)
+	}
+	return data.GetStoriesByIDs(rankedStories.IDs[start:end])
+}
+
+func getCachedRankedStories(customerID int) (*caching.RankedStories, error) {
+	rankedStories := caching.GetRankedStories(customerID, ranking.RankedStoriesTTL)
+	if rankedStories != nil {
+		return rankedStories, nil
+	}
+	return ranking.CacheRankedStories(customerID)
 }
 
 /*RecentStoriesHandler handles showing recently published stories*/
 func RecentStoriesHandler(w http.ResponseWriter, r *http.Request) {
-	renderStoriesPage("Recent Stories", data.GetRecentStories, w, r)
+	renderStoriesPage("Recent Stories", newFeedViewModel("Recent Stories", "/feeds/recent"), keysetListing(data.GetRecentStoriesByKeyset), w, r)
 }
 
-func renderStoriesPage(title string, fnGetStories getStoriesPaged, w http.ResponseWriter, r *http.Request) {
-	var model = &models.StoryPageViewModel{Title: title}
+/*StoriesAPIHandler handles listing the ranked or recent stories as json. Next and previous pages are requested with the cursors in the paging of the response.*/
+func StoriesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	var listing storyListing
+	switch r.URL.Query().Get("list") {
+	case "", "ranked":
+		listing = rankedListing
+	case "recent":
+		listing = keysetListing(data.GetRecentStoriesByKeyset)
+	default:
+		http.Error(w, "List must be ranked or recent.", http.StatusBadRequest)
+		return
+	}
 	customerCtx := shared.GetCustomerFromContext(r)
-	user := shared.GetUserFromContext(r)
-
-	var page int = getPage(r)
-	stories, err := fnGetStories(customerCtx.ID, page, DefaultPageSize)
+	stories, pagingModel, err := listing(customerCtx.ID, r)
+	if err != nil {
+		panic(err)
+	}
+	response := &models.StoriesResponse{
+		Stories: []models.StoryViewModel{},
+		Paging:  pagingModel,
+	}
+	if len(*stories) > 0 {
+		response.Stories = *mapStoriesToStoryViewModel(stories, shared.GetUserFromContext(r))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
 		panic(err)
 	}
+}
 
-	storiesCount, err := data.GetCustomerStoriesCount(customerCtx.ID)
+/*TagStoriesHandler handles showing the popular stories tagged with the tag in /t/{tag} path*/
+func TagStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	tag := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, "/t/"), "/"))
+	tags, err := data.GetTagsByCustomerID(customerCtx.ID)
 	if err != nil {
 		panic(err)
 	}
+	if tag == "" || models.ValidateStoryTags([]string{tag}, *tags) != "" {
+		renderNotFound(w)
+		return
+	}
+	fnGetStories := func(customerID, pageNo, storyCountPerPage int) (*[]data.Story, error) {
+		return data.GetStoriesByTag(customerID, tag, pageNo, storyCountPerPage)
+	}
+	fnCount := func(customerID int) (int, error) {
+		return data.GetCustomerStoriesCountByTag(customerID, tag)
+	}
+	renderStoriesPage("Stories tagged "+tag, nil, pagedListing(fnGetStories, fnCount), w, r)
+}
+
+/*AskStoriesHandler handles showing the popular ask stories*/
+func AskStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	renderStoryKindPage("Ask", data.StoryKindAsk, w, r)
+}
+
+/*ShowStoriesHandler handles showing the popular show stories*/
+func ShowStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	renderStoryKindPage("Show", data.StoryKindShow, w, r)
+}
+
+/*KindStoriesHandler handles showing the popular stories of the kind in /k/{kind} path*/
+func KindStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	kind := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, "/k/"), "/"))
+	if kind == "" || models.ValidateStoryKind(kind, *getCustomerStoryKinds(r)) != "" {
+		renderNotFound(w)
+		return
+	}
+	renderStoryKindPage("Stories of kind "+kind, kind, w, r)
+}
+
+func renderStoryKindPage(title, kind string, w http.ResponseWriter, r *http.Request) {
+	fnGetStories := func(customerID, pageNo, storyCountPerPage int) (*[]data.Story, error) {
+		return data.GetStoriesByKind(customerID, kind, pageNo, storyCountPerPage)
+	}
+	fnCount := func(customerID int) (int, error) {
+		return data.GetCustomerStoriesCountByKind(customerID, kind)
+	}
+	renderStoriesPage(title, nil, pagedListing(fnGetStories, fnCount), w, r)
+}
+
+func renderStoriesPage(title string, feed *models.FeedViewModel, listing storyListing, w http.ResponseWriter, r *http.Request) {
+	var model = &models.StoryPageViewModel{Title: title, Feed: feed}
+	customerCtx := shared.GetCustomerFromContext(r)
+	user := shared.GetUserFromContext(r)
+
+	// Pages of anonymous users are same for everyone so they are served from cache
+	pageKey := r.URL.RequestURI()
+	if user == nil {
+		if body, ok := caching.GetPage(customerCtx.ID, pageKey); ok {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(body)
+			return
+		}
+	}
 
-	pagingModel, err := setPagingViewModel(customerCtx.ID, page, storiesCount)
+	stories, pagingModel, err := listing(customerCtx.ID, r)
 	if err != nil {
 		panic(err)
 	}
@@ -80,7 +219,26 @@ func renderStoriesPage(title string, fnGetStories getStoriesPaged, w http.Respon
 	if stories != nil && len(*stories) > 0 {
 		model.Stories = *mapStoriesToStoryViewModel(stories, user)
 	}
-	templates.RenderInLayout(w, r, "stories.html", model)
+	if user != nil {
+		templates.RenderInLayout(w, r, "stories.html", model)
+		return
+	}
+	writer := &pageCachingWriter{ResponseWriter: w}
+	err = templates.RenderInLayout(writer, r, "stories.html", model)
+	if err == nil {
+		caching.SetPage(customerCtx.ID, pageKey, writer.body.Bytes(), AnonymousPageTTL)
+	}
+}
+
+// pageCachingWriter keeps a copy of the written page to cache it
+type pageCachingWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *pageCachingWriter) Write(b []byte) (int, error) {
+	writer.body.Write(b)
+	return writer.ResponseWriter.Write(b)
 }
 
 func setPagingViewModel(customerID, currentPage, storiesCount int) (*models.Paging, error) {
@@ -107,28 +265,7 @@ func UserSavedStoriesHandler(w http.ResponseWriter, r *http.Request) {
 		Title: "Saved Stories"}
 
 	user := shared.GetUserFromContext(r)
-
-	var page int = getPage(r)
-	stories, err := data.GetUserSavedStories(user.ID, page, DefaultPageSize)
-	if err != nil {
-		panic(err)
-	}
-	storiesCount, err := data.GetUserSavedStoriesCount(user.ID)
-	if err != nil {
-		panic(err)
-	}
-	pagingModel, err := setPagingViewModel(user.CustomerID, page, storiesCount)
-	if err != nil {
-		panic(err)
-	}
-	model.Page = pagingModel
-	if stories == nil || len(*stories) > 0 {
-		model.Stories = *mapStoriesToStoryViewModel(stories, user)
-	}
-	err = templates.RenderInLayout(w, r, "stories.html", model)
-	if err != nil {
-		panic(err)
-	}
+	renderUserStoriesPage(model, user.ID, data.GetUserSavedStoriesByKeyset, w, r)
 }
 
 /*UserSubmittedStoriesHandler handles user's submitted stories*/
@@ -142,27 +279,8 @@ func UserSubmittedStoriesHandler(w http.ResponseWriter, r *http.Request) {
 	if strings.TrimSpace(strUserID) != "" {
 		userID, _ = strconv.Atoi(strUserID)
 	}
-	var page int = getPage(r)
-	stories, err := data.GetUserSubmittedStories(userID, page, DefaultPageSize)
-	if err != nil {
-		panic(err)
-	}
-	storiesCount, err := data.GetUserSubmittedStoriesCount(userID)
-	if err != nil {
-		panic(err)
-	}
-	pagingModel, err := setPagingViewModel(user.CustomerID, page, storiesCount)
-	if err != nil {
-		panic(err)
-	}
-	model.Page = pagingModel
-	if stories == nil || len(*stories) > 0 {
-		model.Stories = *mapStoriesToStoryViewModel(stories, user)
-	}
-	err = templates.RenderInLayout(w, r, "stories.html", model)
-	if err != nil {
-		panic(err)
-	}
+	model.Feed = newFeedViewModel("Submitted Stories", fmt.Sprintf("/feeds/users?userid=%d", userID))
+	renderUserStoriesPage(model, userID, data.GetUserSubmittedStoriesByKeyset, w, r)
 }
 
 /*UserUpvotedStoriesHandler handles showing the upvoted stories by user*/
@@ -172,23 +290,22 @@ func UserUpvotedStoriesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := shared.GetUserFromContext(r)
+	renderUserStoriesPage(model, user.ID, data.GetUserUpvotedStoriesByKeyset, w, r)
+}
 
-	var page int = getPage(r)
-	stories, err := data.GetUserUpvotedStories(user.ID, page, DefaultPageSize)
-	if err != nil {
-		panic(err)
-	}
-	storiesCount, err := data.GetUserUpvotedStoriesCount(user.ID)
-	if err != nil {
-		panic(err)
-	}
-	pagingModel, err := setPagingViewModel(user.CustomerID, page, storiesCount)
+type getUserStoriesByKeyset func(userID, viewerUserID int, keyset *data.Keyset) (*data.KeysetPage, error)
+
+func renderUserStoriesPage(model *models.StoryPageViewModel, userID int, fnGetStories getUserStoriesByKeyset, w http.ResponseWriter, r *http.Request) {
+	viewer := shared.GetUserFromContext(r)
+	stories, pagingModel, err := getKeysetStories(userID, func(id int, keyset *data.Keyset) (*data.KeysetPage, error) {
+		return fnGetStories(id, viewer.ID, keyset)
+	}, r)
 	if err != nil {
 		panic(err)
 	}
 	model.Page = pagingModel
-	if stories == nil || len(*stories) > 0 {
-		model.Stories = *mapStoriesToStoryViewModel(stories, user)
+	if len(*stories) > 0 {
+		model.Stories = *mapStoriesToStoryViewModel(stories, shared.GetUserFromContext(r))
 	}
 	err = templates.RenderInLayout(w, r, "stories.html", model)
 	if err != nil {
@@ -209,11 +326,40 @@ func SubmitStoryHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func handlesSubmitGET(w http.ResponseWriter, r *http.Request) {
-	model := &models.StorySubmitModel{}
+	model := &models.StorySubmitModel{
+		AvailableTags:  *getCustomerTags(r),
+		AvailableKinds: *getCustomerStoryKinds(r),
+	}
+	check := newPostingCheck(r)
+	model.PostingNotice = check.storyThrottleMessage()
+	if model.PostingNotice == "" {
+		model.PostingNotice = check.linkNotice()
+	}
 	templates.RenderInLayout(w, r, "submit.html", model)
 }
 
+func getCustomerTags(r *http.Request) *[]data.Tag {
+	customerCtx := shared.GetCustomerFromContext(r)
+	tags, err := data.GetTagsByCustomerID(customerCtx.ID)
+	if err != nil {
+		panic(err)
+	}
+	return tags
+}
+
+func getCustomerStoryKinds(r *http.Request) *[]data.StoryKind {
+	customerCtx := shared.GetCustomerFromContext(r)
+	kinds, err := data.GetStoryKinds(customerCtx.ID)
+	if err != nil {
+		panic(err)
+	}
+	return kinds
+}
+
 func handleSubmitPOST(w http.ResponseWriter, r *http.Request) {
+	if !ensureNotBanned(w, r) {
+		return
+	}
 	if err := r.ParseForm(); err != nil {
 		panic(err)
 	}
@@ -221,14 +367,29 @@ func handleSubmitPOST(w http.ResponseWriter, r *http.Request) {
 		URL:   r.FormValue("url"),
 		Title: r.FormValue("title"),
 		Text:  r.FormValue("text"),
+		Tags:  r.Form["tags"],
+		Kind:  r.FormValue("kind"),
+
+		AvailableTags:    *getCustomerTags(r),
+		AvailableKinds:   *getCustomerStoryKinds(r),
+		ConfirmDuplicate: r.FormValue("confirmduplicate") == "true",
 	}
 	if model.Validate() == false {
 		templates.RenderInLayout(w, r, "submit.html", model)
 		return
 	}
+	if errors := checkStoryRules(r, model.URL, model.Text); len(errors) > 0 {
+		model.Errors = errors
+		templates.RenderInLayout(w, r, "submit.html", model)
+		return
+	}
+	customerCtx := shared.GetCustomerFromContext(r)
+	var metadata *shared.PageMetadata
+	var canonicalURL string
 	if strings.TrimSpace(model.URL) != "" {
-		fetchedTitle, err := shared.FetchURL(model.URL)
-		if err != nil {
+		var err error
+		metadata, err = shared.FetchPageMetadata(model.URL)
+		if err != nil || (metadata.Title == "" && strings.TrimSpace(model.Title) == "") {
 			fmt.Println(err)
 			model.Errors["URL"] = "Something went wrong while fetching URL. Please make sure that you entered a valid URL."
 			templates.RenderInLayout(w, r, "submit.html", model)
@@ -236,7 +397,33 @@ func handleSubmitPOST(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if strings.TrimSpace(model.Title) == "" {
-			model.Title = fetchedTitle
+			model.Title = metadata.Title
+		}
+
+		canonicalURL, err = shared.CanonicalizeSubmittedURL(model.URL, metadata.CanonicalURL)
+		if err != nil {
+			model.Errors["URL"] = "Please make sure that you entered a valid URL."
+			templates.RenderInLayout(w, r, "submit.html", model)
+			return
+		}
+		duplicate, err := data.GetStoryByCanonicalURL(customerCtx.ID, canonicalURL, time.Now().Add(-DuplicateStoryPromptWindow))
+		if err != nil {
+			panic(err)
+		}
+		if duplicate != nil {
+			if time.Since(duplicate.SubmittedOn) < DuplicateStoryRedirectWindow {
+				http.Redirect(w, r, fmt.Sprintf("/stories/detail?id=%d", duplicate.ID), http.StatusSeeOther)
+				return
+			}
+			if !model.ConfirmDuplicate {
+				model.Duplicate = &models.DuplicateStoryViewModel{
+					ID:              duplicate.ID,
+					Title:           duplicate.Title,
+					SubmittedOnText: shared.DateToString(duplicate.SubmittedOn),
+				}
+				templates.RenderInLayout(w, r, "submit.html", model)
+				return
+			}
 		}
 	}
 
@@ -245,18 +432,148 @@ func handleSubmitPOST(w http.ResponseWriter, r *http.Request) {
 	story.Title = model.Title
 	story.URL = model.URL
 	story.Text = model.Text
+	story.Tags = model.Tags
+	story.Kind = model.Kind
+	if story.Kind == "" {
+		story.Kind = data.DetectStoryKind(story.Title, story.URL, model.AvailableKinds)
+	}
 	story.CommentCount = 0
 	story.UpVotes = 0
 	story.SubmittedOn = time.Now()
 	story.UserID = user.ID
+	story.CanonicalURL = canonicalURL
+	if metadata != nil {
+		story.Metadata = data.LinkMetadata{
+			Description:  metadata.Description,
+			ImageURL:     metadata.ImageURL,
+			SiteName:     metadata.SiteName,
+			CanonicalURL: metadata.CanonicalURL,
+			PublishedOn:  metadata.PublishedOn,
+		}
+	}
+
+	content := &screening.Content{
+		Type:  data.ReportTargetStory,
+		Title: story.Title,
+		Text:  story.Text,
+		URL:   story.URL,
+	}
+	verdict := screenContent(r, content)
+	story.Hidden = verdict.Held()
 
 	err := data.CreateStory(&story)
 	if err != nil {
 		panic(err)
 	}
+	if story.Hidden {
+		// held stories are shown only to their authors until moderators review them
+		holdContent(content, verdict, story.ID, story.ID)
+		http.Redirect(w, r, fmt.Sprintf("/stories/detail?id=%d", story.ID), http.StatusSeeOther)
+		return
+	}
+	refreshStoryRank(customerCtx.ID, story.ID)
+	caching.DeleteRankedStories(customerCtx.ID)
+	emitContentCreated(customerCtx.ID, user.ID, enums.StoryCreated, webhooks.NewStoryPayload(&story, user.UserName))
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+/*EditStoryHandler handles editing the title, text, tags and kind of a story by its owner*/
+func EditStoryHandler(w http.ResponseWriter, r *http.Request) {
+	customerCtx := shared.GetCustomerFromContext(r)
+	user := shared.GetUserFromContext(r)
+	storyID, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		renderNotFound(w)
+		return
+	}
+	exists, err := data.ExistsStoryInCustomer(customerCtx.ID, storyID)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		renderNotFound(w)
+		return
+	}
+	story, err := data.GetStoryByID(storyID)
+	if err != nil {
+		panic(err)
+	}
+	if story.UserID != user.ID {
+		err = templates.RenderFile(w, "errors/500.html", nil)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	model := &models.StoryEditModel{
+		ID:             story.ID,
+		URL:            story.URL,
+		Title:          story.Title,
+		Text:           story.Text,
+		Tags:           story.Tags,
+		AvailableTags:  *getCustomerTags(r),
+		Kind:           story.Kind,
+		AvailableKinds: *getCustomerStoryKinds(r),
+	}
+	if r.Method != "POST" {
+		templates.RenderInLayout(w, r, "edit.html", model)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		panic(err)
+	}
+	model.Title = r.FormValue("title")
+	model.Text = r.FormValue("text")
+	model.Tags = r.Form["tags"]
+	model.Kind = r.FormValue("kind")
+	if model.Validate() == false {
+		templates.RenderInLayout(w, r, "edit.html", model)
+		return
+	}
+	story.Title = model.Title
+	story.Text = model.Text
+	story.Tags = model.Tags
+	story.Kind = model.Kind
+	err = data.UpdateStory(story)
+	if err != nil {
+		panic(err)
+	}
+	refreshStoryRank(customerCtx.ID, story.ID)
+	http.Redirect(w, r, fmt.Sprintf("/stories/detail?id=%d", story.ID), http.StatusSeeOther)
+}
+
+// refreshStoryRank updates the precalculated rank of the story immediately instead of waiting for the ranking job and drops the cached pages showing old votes. Errors are only reported since the job fixes the rank later.
+func refreshStoryRank(customerID, storyID int) {
+	err := ranking.RefreshStoryRank(customerID, storyID)
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+	caching.InvalidatePages(customerID)
+}
+
+/*SubmitPreviewHandler handles fetching the metadata of a url to prefill the submit form*/
+func SubmitPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	pageURL := strings.TrimSpace(r.URL.Query().Get("url"))
+	uri, err := url.Parse(pageURL)
+	if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || uri.Host == "" {
+		http.Error(w, "Please enter a valid URL.", http.StatusBadRequest)
+		return
+	}
+	metadata, err := shared.FetchPageMetadata(pageURL)
+	if err != nil {
+		fmt.Println(err)
+		http.Error(w, "Something went wrong while fetching URL.", http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(metadata)
+	if err != nil {
+		panic(err)
+	}
+}
+
 /*StoryDetailHandler handles showing comments by giving story id*/
 func StoryDetailHandler(w http.ResponseWriter, r *http.Request) {
 	strStoryID := r.URL.Query().Get("id")
@@ -282,31 +599,96 @@ func StoryDetailHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	comments, err := data.GetRootCommentsByStoryID(storyID)
+	user := shared.GetUserFromContext(r)
+	if (story.Hidden || story.AuthorShadowbanned) && !canSeeHiddenStory(story, user) {
+		renderNotFound(w)
+		return
+	}
+	comments, err := data.GetComments(storyID)
 	if err != nil {
 		panic(fmt.Errorf("Cannot get comments from db (StoryID : %d). Original err : %v", storyID, err))
 	}
 	model := &models.StoryDetailPageViewModel{
-		Title: story.Title,
+		Title:    story.Title,
+		Feed:     newFeedViewModel("Comments", fmt.Sprintf("/feeds/comments?id=%d", story.ID)),
+		IsHidden: story.Hidden,
 	}
-	user := shared.GetUserFromContext(r)
 	model.Story = mapStoryToStoryViewModel(story, user)
-	model.Comments = mapCommentsToViewModelsWithChildren(comments, user, storyID)
+	commentSort := getCommentSort(r, user)
+	tree := newCommentTree(comments, user, getMaxCommentDepth())
+	tree.sort(commentSort)
+	if user != nil {
+		tree.markNewSince(getLastStoryVisit(user.ID, storyID))
+		model.NewCommentCount = tree.newCount
+		model.CommentRestriction = newPostingCheck(r).commentThrottleMessage()
+	}
+	strThreadID := r.URL.Query().Get("thread")
+	model.CommentSorts = newCommentSortViewModels(storyID, strThreadID, commentSort)
+	if len(strThreadID) == 0 {
+		model.Comments = tree.build(tree.children[data.CommentRootID], 0)
+	} else {
+		threadID, _ := strconv.Atoi(strThreadID)
+		thread := tree.find(threadID)
+		if thread == nil {
+			renderNotFound(w)
+			return
+		}
+		model.IsThread = true
+		model.ParentThreadURL = commentThreadURL(storyID, thread.ParentID)
+		model.Comments = tree.build([]*data.Comment{thread}, 0)
+	}
 
 	templates.RenderInLayout(w, r, "detail.html", model)
 }
 
+// canSeeHiddenStory returns true if the user is the author of the story or an admin who reviews it
+func canSeeHiddenStory(story *data.Story, user *shared.SignedInUserClaims) bool {
+	if user == nil {
+		return false
+	}
+	if user.ID == story.UserID {
+		return true
+	}
+	isAdmin, err := data.IsUserAdmin(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	return isAdmin
+}
+
 func mapStoriesToStoryViewModel(stories *[]data.Story, userClaims *shared.SignedInUserClaims) *[]models.StoryViewModel {
 	var viewModels []models.StoryViewModel
-
+	states := getUserStoryStates(stories, userClaims)
 	for _, story := range *stories {
-		viewModel := mapStoryToStoryViewModel(&story, userClaims)
+		viewModel := newStoryViewModel(&story, userClaims, states[story.ID])
 		viewModels = append(viewModels, *viewModel)
 	}
 	return &viewModels
 }
 
 func mapStoryToStoryViewModel(story *data.Story, userClaims *shared.SignedInUserClaims) *models.StoryViewModel {
+	states := getUserStoryStates(&[]data.Story{*story}, userClaims)
+	return newStoryViewModel(story, userClaims, states[story.ID])
+}
+
+// getUserStoryStates fetches the vote and saved states of all stories in one query. Returns nil for anonymous users or on error.
+func getUserStoryStates(stories *[]data.Story, userClaims *shared.SignedInUserClaims) map[int]data.UserStoryState {
+	if userClaims == nil || len(*stories) == 0 {
+		return nil
+	}
+	ids := make([]int, len(*stories))
+	for i, story := range *stories {
+		ids[i] = story.ID
+	}
+	states, err := data.GetUserStoryStates(userClaims.ID, ids)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil
+	}
+	return states
+}
+
+func newStoryViewModel(story *data.Story, userClaims *shared.SignedInUserClaims, state data.UserStoryState) *models.StoryViewModel {
 	uri, _ := url.Parse(story.URL)
 	var viewModel = models.StoryViewModel{
 		ID:              story.ID,
@@ -323,30 +705,23 @@ func mapStoryToStoryViewModel(story *data.Story, userClaims *shared.SignedInUser
 		IsSaved:         false,
 		ShowDownvoteBtn: false,
 		SubmittedOnText: shared.DateToString(story.SubmittedOn),
+		Description:     shortenText(story.Metadata.Description, storyDescriptionLength),
+		SiteName:        story.Metadata.SiteName,
+		Tags:            story.Tags,
+		Kind:            story.Kind,
 	}
 
 	if userClaims != nil {
 		viewModel.SignedInUser = mapUserClaimsToSignedUserViewModel(userClaims)
 		viewModel.ShowDownvoteBtn = userClaims.Karma > MinKarmaToDownVote
-		voteType, err := data.GetStoryVoteByUser(userClaims.ID, story.ID)
-		if err != nil {
-			sentry.CaptureException(err)
-		}
-		if voteType != nil {
-			if *voteType == enums.UpVote {
+		if state.VoteType != nil {
+			if *state.VoteType == enums.UpVote {
 				viewModel.IsUpvoted = true
-			} else if *voteType == enums.DownVote {
+			} else if *state.VoteType == enums.DownVote {
 				viewModel.IsDownvoted = true
 			}
 		}
-
-		isSaved, err := data.CheckIfUserSavedStory(userClaims.ID, story.ID)
-
-		if err != nil {
-			sentry.CaptureException(err)
-			isSaved = false
-		}
-		viewModel.IsSaved = isSaved
+		viewModel.IsSaved = state.IsSaved
 	}
 	return &viewModel
 }
@@ -364,10 +739,22 @@ func VoteStoryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	voteType, err := data.GetStoryVoteByUser(model.UserID, model.StoryID)
+	userID := shared.GetUserFromContext(r).ID
+	customerCtx := shared.GetCustomerFromContext(r)
+	message, err := checkVoteRules(customerCtx.ID, userID)
 	if err != nil {
-		sentry.CaptureMessage(fmt.Sprintf("Error occured while getting user's current vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", model.UserID, model.StoryID, model.VoteType, err))
-		http.Error(w, fmt.Sprintf("Error occured while getting user's current vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", model.UserID, model.StoryID, model.VoteType, err), http.StatusInternalServerError)
+		sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("Error occured while checking vote rules. UserID: %d, Error : %v", userID, err), http.StatusInternalServerError)
+		return
+	}
+	if message != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+	voteType, err := data.GetStoryVoteByUser(userID, model.StoryID)
+	if err != nil {
+		sentry.CaptureMessage(fmt.Sprintf("Error occured while getting user's current vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", userID, model.StoryID, model.VoteType, err))
+		http.Error(w, fmt.Sprintf("Error occured while getting user's current vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", userID, model.StoryID, model.VoteType, err), http.StatusInternalServerError)
 		return
 	}
 	if voteType != nil {
@@ -381,18 +768,21 @@ func VoteStoryHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Remove story's previous vote given by user to make sure that there can be only one type of vote at a time
-		err = data.RemoveStoryVote(model.UserID, model.StoryID, *voteType)
+		err = data.RemoveStoryVote(userID, model.StoryID, *voteType)
 		if err != nil {
-			sentry.CaptureMessage(fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", model.UserID, model.StoryID, *voteType, err))
-			http.Error(w, fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", model.UserID, model.StoryID, *voteType, err), http.StatusInternalServerError)
+			sentry.CaptureMessage(fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", userID, model.StoryID, *voteType, err))
+			http.Error(w, fmt.Sprintf("Error occured while removing user's previous vote. UserID: %d, StoryID: %d, VoteType: %d,  Error : %v", userID, model.StoryID, *voteType, err), http.StatusInternalServerError)
 			return
 		}
 	}
-	err = data.VoteStory(model.UserID, model.StoryID, model.VoteType)
+	err = data.VoteStory(userID, model.StoryID, model.VoteType, shared.GetIPAddress(r))
 	if err != nil {
-		sentry.CaptureMessage(fmt.Sprintf("Error occured while voting story. UserID: %d, StoryID: %d, VoteType: %d, Error : %v", model.UserID, model.StoryID, model.VoteType, err))
-		http.Error(w, fmt.Sprintf("Error occured while voting story. UserID: %d, StoryID: %d, VoteType: %d, Error : %v", model.UserID, model.StoryID, model.VoteType, err), http.StatusInternalServerError)
+		sentry.CaptureMessage(fmt.Sprintf("Error occured while voting story. UserID: %d, StoryID: %d, VoteType: %d, Error : %v", userID, model.StoryID, model.VoteType, err))
+		http.Error(w, fmt.Sprintf("Error occured while voting story. UserID: %d, StoryID: %d, VoteType: %d, Error : %v", userID, model.StoryID, model.VoteType, err), http.StatusInternalServerError)
+		return
 	}
+	refreshStoryRank(customerCtx.ID, model.StoryID)
+	webhooks.Emit(customerCtx.ID, enums.VoteCast, webhooks.NewVotePayload("story", model.StoryID, userID, model.VoteType))
 	res, _ := json.Marshal(&JSONResponse{
 		Result: "Voted",
 	})
@@ -415,8 +805,9 @@ func RemoveStoryVoteHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	userID := shared.GetUserFromContext(r).ID
 
-	voteType, err := data.GetStoryVoteByUser(model.UserID, model.StoryID)
+	voteType, err := data.GetStoryVoteByUser(userID, model.StoryID)
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while checking user story vote. Error : %v", err), http.StatusInternalServerError)
@@ -431,12 +822,13 @@ func RemoveStoryVoteHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write(res)
 		return
 	}
-	err = data.RemoveStoryVote(model.UserID, model.StoryID, model.VoteType)
+	err = data.RemoveStoryVote(userID, model.StoryID, *voteType)
 	if err != nil {
 		sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("Error occured while unvoting story. Error : %v", err), http.StatusInternalServerError)
 		return
 	}
+	refreshStoryRank(shared.GetCustomerFromContext(r).ID, model.StoryID)
 	res, _ := json.Marshal(&JSONResponse{
 		Result: "Unvoted",
 	})
@@ -546,7 +938,7 @@ func getPage(r *http.Request) int {
 	return page
 }
 
-func mapCommentToCommentViewModel(comment *data.Comment, userClaims *shared.SignedInUserClaims) *models.CommentViewModel {
+func mapCommentToCommentViewModel(comment *data.Comment, userClaims *shared.SignedInUserClaims, voteType *enums.VoteType) *models.CommentViewModel {
 	model := &models.CommentViewModel{
 		ID:              comment.ID,
 		ParentID:        comment.ParentID,
@@ -560,13 +952,16 @@ func mapCommentToCommentViewModel(comment *data.Comment, userClaims *shared.Sign
 	if comment.ParentID == data.CommentRootID {
 		model.IsRoot = true
 	}
+	if comment.Hidden {
+		// hidden comments keep their place in the tree so that their replies are still listed
+		model.IsHidden = true
+		if userClaims == nil || userClaims.ID != comment.UserID {
+			model.Comment = ""
+		}
+	}
 	if userClaims != nil {
 		model.SignedInUser = mapUserClaimsToSignedUserViewModel(userClaims)
 		model.ShowDownvoteBtn = userClaims.Karma > MinKarmaToDownVote
-		voteType, err := data.GetCommentVoteByUser(userClaims.ID, comment.ID)
-		if err != nil {
-			sentry.CaptureException(err)
-		}
 		if voteType != nil {
 			if *voteType == enums.UpVote {
 				model.IsUpvoted = true
@@ -579,21 +974,6 @@ func mapCommentToCommentViewModel(comment *data.Comment, userClaims *shared.Sign
 	return model
 }
 
-func mapCommentsToViewModelsWithChildren(comments *[]data.Comment, userClaims *shared.SignedInUserClaims, storyID int) *[]models.CommentViewModel {
-	var viewModels []models.CommentViewModel
-	for _, comment := range *comments {
-		viewModel := *mapCommentToCommentViewModel(&comment, userClaims)
-		childComments, err := data.GetCommentsByParentIDAndStoryID(comment.ID, storyID)
-		if err != nil {
-			sentry.CaptureException(err)
-			continue
-		}
-		viewModel.ChildComments = *mapCommentsToViewModelsWithChildren(childComments, userClaims, storyID)
-		viewModels = append(viewModels, viewModel)
-	}
-	return &viewModels
-}
-
 func mapUserClaimsToSignedUserViewModel(signedInUserClaims *shared.SignedInUserClaims) *models.SignedInUserViewModel {
 	return &models.SignedInUserViewModel{
 		UserID:     signedInUserClaims.ID,
@@ -603,3 +983,11 @@ func mapUserClaimsToSignedUserViewModel(signedInUserClaims *shared.SignedInUserC
 		Karma:      signedInUserClaims.Karma,
 	}
 }
+
+func shortenText(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxLength])) + "..."
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*StoryKindsHandler handles managing the story kinds of the customer and their ranking parameters*/
+func StoryKindsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case "GET":
+		renderStoryKinds(w, r, newStoryKindsViewModel())
+	case "POST":
+		handleStoryKindsPOST(w, r)
+	default:
+		renderStoryKinds(w, r, newStoryKindsViewModel())
+	}
+}
+
+func newStoryKindsViewModel() *models.StoryKindsViewModel {
+	return &models.StoryKindsViewModel{
+		VoteExponent:   data.DefaultVoteExponent,
+		TimeExponent:   data.DefaultTimeExponent,
+		RankMultiplier: data.DefaultRankMultiplier,
+	}
+}
+
+func handleStoryKindsPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+
+	if r.FormValue("action") == "delete" {
+		name := r.FormValue("name")
+		err = data.DeleteStoryKind(user.CustomerID, name)
+		if err != nil {
+			panic(err)
+		}
+		refreshRecentRanks(user.CustomerID)
+		model := newStoryKindsViewModel()
+		model.SuccessMessage = "Story kind is deleted."
+		if data.IsBuiltInStoryKind(name) {
+			model.SuccessMessage = "Ranking parameters are reset."
+		}
+		renderStoryKinds(w, r, model)
+		return
+	}
+
+	model := &models.StoryKindsViewModel{
+		Name:           r.FormValue("name"),
+		TitlePrefix:    r.FormValue("titleprefix"),
+		VoteExponent:   parseRankingParameter(r.FormValue("voteexponent")),
+		TimeExponent:   parseRankingParameter(r.FormValue("timeexponent")),
+		RankMultiplier: parseRankingParameter(r.FormValue("rankmultiplier")),
+	}
+	if model.Validate() == false {
+		renderStoryKinds(w, r, model)
+		return
+	}
+	if data.IsBuiltInStoryKind(model.Name) {
+		// Title prefixes of built-in kinds are fixed
+		model.TitlePrefix = ""
+	}
+	err = data.SaveStoryKind(&data.StoryKind{
+		CustomerID:     user.CustomerID,
+		Name:           model.Name,
+		TitlePrefix:    model.TitlePrefix,
+		VoteExponent:   model.VoteExponent,
+		TimeExponent:   model.TimeExponent,
+		RankMultiplier: model.RankMultiplier,
+		CreatedOn:      time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	refreshRecentRanks(user.CustomerID)
+	model = newStoryKindsViewModel()
+	model.SuccessMessage = "Story kind is saved."
+	renderStoryKinds(w, r, model)
+}
+
+// parseRankingParameter returns 0 for invalid values so that validation fails
+func parseRankingParameter(value string) float64 {
+	parameter, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0
+	}
+	return parameter
+}
+
+func renderStoryKinds(w http.ResponseWriter, r *http.Request, model *models.StoryKindsViewModel) {
+	model.Kinds = *getCustomerStoryKinds(r)
+	err := templates.RenderInLayout(w, r, "kinds.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
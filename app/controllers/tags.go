@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*TagsHandler handles managing the tag vocabulary of the customer*/
+func TagsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case "GET":
+		renderTags(w, r, &models.TagsViewModel{})
+	case "POST":
+		handleTagsPOST(w, r)
+	default:
+		renderTags(w, r, &models.TagsViewModel{})
+	}
+}
+
+func handleTagsPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+
+	if r.FormValue("action") == "delete" {
+		tagID, err := strconv.Atoi(r.FormValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid tag id", http.StatusBadRequest)
+			return
+		}
+		err = data.DeleteTag(user.CustomerID, tagID)
+		if err != nil {
+			panic(err)
+		}
+		renderTags(w, r, &models.TagsViewModel{SuccessMessage: "Tag is deleted."})
+		return
+	}
+
+	model := &models.TagsViewModel{
+		Name:        r.FormValue("name"),
+		Description: r.FormValue("description"),
+	}
+	if model.Validate() == false {
+		renderTags(w, r, model)
+		return
+	}
+	err = data.CreateTag(&data.Tag{
+		CustomerID:  user.CustomerID,
+		Name:        model.Name,
+		Description: model.Description,
+		CreatedOn:   time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	renderTags(w, r, &models.TagsViewModel{SuccessMessage: "Tag is saved."})
+}
+
+func renderTags(w http.ResponseWriter, r *http.Request, model *models.TagsViewModel) {
+	model.Tags = *getCustomerTags(r)
+	err := templates.RenderInLayout(w, r, "tags.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*HiddenTagsHandler handles the tags which signed in user does not want to see on front page*/
+func HiddenTagsHandler(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	model := &models.HiddenTagsViewModel{
+		Tags: *getCustomerTags(r),
+	}
+	if r.Method == "POST" {
+		err := r.ParseForm()
+		if err != nil {
+			panic(err)
+		}
+		err = data.SaveHiddenTags(user.ID, filterKnownTags(r.Form["tags"], model.Tags))
+		if err != nil {
+			panic(err)
+		}
+		model.SuccessMessage = "Hidden tags are saved."
+	}
+	hiddenTags, err := data.GetHiddenTags(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	model.HiddenTags = hiddenTags
+	err = templates.RenderInLayout(w, r, "hidden-tags.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func filterKnownTags(tags []string, vocabulary []data.Tag) []string {
+	known := []string{}
+	for _, tag := range tags {
+		for _, t := range vocabulary {
+			if t.Name == tag {
+				known = append(known, tag)
+				break
+			}
+		}
+	}
+	return known
+}
@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"linkwind/app/data"
+	"linkwind/app/enums"
 	"linkwind/app/models"
 	"linkwind/app/shared"
 	"linkwind/app/templates"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -124,4 +126,79 @@ func setUserToModel(user *data.User, model *models.UserProfileViewModel, isAdmin
 	model.About = user.About
 	model.Email = user.Email
 	model.IsAdmin = isAdmin
+	inviterName, viaLink, err := data.GetInviter(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	model.InvitedBy = inviterName
+	model.InvitedViaLink = viaLink
+}
+
+/*DigestSettingsHandler handles showing and updating user's digest email settings*/
+func DigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handleDigestSettingsGET(w, r)
+	case "POST":
+		handleDigestSettingsPOST(w, r)
+	default:
+		handleDigestSettingsGET(w, r)
+	}
+}
+
+func handleDigestSettingsGET(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	setting, err := data.GetDigestSettingByUserID(user.ID)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.DigestSettingsViewModel{
+		Frequency: setting.Frequency,
+	}
+	err = templates.RenderInLayout(w, r, "digest-settings.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func handleDigestSettingsPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	frequency, _ := strconv.Atoi(r.FormValue("frequency"))
+	model := &models.DigestSettingsViewModel{
+		Frequency: enums.DigestFrequency(frequency),
+	}
+	if model.Frequency != enums.DigestDaily && model.Frequency != enums.DigestWeekly {
+		model.Frequency = enums.DigestNever
+	}
+	err := data.SaveDigestSetting(user.ID, model.Frequency)
+	if err != nil {
+		panic(err)
+	}
+	model.SuccessMessage = "Digest settings updated successfuly!"
+	err = templates.RenderInLayout(w, r, "digest-settings.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*DigestUnsubscribeHandler handles unsubscribing from digest emails by the signed link in the mail*/
+func DigestUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if strings.TrimSpace(token) == "" {
+		http.Error(w, "Missing Token! ", http.StatusBadRequest)
+		return
+	}
+	userID, err := shared.ParseUnsubscribeToken(token)
+	if err != nil {
+		http.Error(w, "Token is not valid! ", http.StatusBadRequest)
+		return
+	}
+	err = data.SaveDigestSetting(userID, enums.DigestNever)
+	if err != nil {
+		panic(err)
+	}
+	err = templates.RenderFile(w, "layouts/users/digest-unsubscribed.html", nil)
+	if err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"linkwind/app/voting"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const voteFlagPageSize = 100
+
+/*VoteIntegrityHandler handles the vote rules of the customer and the report of suspicious votes which vote analysis flagged*/
+func VoteIntegrityHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	if r.Method != "POST" {
+		model := newVoteIntegrityViewModel(user.CustomerID)
+		model.Status = r.URL.Query().Get("status")
+		renderVoteIntegrity(w, r, model)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+	switch r.FormValue("action") {
+	case "nullify", "dismiss":
+		handleVoteFlagPOST(w, r, user.CustomerID)
+	case "analyze":
+		count, err := voting.AnalyzeVotes(user.CustomerID, time.Now().Add(-voting.AnalysisWindow))
+		if err != nil {
+			panic(err)
+		}
+		model := newVoteIntegrityViewModel(user.CustomerID)
+		model.SuccessMessage = fmt.Sprintf("Vote analysis is completed. %d suspicious patterns are detected.", count)
+		renderVoteIntegrity(w, r, model)
+	default:
+		handleVoteRulesPOST(w, r, user.CustomerID)
+	}
+}
+
+func newVoteIntegrityViewModel(customerID int) *models.VoteIntegrityViewModel {
+	rules, err := data.GetVoteRules(customerID)
+	if err != nil {
+		panic(err)
+	}
+	return &models.VoteIntegrityViewModel{
+		MinAccountAgeDays: rules.MinAccountAgeDays,
+		MinKarma:          rules.MinKarma,
+	}
+}
+
+func handleVoteRulesPOST(w http.ResponseWriter, r *http.Request, customerID int) {
+	model := &models.VoteIntegrityViewModel{
+		MinAccountAgeDays: parseFormCount(r.FormValue("minaccountagedays")),
+		MinKarma:          parseFormCount(r.FormValue("minkarma")),
+	}
+	if model.Validate() == false {
+		renderVoteIntegrity(w, r, model)
+		return
+	}
+	err := data.SaveVoteRules(&data.VoteRules{
+		CustomerID:        customerID,
+		MinAccountAgeDays: model.MinAccountAgeDays,
+		MinKarma:          model.MinKarma,
+		UpdatedOn:         time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	model.SuccessMessage = "Vote rules are saved."
+	renderVoteIntegrity(w, r, model)
+}
+
+func handleVoteFlagPOST(w http.ResponseWriter, r *http.Request, customerID int) {
+	flagID, err := strconv.Atoi(r.FormValue("flagid"))
+	if err != nil {
+		http.Error(w, "Invalid flag id.", http.StatusBadRequest)
+		return
+	}
+	flag, err := data.GetVoteFlagByID(customerID, flagID)
+	if err != nil {
+		panic(err)
+	}
+	if flag == nil {
+		renderNotFound(w)
+		return
+	}
+	model := newVoteIntegrityViewModel(customerID)
+	if r.FormValue("action") == "nullify" {
+		err = voting.NullifyVoteFlag(customerID, flagID)
+		model.SuccessMessage = fmt.Sprintf("Votes of %d users are nullified.", len(flag.UserIDs))
+	} else {
+		err = data.ResolveVoteFlag(flagID, data.VoteFlagDismissed)
+		model.SuccessMessage = "Flag is dismissed."
+	}
+	if err != nil {
+		panic(err)
+	}
+	renderVoteIntegrity(w, r, model)
+}
+
+func renderVoteIntegrity(w http.ResponseWriter, r *http.Request, model *models.VoteIntegrityViewModel) {
+	user := shared.GetUserFromContext(r)
+	model.Statuses = []string{data.VoteFlagOpen, data.VoteFlagNullified, data.VoteFlagDismissed}
+	if model.Status != data.VoteFlagNullified && model.Status != data.VoteFlagDismissed {
+		model.Status = data.VoteFlagOpen
+	}
+	flags, err := data.GetVoteFlags(user.CustomerID, model.Status, voteFlagPageSize)
+	if err != nil {
+		panic(err)
+	}
+	model.Flags = *flags
+	err = templates.RenderInLayout(w, r, "votes.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// checkVoteRules returns the message which explains why the user cannot vote. Returns empty string if the user meets the vote rules of the customer.
+func checkVoteRules(customerID, userID int) (string, error) {
+	message, err := userBanMessage(userID)
+	if err != nil || message != "" {
+		return message, err
+	}
+	rules, err := data.GetVoteRules(customerID)
+	if err != nil {
+		return "", err
+	}
+	if rules.MinAccountAgeDays == 0 && rules.MinKarma == 0 {
+		return "", nil
+	}
+	user, err := data.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if time.Since(user.RegisteredOn) < time.Duration(rules.MinAccountAgeDays)*24*time.Hour {
+		return fmt.Sprintf("Your account must be at least %d days old to vote.", rules.MinAccountAgeDays), nil
+	}
+	if user.Karma < rules.MinKarma {
+		return fmt.Sprintf("You need at least %d karma to vote.", rules.MinKarma), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/models"
+	"linkwind/app/shared"
+	"linkwind/app/templates"
+	"linkwind/app/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const webhookDeliveryPageSize = 50
+
+/*WebhooksHandler handles listing, creating and deleting customer's webhooks*/
+func WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case "GET":
+		handleWebhooksGET(w, r)
+	case "POST":
+		handleWebhooksPOST(w, r)
+	default:
+		handleWebhooksGET(w, r)
+	}
+}
+
+func handleWebhooksGET(w http.ResponseWriter, r *http.Request) {
+	model := &models.WebhooksViewModel{}
+	if r.URL.Query().Get("deleted") == "true" {
+		model.SuccessMessage = "Webhook is deleted."
+	}
+	renderWebhooks(w, r, model)
+}
+
+func handleWebhooksPOST(w http.ResponseWriter, r *http.Request) {
+	user := shared.GetUserFromContext(r)
+	err := r.ParseForm()
+	if err != nil {
+		panic(err)
+	}
+
+	if r.FormValue("action") == "delete" {
+		webhookID, err := strconv.Atoi(r.FormValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid webhook id", http.StatusBadRequest)
+			return
+		}
+		err = data.DeleteWebhook(user.CustomerID, webhookID)
+		if err != nil {
+			panic(err)
+		}
+		http.Redirect(w, r, "/admin/webhooks?deleted=true", http.StatusSeeOther)
+		return
+	}
+
+	model := &models.WebhooksViewModel{
+		URL:            strings.TrimSpace(r.FormValue("url")),
+		SelectedEvents: r.Form["events"],
+	}
+	if model.Validate() == false {
+		renderWebhooks(w, r, model)
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		panic(err)
+	}
+	err = data.CreateWebhook(&data.Webhook{
+		CustomerID: user.CustomerID,
+		URL:        model.URL,
+		Secret:     secret,
+		Events:     model.SelectedEvents,
+		Active:     true,
+		CreatedOn:  time.Now(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	renderWebhooks(w, r, &models.WebhooksViewModel{
+		SuccessMessage: "Webhook is created.",
+	})
+}
+
+func renderWebhooks(w http.ResponseWriter, r *http.Request, model *models.WebhooksViewModel) {
+	user := shared.GetUserFromContext(r)
+	hooks, err := data.GetWebhooksByCustomerID(user.CustomerID)
+	if err != nil {
+		panic(err)
+	}
+	model.Webhooks = *hooks
+	model.Events = enums.WebhookEvents
+	err = templates.RenderInLayout(w, r, "webhooks.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*WebhookDeliveriesHandler handles showing the recent deliveries of a webhook*/
+func WebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	webhookID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		renderNotFound(w)
+		return
+	}
+	webhook, err := data.GetWebhookByID(user.CustomerID, webhookID)
+	if err != nil {
+		panic(err)
+	}
+	if webhook == nil {
+		renderNotFound(w)
+		return
+	}
+	deliveries, err := data.GetWebhookDeliveries(webhook.ID, webhookDeliveryPageSize)
+	if err != nil {
+		panic(err)
+	}
+	model := &models.WebhookDeliveriesViewModel{
+		Webhook:    webhook,
+		Deliveries: *deliveries,
+	}
+	if r.URL.Query().Get("redelivered") == "true" {
+		model.SuccessMessage = "Delivery is queued. Refresh the page to see the result."
+	}
+	err = templates.RenderInLayout(w, r, "webhook-deliveries.html", model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+/*WebhookRedeliverHandler handles sending the payload of a previous delivery again*/
+func WebhookRedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	if !ensureAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := shared.GetUserFromContext(r)
+	deliveryID, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid delivery id", http.StatusBadRequest)
+		return
+	}
+	delivery, err := data.GetWebhookDeliveryByID(user.CustomerID, deliveryID)
+	if err != nil {
+		panic(err)
+	}
+	if delivery == nil {
+		renderNotFound(w)
+		return
+	}
+	webhook, err := data.GetWebhookByID(user.CustomerID, delivery.WebhookID)
+	if err != nil {
+		panic(err)
+	}
+	if webhook == nil {
+		renderNotFound(w)
+		return
+	}
+	webhooks.Redeliver(webhook, delivery)
+	http.Redirect(w, r, fmt.Sprintf("/admin/webhooks/deliveries?id=%d&redelivered=true", webhook.ID), http.StatusSeeOther)
+}
+
+func renderNotFound(w http.ResponseWriter) {
+	err := templates.RenderFile(w, "errors/404.html", nil)
+	if err != nil {
+		panic(err)
+	}
+}
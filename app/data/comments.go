@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"linkwind/app/enums"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const (
@@ -24,8 +26,20 @@ type Comment struct {
 	ReplyCount  int
 	Comment     string
 	CommentedOn time.Time
+	Hidden      bool
+	// AuthorShadowbanned is true if the comment is visible only to its author since the author is shadowbanned
+	AuthorShadowbanned bool
 }
 
+// commentColumns represents the comment columns in the order which comment mappers read them
+const commentColumns = "comments.comment, comments.upvotes, comments.storyid, comments.parentid, comments.replycount, comments.userid, comments.commentedon, comments.id, comments.downvotes, comments.hidden, " + commentAuthorShadowbannedSQL
+
+// commentAuthorShadowbannedSQL checks whether the author of the comment is shadowbanned
+const commentAuthorShadowbannedSQL = "EXISTS (SELECT 1 FROM bannedusers WHERE bannedusers.userid = comments.userid AND bannedusers.kind = 'shadowban')"
+
+// visibleCommentsSQL filters the comments which are listed to everyone. Hidden comments and the comments of shadowbanned users are left out.
+const visibleCommentsSQL = "NOT comments.hidden AND NOT " + commentAuthorShadowbannedSQL
+
 /*CommentError contains the error and comment data which caused to error*/
 type CommentError struct {
 	Message       string
@@ -71,7 +85,7 @@ func WriteComment(comment *Comment) (*int, error) {
 	if err != nil {
 		return &commentID, &CommentError{"Can not start the transaction.", comment, err}
 	}
-	sql := "INSERT INTO comments (storyid, userid, parentid, upvotes, downvotes, replycount, comment, commentedon) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id"
+	sql := "INSERT INTO comments (storyid, userid, parentid, upvotes, downvotes, replycount, comment, commentedon, hidden) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id"
 	err = tran.QueryRow(
 		sql,
 		comment.StoryID,
@@ -81,7 +95,8 @@ func WriteComment(comment *Comment) (*int, error) {
 		comment.DownVotes,
 		comment.ReplyCount,
 		comment.Comment,
-		comment.CommentedOn).Scan(&commentID)
+		comment.CommentedOn,
+		comment.Hidden).Scan(&commentID)
 	if err != nil {
 		tran.Rollback()
 		return &commentID, &CommentError{"Cannot insert comment to the db.", comment, err}
@@ -107,7 +122,7 @@ func WriteComment(comment *Comment) (*int, error) {
 	return &commentID, nil
 }
 
-/*GetComments retunrs comment list by provided story id*/
+/*GetComments retunrs all comments of the story in one query. Comments are ordered by their date so that the comment tree can be assembled in memory.*/
 func GetComments(storyID int) (comments *[]Comment, err error) {
 	db, err := connectToDB()
 
@@ -116,7 +131,7 @@ func GetComments(storyID int) (comments *[]Comment, err error) {
 	}
 	defer db.Close()
 
-	sql := "SELECT comments.*, users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE storyid = $1"
+	sql := "SELECT " + commentColumns + ", users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE storyid = $1 ORDER BY comments.commentedon ASC, comments.id ASC"
 	rows, err := db.Query(sql, storyID)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot query comments. StoryID: %d.", storyID), err}
@@ -128,46 +143,49 @@ func GetComments(storyID int) (comments *[]Comment, err error) {
 	return comments, nil
 }
 
-/*GetRootCommentsByStoryID retunrs only root comments by provided story id*/
-func GetRootCommentsByStoryID(storyID int) (comments *[]Comment, err error) {
+/*GetCommentByID returns the comment with the user name of its author. Returns nil if there is no such comment.*/
+func GetCommentByID(commentID int) (*Comment, error) {
 	db, err := connectToDB()
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("DB connection error. StoryID: %d.", storyID), err}
+		return nil, &DBError{fmt.Sprintf("DB connection error. CommentID: %d.", commentID), err}
 	}
 	defer db.Close()
-	sql := "SELECT comments.*, users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE storyid = $1 AND parentid is null ORDER BY commentedon ASC"
-	rows, err := db.Query(sql, storyID)
+	sql := "SELECT " + commentColumns + ", users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE comments.id = $1"
+	rows, err := db.Query(sql, commentID)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot query comments. StoryID: %d.", storyID), err}
+		return nil, &DBError{fmt.Sprintf("Cannot query comment. CommentID: %d.", commentID), err}
 	}
-	comments, err = MapSQLRowsToComments(rows)
+	comments, err := MapSQLRowsToComments(rows)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot read comment row. StoryID: %d.", storyID), err}
+		return nil, &DBError{fmt.Sprintf("Cannot read comment row. CommentID: %d.", commentID), err}
 	}
-	return comments, nil
+	if len(*comments) == 0 {
+		return nil, nil
+	}
+	return &(*comments)[0], nil
 }
 
-/*GetCommentsByParentIDAndStoryID retunrs only root comments by provided story id*/
-func GetCommentsByParentIDAndStoryID(parentID int, storyID int) (comments *[]Comment, err error) {
+/*GetRecentCommentsByStoryID returns the latest comments of the story*/
+func GetRecentCommentsByStoryID(storyID, count int) (comments *[]Comment, err error) {
 	db, err := connectToDB()
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("DB connection error. ParentID: %d, StoryID: %d.", parentID, storyID), err}
+		return nil, &DBError{fmt.Sprintf("DB connection error. StoryID: %d.", storyID), err}
 	}
 	defer db.Close()
-	sql := "SELECT comments.*, users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE storyid = $1 AND parentid = $2 ORDER BY commentedon ASC"
-	rows, err := db.Query(sql, storyID, parentID)
+	sql := "SELECT " + commentColumns + ", users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE storyid = $1 AND " + visibleCommentsSQL + " ORDER BY commentedon DESC LIMIT $2"
+	rows, err := db.Query(sql, storyID, count)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot query comments by parent id and story id. ParentID: %d and StoryID: %d.", parentID, storyID), err}
+		return nil, &DBError{fmt.Sprintf("Cannot query recent comments. StoryID: %d.", storyID), err}
 	}
 	comments, err = MapSQLRowsToComments(rows)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot read comment row. ParentID: %d, StoryID: %d.", parentID, storyID), err}
+		return nil, &DBError{fmt.Sprintf("Cannot read comment row. StoryID: %d.", storyID), err}
 	}
 	return comments, nil
 }
 
-/*VoteComment votes (upvote, downvote) for comment on database*/
-func VoteComment(userID int, commentID int, voteType enums.VoteType) error {
+/*VoteComment votes (upvote, downvote) for comment on database. IP address of the voter is kept for vote analysis.*/
+func VoteComment(userID int, commentID int, voteType enums.VoteType, ipAddress string) error {
 	updateQuery := "UPDATE comments SET upvotes = upvotes + 1 WHERE id = $1"
 	if voteType == enums.DownVote {
 		updateQuery = "UPDATE comments SET downvotes = downvotes + 1 WHERE id = $1"
@@ -181,8 +199,8 @@ func VoteComment(userID int, commentID int, voteType enums.VoteType) error {
 	if err != nil {
 		return &DBError{fmt.Sprintf("Cannot begin transaction. UserID: %d, CommentID: %d", userID, commentID), err}
 	}
-	sql := "INSERT INTO commentvotes (userid, commentid, votetype) VALUES ($1, $2, $3)"
-	_, err = tran.Exec(sql, userID, commentID, voteType)
+	sql := "INSERT INTO commentvotes (userid, commentid, votetype, votedon, ipaddress) VALUES ($1, $2, $3, $4, $5)"
+	_, err = tran.Exec(sql, userID, commentID, voteType, time.Now(), nullString(ipAddress))
 	if err != nil {
 		tran.Rollback()
 		return &DBError{fmt.Sprintf("Cannot insert commentvotes. UserID: %d, CommentID: %d", userID, commentID), err}
@@ -193,8 +211,7 @@ func VoteComment(userID int, commentID int, voteType enums.VoteType) error {
 		return &DBError{fmt.Sprintf("Cannot update comment's upvotes. UserID: %d, CommentID: %d", userID, commentID), err}
 	}
 	if voteType == enums.UpVote {
-		sql = "UPDATE users SET karma = karma + 1 WHERE id = (SELECT userid FROM comments WHERE id = $1)"
-		_, err = tran.Exec(sql, commentID)
+		err = recordVoteKarma(tran, enums.CommentVoteKarma, commentID, userID, 1)
 		if err != nil {
 			tran.Rollback()
 			return &DBError{fmt.Sprintf("Error occurred while increasing user's karma. UserID: %d, CommentID: %d", userID, commentID), err}
@@ -234,8 +251,7 @@ func RemoveCommentVote(userID int, commentID int, voteType enums.VoteType) error
 		return &DBError{fmt.Sprintf("Cannot update comment's upvotes. UserID: %d, CommentID: %d", userID, commentID), err}
 	}
 	if voteType == enums.UpVote {
-		sql = "UPDATE users SET karma = karma - 1 WHERE id = (SELECT userid FROM comments WHERE id = $1)"
-		_, err = tran.Exec(sql, commentID)
+		err = recordVoteKarma(tran, enums.CommentVoteKarma, commentID, userID, -1)
 		if err != nil {
 			tran.Rollback()
 			return &DBError{fmt.Sprintf("Error occurred while decreasing user's karma. UserID: %d, CommentID: %d", userID, commentID), err}
@@ -268,6 +284,36 @@ func GetCommentVoteByUser(userID int, commentID int) (*enums.VoteType, error) {
 	return voteType, nil
 }
 
+/*GetCommentVotesByUser returns the votes of the user to given comments in one query. Comments which are not voted by the user are not in the map.*/
+func GetCommentVotesByUser(userID int, commentIDs []int) (map[int]enums.VoteType, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT commentid, votetype FROM commentvotes WHERE userid = $1 AND commentid = ANY($2::integer[])"
+	ids := make([]int64, len(commentIDs))
+	for i, id := range commentIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := db.Query(query, userID, pq.Array(ids))
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query comment votes. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	votes := make(map[int]enums.VoteType)
+	for rows.Next() {
+		var commentID int
+		var voteType enums.VoteType
+		err = rows.Scan(&commentID, &voteType)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read comment vote row. UserID: %d", userID), err}
+		}
+		votes[commentID] = voteType
+	}
+	return votes, nil
+}
+
 /*GetUserReplies returns reply list by provided user id and paging parameters*/
 func GetUserReplies(userID int) (replies *[]Reply, err error) {
 	db, err := connectToDB()
@@ -275,7 +321,7 @@ func GetUserReplies(userID int) (replies *[]Reply, err error) {
 		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d.", userID), err}
 	}
 	defer db.Close()
-	sql := "SELECT comments.*, stories.title, stories.id, users.username FROM comments INNER JOIN stories ON comments.storyid = stories.id INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1 ORDER BY comments.commentedon DESC"
+	sql := "SELECT " + commentColumns + ", stories.title, stories.id, users.username FROM comments INNER JOIN stories ON comments.storyid = stories.id INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1 AND " + visibleCommentsSQL + " ORDER BY comments.commentedon DESC"
 	rows, err := db.Query(sql, userID)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot query replies. UserID: %d.", userID), err}
@@ -295,7 +341,7 @@ func GetUserCommentsNotPaging(userID int) (comments *[]Comment, err error) {
 	}
 	defer db.Close()
 
-	sql := "SELECT * FROM public.comments WHERE userid = $1"
+	sql := "SELECT " + commentColumns + ", users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE comments.userid = $1"
 	rows, err := db.Query(sql, userID)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot query comments. UserID: %d.", userID), err}
@@ -306,3 +352,22 @@ func GetUserCommentsNotPaging(userID int) (comments *[]Comment, err error) {
 	}
 	return comments, nil
 }
+
+/*GetRecentCommentsByUserID returns the latest comments of the user including the hidden ones*/
+func GetRecentCommentsByUserID(userID, count int) (comments *[]Comment, err error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d.", userID), err}
+	}
+	defer db.Close()
+	sql := "SELECT " + commentColumns + ", users.username FROM comments INNER JOIN users ON users.id = comments.userid WHERE comments.userid = $1 ORDER BY commentedon DESC LIMIT $2"
+	rows, err := db.Query(sql, userID, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query recent comments. UserID: %d.", userID), err}
+	}
+	comments, err = MapSQLRowsToComments(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read comment row. UserID: %d.", userID), err}
+	}
+	return comments, nil
+}
@@ -0,0 +1,41 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+)
+
+/*GetCommentSortByUserID gets the comment order which the user selected last. If user has not selected it yet, returns best order.*/
+func GetCommentSortByUserID(userID int) (enums.CommentSort, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return enums.BestComments, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT sort FROM commentsettings WHERE userid = $1"
+	var sort enums.CommentSort
+	err = db.QueryRow(query, userID).Scan(&sort)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return enums.BestComments, nil
+		}
+		return enums.BestComments, &DBError{fmt.Sprintf("Cannot read comment setting. UserID: %d", userID), err}
+	}
+	return sort, nil
+}
+
+/*SaveCommentSort inserts or updates the comment order of the user*/
+func SaveCommentSort(userID int, sort enums.CommentSort) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO commentsettings (userid, sort) VALUES ($1, $2) ON CONFLICT (userid) DO UPDATE SET sort = EXCLUDED.sort"
+	_, err = db.Exec(query, userID, sort)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save comment setting. UserID: %d, Sort: %s", userID, sort), err}
+	}
+	return nil
+}
@@ -204,3 +204,22 @@ func nullCustomerValue(value string) sql.NullString {
 		Valid:  true,
 	}
 }
+
+/*GetCustomers gets all customers from database*/
+func GetCustomers() (*[]Customer, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	query := "SELECT id, name, email, registeredon, domain, imglogo, title FROM customers ORDER BY id"
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, &DBError{"Cannot get customers.", err}
+	}
+	customers, err := MapSQLRowsToCustomers(rows)
+	if err != nil {
+		return nil, &DBError{"Cannot read customer rows.", err}
+	}
+	return customers, nil
+}
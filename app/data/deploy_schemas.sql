@@ -65,6 +65,7 @@ TABLESPACE pg_default;
     registeredon timestamp
         with time zone NOT NULL,
     customerid integer,
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
     CONSTRAINT users_pkey PRIMARY KEY
         (id),
     CONSTRAINT unique_email UNIQUE
@@ -147,6 +148,15 @@ TABLESPACE
             with time zone NOT NULL,
     tags text[] COLLATE pg_catalog."default",
     downvotes integer NOT NULL DEFAULT 0,
+    metadescription text COLLATE pg_catalog."default",
+    metaimageurl character varying(500) COLLATE pg_catalog."default",
+    metasitename character varying(250) COLLATE pg_catalog."default",
+    metacanonicalurl character varying(500) COLLATE pg_catalog."default",
+    metapublishedon timestamp with time zone,
+    canonicalurl character varying(500) COLLATE pg_catalog."default",
+    kind character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'link'::character varying,
+    rank double precision NOT NULL DEFAULT 0,
+    hidden boolean NOT NULL DEFAULT false,
     CONSTRAINT stories_pkey PRIMARY KEY
             (id),
     CONSTRAINT fk_userid FOREIGN KEY
@@ -173,6 +183,14 @@ TABLESPACE
             CREATE INDEX ix_submittedon
     ON public.stories USING btree
             (submittedon DESC NULLS LAST)
+    TABLESPACE pg_default;
+            -- Index: ix_rank
+
+            -- DROP INDEX public.ix_rank;
+
+            CREATE INDEX ix_rank
+    ON public.stories USING btree
+            (rank DESC NULLS LAST)
     TABLESPACE pg_default;
             -- Index: ix_tags
 
@@ -182,6 +200,14 @@ TABLESPACE
     ON public.stories USING btree
             (tags COLLATE pg_catalog."default" ASC NULLS LAST)
     TABLESPACE pg_default;
+            -- Index: ix_canonicalurl
+
+            -- DROP INDEX public.ix_canonicalurl;
+
+            CREATE INDEX ix_canonicalurl
+    ON public.stories USING btree
+            (canonicalurl COLLATE pg_catalog."default" ASC NULLS LAST, submittedon DESC NULLS LAST)
+    TABLESPACE pg_default;
 
 
 
@@ -194,6 +220,8 @@ TABLESPACE
                 storyid integer NOT NULL,
                 userid integer NOT NULL,
                 votetype integer NOT NULL,
+                votedon timestamp with time zone NOT NULL DEFAULT now(),
+                ipaddress character varying(45) COLLATE pg_catalog."default",
                 CONSTRAINT storyvotes_pk PRIMARY KEY (storyid, userid, votetype),
                 CONSTRAINT storyid_fk FOREIGN KEY (storyid)
         REFERENCES public.stories (id)
@@ -286,6 +314,7 @@ TABLESPACE pg_default;
                         with time zone NOT NULL,
     id serial,
     downvotes integer NOT NULL,
+    hidden boolean NOT NULL DEFAULT false,
     CONSTRAINT comments_pkey PRIMARY KEY
                         (id),
     CONSTRAINT "parentId_fk" FOREIGN KEY
@@ -339,6 +368,8 @@ TABLESPACE pg_default;
                             commentid integer NOT NULL,
                             userid integer NOT NULL,
                             votetype integer NOT NULL,
+                            votedon timestamp with time zone NOT NULL DEFAULT now(),
+                            ipaddress character varying(45) COLLATE pg_catalog."default",
                             CONSTRAINT commentvotes_pk PRIMARY KEY (commentid, userid, votetype),
                             CONSTRAINT commentid_fk FOREIGN KEY (commentid)
         REFERENCES public.comments (id)
@@ -383,6 +414,11 @@ TABLESPACE
     invitedemail character varying
                                 (50) COLLATE pg_catalog."default" NOT NULL,
     used boolean NOT NULL DEFAULT false,
+    expireson timestamp with time zone,
+    revoked boolean NOT NULL DEFAULT false,
+    lastsenton timestamp with time zone,
+    fullname character varying(50) COLLATE pg_catalog."default",
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
     CONSTRAINT invitecodes_pkey PRIMARY KEY
                                 (code),
     CONSTRAINT userid_fk FOREIGN KEY
@@ -436,98 +472,1113 @@ TABLESPACE
 
 
 
+-- Table: public.digestsettings
+
+-- DROP TABLE public.digestsettings;
+
+CREATE TABLE public.digestsettings
+(
+    userid integer NOT NULL,
+    frequency integer NOT NULL DEFAULT 0,
+    lastsenton timestamp with time zone,
+    CONSTRAINT digestsettings_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.digestsettings
+    OWNER to postgres;
+
+-- Index: ix_digestsettings_frequency
 
-                                    CREATE OR REPLACE FUNCTION public.calculatestorypenalty
-                                    (
-	commentcount integer)
-    RETURNS integer
-    LANGUAGE 'plpgsql'
+-- DROP INDEX public.ix_digestsettings_frequency;
 
-    COST 100
-    IMMUTABLE 
-AS $BODY$declare
-    penalty integer := 40;
-    i integer := -1;
-                                    begin 
-    loop
-        exit when penalty = i;
-				i := i+1;
-                                    if (commentCount = i) then
-                                    return penalty-i;
-                                    end
-                                    if;
-    end loop;
-                                    return 1;
+CREATE INDEX ix_digestsettings_frequency
+    ON public.digestsettings USING btree
+    (frequency)
+    TABLESPACE pg_default;
 
-                                    end ;
 
-$BODY$;
 
-                                    ALTER FUNCTION public.calculatestorypenalty(integer)
-    OWNER TO postgres;
 
+-- Table: public.webhooks
 
+-- DROP TABLE public.webhooks;
 
+CREATE TABLE public.webhooks
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    url character varying(500) COLLATE pg_catalog."default" NOT NULL,
+    secret character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    events text[] COLLATE pg_catalog."default" NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT webhooks_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
 
+TABLESPACE pg_default;
 
+ALTER TABLE public.webhooks
+    OWNER to postgres;
 
-                                    CREATE OR REPLACE FUNCTION public.calculatestoryrank
-                                    (
-	stories)
-    RETURNS double precision
-    LANGUAGE 'plpgsql'
+-- Index: ix_webhooks_customerid
 
-    COST 100
-    IMMUTABLE 
-AS $BODY$declare
+-- DROP INDEX public.ix_webhooks_customerid;
 
-    timeNow timestamp;
+CREATE INDEX ix_webhooks_customerid
+    ON public.webhooks USING btree
+    (customerid)
+    TABLESPACE pg_default;
 
-    votes integer;
+-- Table: public.webhookdeliveries
 
-    up integer;
+-- DROP TABLE public.webhookdeliveries;
 
-    down float;
+CREATE TABLE public.webhookdeliveries
+(
+    id serial NOT NULL,
+    webhookid integer NOT NULL,
+    event character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    payload text COLLATE pg_catalog."default" NOT NULL,
+    responsecode integer,
+    error text COLLATE pg_catalog."default",
+    attempts integer NOT NULL DEFAULT 0,
+    createdon timestamp with time zone NOT NULL,
+    deliveredon timestamp with time zone,
+    CONSTRAINT webhookdeliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT webhookid_fk FOREIGN KEY (webhookid)
+        REFERENCES public.webhooks (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
 
-    timeDiff integer;
+TABLESPACE pg_default;
 
-                                    begin 
-    votes := $1.upvotes- $1.downvotes;
-                                    if (votes <= 0) then
-        votes := 1;
-                                    end
-                                    if;
-    up :=  POWER
-                                    ((votes),0.8);
-    timeNow := NOW
-                                    ();
-    timeDiff :=  EXTRACT
-                                    (EPOCH 
-                         FROM
-                                    (timeNow::timestamp - 
-                               $1.submittedon::timestamp));
-    down := POWER
-                                    (timeDiff+1,0.1);
-                                    return (up/down)*calculatestorypenalty($1.
-                                    commentcount);
-                                    end ;
+ALTER TABLE public.webhookdeliveries
+    OWNER to postgres;
 
-$BODY$;
+-- Index: ix_webhookdeliveries_webhookid
 
-                                    ALTER FUNCTION public.calculatestoryrank(stories)
-    OWNER TO postgres;
+-- DROP INDEX public.ix_webhookdeliveries_webhookid;
 
+CREATE INDEX ix_webhookdeliveries_webhookid
+    ON public.webhookdeliveries USING btree
+    (webhookid, createdon DESC)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.tags
+
+-- DROP TABLE public.tags;
+
+CREATE TABLE public.tags
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    name character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    description character varying(100) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT tags_pkey PRIMARY KEY (id),
+    CONSTRAINT tags_customerid_name_key UNIQUE (customerid, name),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.tags
+    OWNER to postgres;
+
+-- Table: public.hiddentags
+
+-- DROP TABLE public.hiddentags;
+
+CREATE TABLE public.hiddentags
+(
+    userid integer NOT NULL,
+    tag character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT hiddentags_pkey PRIMARY KEY (userid, tag),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.hiddentags
+    OWNER to postgres;
+
+-- Index: ix_stories_tags_gin
+
+-- DROP INDEX public.ix_stories_tags_gin;
+
+CREATE INDEX ix_stories_tags_gin
+    ON public.stories USING gin
+    (tags)
+    TABLESPACE pg_default;
 
 
 
 
-                                    -- Index: calculatestoryrank_idx
+-- Table: public.storykinds
 
-                                    -- DROP INDEX public.calculatestoryrank_idx;
+-- DROP TABLE public.storykinds;
 
-                                    CREATE INDEX ix_calculatestoryrank
+CREATE TABLE public.storykinds
+(
+    customerid integer NOT NULL,
+    name character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    titleprefix character varying(25) COLLATE pg_catalog."default",
+    voteexponent double precision NOT NULL DEFAULT 0.8,
+    timeexponent double precision NOT NULL DEFAULT 0.1,
+    rankmultiplier double precision NOT NULL DEFAULT 1.0,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT storykinds_pkey PRIMARY KEY (customerid, name),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.storykinds
+    OWNER to postgres;
+
+-- Index: ix_stories_kind
+
+-- DROP INDEX public.ix_stories_kind;
+
+CREATE INDEX ix_stories_kind
     ON public.stories USING btree
-                                    (calculatestoryrank
-                                    (stories.*) DESC NULLS FIRST)
+    (kind COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Column: public.stories.searchvector
+
+-- ALTER TABLE public.stories DROP COLUMN searchvector;
+
+ALTER TABLE public.stories
+    ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english'::regconfig, COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english'::regconfig, COALESCE(text, '')), 'B') ||
+        setweight(to_tsvector('simple'::regconfig, COALESCE(substring(url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)'), '')), 'C')
+    ) STORED;
+
+-- Index: ix_stories_searchvector
+
+-- DROP INDEX public.ix_stories_searchvector;
+
+CREATE INDEX ix_stories_searchvector
+    ON public.stories USING gin
+    (searchvector)
+    TABLESPACE pg_default;
+
+-- Column: public.comments.searchvector
+
+-- ALTER TABLE public.comments DROP COLUMN searchvector;
+
+ALTER TABLE public.comments
+    ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+        to_tsvector('english'::regconfig, COALESCE(comment, ''))
+    ) STORED;
+
+-- Index: ix_comments_searchvector
+
+-- DROP INDEX public.ix_comments_searchvector;
+
+CREATE INDEX ix_comments_searchvector
+    ON public.comments USING gin
+    (searchvector)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.rankingsettings
+
+-- DROP TABLE public.rankingsettings;
+
+CREATE TABLE public.rankingsettings
+(
+    customerid integer NOT NULL,
+    algorithm character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'gravity'::character varying,
+    gravity double precision NOT NULL DEFAULT 0.1,
+    commentpenalty integer NOT NULL DEFAULT 40,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT rankingsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.rankingsettings
+    OWNER to postgres;
+
+
+
+
+-- Table: public.commentsettings
+
+-- DROP TABLE public.commentsettings;
+
+CREATE TABLE public.commentsettings
+(
+    userid integer NOT NULL,
+    sort character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'best'::character varying,
+    CONSTRAINT commentsettings_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.commentsettings
+    OWNER to postgres;
+
+
+
+
+-- Table: public.storyvisits
+
+-- DROP TABLE public.storyvisits;
+
+CREATE TABLE public.storyvisits
+(
+    userid integer NOT NULL,
+    storyid integer NOT NULL,
+    visitedon timestamp with time zone NOT NULL,
+    CONSTRAINT storyvisits_pkey PRIMARY KEY (userid, storyid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.storyvisits
+    OWNER to postgres;
+
+
+
+
+-- Table: public.voterules
+
+-- DROP TABLE public.voterules;
+
+CREATE TABLE public.voterules
+(
+    customerid integer NOT NULL,
+    minaccountagedays integer NOT NULL DEFAULT 0,
+    minkarma integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT voterules_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voterules
+    OWNER to postgres;
+
+
+
+
+-- Table: public.voteflags
+
+-- DROP TABLE public.voteflags;
+
+CREATE TABLE public.voteflags
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    reason character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    fingerprint character varying(200) COLLATE pg_catalog."default" NOT NULL,
+    evidence text COLLATE pg_catalog."default" NOT NULL,
+    userids integer[] NOT NULL,
+    storyid integer,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open'::character varying,
+    detectedon timestamp with time zone NOT NULL,
+    resolvedon timestamp with time zone,
+    CONSTRAINT voteflags_pkey PRIMARY KEY (id),
+    CONSTRAINT voteflags_fingerprint_key UNIQUE (customerid, fingerprint),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voteflags
+    OWNER to postgres;
+
+-- Index: ix_voteflags_status
+
+-- DROP INDEX public.ix_voteflags_status;
+
+CREATE INDEX ix_voteflags_status
+    ON public.voteflags USING btree
+    (customerid, status COLLATE pg_catalog."default")
     TABLESPACE pg_default;
 
+-- Table: public.voteflagvotes
+
+-- DROP TABLE public.voteflagvotes;
+
+CREATE TABLE public.voteflagvotes
+(
+    flagid integer NOT NULL,
+    targettype character varying(10) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    userid integer NOT NULL,
+    votetype integer NOT NULL,
+    CONSTRAINT voteflagvotes_pkey PRIMARY KEY (flagid, targettype, targetid, userid),
+    CONSTRAINT flagid_fk FOREIGN KEY (flagid)
+        REFERENCES public.voteflags (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voteflagvotes
+    OWNER to postgres;
+
+-- Index: ix_storyvotes_votedon
+
+-- DROP INDEX public.ix_storyvotes_votedon;
+
+CREATE INDEX ix_storyvotes_votedon
+    ON public.storyvotes USING btree
+    (votedon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_commentvotes_votedon
+
+-- DROP INDEX public.ix_commentvotes_votedon;
+
+CREATE INDEX ix_commentvotes_votedon
+    ON public.commentvotes USING btree
+    (votedon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.karmaledger
+
+-- DROP TABLE public.karmaledger;
+
+CREATE TABLE public.karmaledger
+(
+    id serial NOT NULL,
+    userid integer NOT NULL,
+    source character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    points integer NOT NULL,
+    storyid integer,
+    commentid integer,
+    actoruserid integer,
+    reason character varying(500) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT karmaledger_pkey PRIMARY KEY (id),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT commentid_fk FOREIGN KEY (commentid)
+        REFERENCES public.comments (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT actoruserid_fk FOREIGN KEY (actoruserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.karmaledger
+    OWNER to postgres;
+
+-- Index: ix_karmaledger_userid
+
+-- DROP INDEX public.ix_karmaledger_userid;
+
+CREATE INDEX ix_karmaledger_userid
+    ON public.karmaledger USING btree
+    (userid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.reportsettings
+
+-- DROP TABLE public.reportsettings;
+
+CREATE TABLE public.reportsettings
+(
+    customerid integer NOT NULL,
+    reasons text[] COLLATE pg_catalog."default" NOT NULL,
+    hidethreshold integer NOT NULL,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT reportsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.reportsettings
+    OWNER to postgres;
+
+
+
+
+-- Table: public.reports
+
+-- DROP TABLE public.reports;
+
+CREATE TABLE public.reports
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    targettype character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    storyid integer NOT NULL,
+    userid integer NOT NULL,
+    reason character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    reportedon timestamp with time zone NOT NULL,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open'::character varying,
+    resolvedon timestamp with time zone,
+    resolvedby integer,
+    CONSTRAINT reports_pkey PRIMARY KEY (id),
+    CONSTRAINT reports_target_user_key UNIQUE (targettype, targetid, userid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT resolvedby_fk FOREIGN KEY (resolvedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.reports
+    OWNER to postgres;
+
+-- Index: ix_reports_status
+
+-- DROP INDEX public.ix_reports_status;
+
+CREATE INDEX ix_reports_status
+    ON public.reports USING btree
+    (customerid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_reports_userid
+
+-- DROP INDEX public.ix_reports_userid;
+
+CREATE INDEX ix_reports_userid
+    ON public.reports USING btree
+    (userid ASC NULLS LAST, reportedon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.bannedusers
+
+-- DROP TABLE public.bannedusers;
+
+CREATE TABLE public.bannedusers
+(
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    reason character varying(500) COLLATE pg_catalog."default",
+    bannedby integer,
+    bannedon timestamp with time zone NOT NULL,
+    kind character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'ban'::character varying,
+    endson timestamp with time zone,
+    CONSTRAINT bannedusers_pkey PRIMARY KEY (userid, kind),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT bannedby_fk FOREIGN KEY (bannedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.bannedusers
+    OWNER to postgres;
+
+-- Index: ix_bannedusers_customerid
+
+-- DROP INDEX public.ix_bannedusers_customerid;
+
+CREATE INDEX ix_bannedusers_customerid
+    ON public.bannedusers USING btree
+    (customerid ASC NULLS LAST, kind COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.screeningsettings
+
+-- DROP TABLE public.screeningsettings;
+
+CREATE TABLE public.screeningsettings
+(
+    customerid integer NOT NULL,
+    banneddomains text[] COLLATE pg_catalog."default" NOT NULL,
+    bannedphrases text[] COLLATE pg_catalog."default" NOT NULL,
+    newaccountdays integer NOT NULL,
+    maxnewaccountlinks integer NOT NULL,
+    minreputation double precision NOT NULL,
+    spamthreshold double precision NOT NULL,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT screeningsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.screeningsettings
+    OWNER to postgres;
+
+
+
+
+-- Table: public.heldcontent
+
+-- DROP TABLE public.heldcontent;
+
+CREATE TABLE public.heldcontent
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    targettype character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    storyid integer NOT NULL,
+    userid integer NOT NULL,
+    domain character varying(255) COLLATE pg_catalog."default",
+    reasons text[] COLLATE pg_catalog."default" NOT NULL,
+    spamscore double precision NOT NULL DEFAULT 0,
+    heldon timestamp with time zone NOT NULL,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'held'::character varying,
+    reviewedon timestamp with time zone,
+    reviewedby integer,
+    CONSTRAINT heldcontent_pkey PRIMARY KEY (id),
+    CONSTRAINT heldcontent_target_key UNIQUE (targettype, targetid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT reviewedby_fk FOREIGN KEY (reviewedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.heldcontent
+    OWNER to postgres;
+
+-- Index: ix_heldcontent_status
+
+-- DROP INDEX public.ix_heldcontent_status;
+
+CREATE INDEX ix_heldcontent_status
+    ON public.heldcontent USING btree
+    (customerid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST, heldon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_heldcontent_domain
+
+-- DROP INDEX public.ix_heldcontent_domain;
+
+CREATE INDEX ix_heldcontent_domain
+    ON public.heldcontent USING btree
+    (customerid ASC NULLS LAST, domain COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.spamclassifiers
+
+-- DROP TABLE public.spamclassifiers;
+
+CREATE TABLE public.spamclassifiers
+(
+    customerid integer NOT NULL,
+    spamdocuments integer NOT NULL DEFAULT 0,
+    hamdocuments integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT spamclassifiers_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.spamclassifiers
+    OWNER to postgres;
+
+
+
+
+-- Table: public.spamtokens
+
+-- DROP TABLE public.spamtokens;
+
+CREATE TABLE public.spamtokens
+(
+    customerid integer NOT NULL,
+    token character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    spamcount integer NOT NULL DEFAULT 0,
+    hamcount integer NOT NULL DEFAULT 0,
+    CONSTRAINT spamtokens_pkey PRIMARY KEY (customerid, token),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.spamtokens
+    OWNER to postgres;
+
+
+
+
+-- Table: public.postingrules
+
+-- DROP TABLE public.postingrules;
+
+CREATE TABLE public.postingrules
+(
+    customerid integer NOT NULL,
+    probationdays integer NOT NULL DEFAULT 0,
+    probationkarma integer NOT NULL DEFAULT 0,
+    restricteddomains text[] COLLATE pg_catalog."default" NOT NULL DEFAULT '{}'::text[],
+    storiesperhour integer NOT NULL DEFAULT 0,
+    storiesperday integer NOT NULL DEFAULT 0,
+    commentsperhour integer NOT NULL DEFAULT 0,
+    commentsperday integer NOT NULL DEFAULT 0,
+    karmaperextrapost integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT postingrules_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.postingrules
+    OWNER to postgres;
+
+
+
+
+-- Table: public.ipbans
+
+-- DROP TABLE public.ipbans;
+
+CREATE TABLE public.ipbans
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    cidr character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    reason character varying(500) COLLATE pg_catalog."default",
+    bannedby integer,
+    bannedon timestamp with time zone NOT NULL,
+    endson timestamp with time zone,
+    CONSTRAINT ipbans_pkey PRIMARY KEY (id),
+    CONSTRAINT ipbans_customer_cidr_key UNIQUE (customerid, cidr),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT bannedby_fk FOREIGN KEY (bannedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.ipbans
+    OWNER to postgres;
+
+
+
+
+-- Table: public.moderationlog
+
+-- DROP TABLE public.moderationlog;
+
+CREATE TABLE public.moderationlog
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    actoruserid integer,
+    action character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    targetuserid integer,
+    target character varying(255) COLLATE pg_catalog."default",
+    details character varying(500) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT moderationlog_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT actoruserid_fk FOREIGN KEY (actoruserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT targetuserid_fk FOREIGN KEY (targetuserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.moderationlog
+    OWNER to postgres;
+
+-- Index: ix_moderationlog_customerid
+
+-- DROP INDEX public.ix_moderationlog_customerid;
+
+CREATE INDEX ix_moderationlog_customerid
+    ON public.moderationlog USING btree
+    (customerid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.invitesettings
+
+-- DROP TABLE public.invitesettings;
+
+CREATE TABLE public.invitesettings
+(
+    customerid integer NOT NULL,
+    expirydays integer NOT NULL DEFAULT 7,
+    memberinvitespermonth integer NOT NULL DEFAULT 5,
+    admininvitespermonth integer NOT NULL DEFAULT 0,
+    mininvitekarma integer NOT NULL DEFAULT 0,
+    karmaperextrainvite integer NOT NULL DEFAULT 0,
+    signupmode character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'invite'::character varying,
+    alloweddomains text[] COLLATE pg_catalog."default" NOT NULL DEFAULT '{}'::text[],
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT invitesettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.invitesettings
+    OWNER to postgres;
+
+
+
+
+-- Table: public.invitelinks
+
+-- DROP TABLE public.invitelinks;
+
+CREATE TABLE public.invitelinks
+(
+    code character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    customerid integer NOT NULL,
+    createdby integer,
+    createdon timestamp with time zone NOT NULL,
+    expireson timestamp with time zone,
+    maxuses integer NOT NULL DEFAULT 0,
+    usecount integer NOT NULL DEFAULT 0,
+    revoked boolean NOT NULL DEFAULT false,
+    CONSTRAINT invitelinks_pkey PRIMARY KEY (code),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT createdby_fk FOREIGN KEY (createdby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.invitelinks
+    OWNER to postgres;
+
+-- Index: ix_invitelinks_customerid
+
+-- DROP INDEX public.ix_invitelinks_customerid;
+
+CREATE INDEX ix_invitelinks_customerid
+    ON public.invitelinks USING btree
+    (customerid ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.signuprequests
+
+-- DROP TABLE public.signuprequests;
+
+CREATE TABLE public.signuprequests
+(
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    requestedon timestamp with time zone NOT NULL,
+    CONSTRAINT signuprequests_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.signuprequests
+    OWNER to postgres;
+
+-- Index: ix_signuprequests_customerid
+
+-- DROP INDEX public.ix_signuprequests_customerid;
+
+CREATE INDEX ix_signuprequests_customerid
+    ON public.signuprequests USING btree
+    (customerid ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.memberimports
+
+-- DROP TABLE public.memberimports;
+
+CREATE TABLE public.memberimports
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    createdby integer,
+    createdon timestamp with time zone NOT NULL,
+    filename character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    memo text COLLATE pg_catalog."default",
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'preview',
+    CONSTRAINT memberimports_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT createdby_fk FOREIGN KEY (createdby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.memberimports
+    OWNER to postgres;
+
+-- Index: ix_memberimports_customerid
+
+-- DROP INDEX public.ix_memberimports_customerid;
+
+CREATE INDEX ix_memberimports_customerid
+    ON public.memberimports USING btree
+    (customerid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Table: public.memberimportrows
+
+-- DROP TABLE public.memberimportrows;
+
+CREATE TABLE public.memberimportrows
+(
+    id serial NOT NULL,
+    importid integer NOT NULL,
+    linenumber integer NOT NULL,
+    email character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    fullname character varying(50) COLLATE pg_catalog."default",
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default",
+    invitecode character varying(20) COLLATE pg_catalog."default",
+    CONSTRAINT memberimportrows_pkey PRIMARY KEY (id),
+    CONSTRAINT importid_fk FOREIGN KEY (importid)
+        REFERENCES public.memberimports (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.memberimportrows
+    OWNER to postgres;
+
+-- Index: ix_memberimportrows_importid
+
+-- DROP INDEX public.ix_memberimportrows_importid;
+
+CREATE INDEX ix_memberimportrows_importid
+    ON public.memberimportrows USING btree
+    (importid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.usersessions
+
+-- DROP TABLE public.usersessions;
+
+CREATE TABLE public.usersessions
+(
+    id serial NOT NULL,
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    signedinon timestamp with time zone NOT NULL,
+    expireson timestamp with time zone NOT NULL,
+    ipaddress character varying(45) COLLATE pg_catalog."default",
+    useragent character varying(255) COLLATE pg_catalog."default",
+    CONSTRAINT usersessions_pkey PRIMARY KEY (id),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.usersessions
+    OWNER to postgres;
+
+-- Index: ix_usersessions_userid
+
+-- DROP INDEX public.ix_usersessions_userid;
+
+CREATE INDEX ix_usersessions_userid
+    ON public.usersessions USING btree
+    (userid ASC NULLS LAST, signedinon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+
+
+
+-- Table: public.emailconfirmations
+
+-- DROP TABLE public.emailconfirmations;
+
+CREATE TABLE public.emailconfirmations
+(
+    token character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    userid integer NOT NULL,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT emailconfirmations_pkey PRIMARY KEY (token),
+    CONSTRAINT emailconfirmations_userid_key UNIQUE (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.emailconfirmations
+    OWNER to postgres;
@@ -0,0 +1,124 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+	"time"
+)
+
+/*DigestSetting represents the user's preference for top stories digest emails*/
+type DigestSetting struct {
+	UserID     int
+	Frequency  enums.DigestFrequency
+	LastSentOn *time.Time
+}
+
+/*DigestSubscriber represents a user who will receive the digest email*/
+type DigestSubscriber struct {
+	UserID     int
+	UserName   string
+	Email      string
+	LastSentOn *time.Time
+}
+
+/*GetDigestSettingByUserID gets user's digest setting. If user has not set it yet, returns the default setting.*/
+func GetDigestSettingByUserID(userID int) (*DigestSetting, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT userid, frequency, lastsenton FROM digestsettings WHERE userid = $1"
+	row := db.QueryRow(query, userID)
+	setting := DigestSetting{}
+	var lastSentOn sql.NullTime
+	err = row.Scan(&setting.UserID, &setting.Frequency, &lastSentOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &DigestSetting{UserID: userID, Frequency: enums.DigestNever}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read digest setting. UserID: %d", userID), err}
+	}
+	if lastSentOn.Valid {
+		setting.LastSentOn = &lastSentOn.Time
+	}
+	return &setting, nil
+}
+
+/*SaveDigestSetting inserts or updates user's digest frequency*/
+func SaveDigestSetting(userID int, frequency enums.DigestFrequency) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO digestsettings (userid, frequency) VALUES ($1, $2) ON CONFLICT (userid) DO UPDATE SET frequency = EXCLUDED.frequency"
+	_, err = db.Exec(query, userID, frequency)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save digest setting. UserID: %d, Frequency: %d", userID, frequency), err}
+	}
+	return nil
+}
+
+/*GetDigestSubscribers returns customer's users who opted in to given digest frequency*/
+func GetDigestSubscribers(customerID int, frequency enums.DigestFrequency) (*[]DigestSubscriber, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT users.id, users.username, users.email, digestsettings.lastsenton FROM digestsettings INNER JOIN users ON users.id = digestsettings.userid WHERE users.customerid = $1 AND digestsettings.frequency = $2"
+	rows, err := db.Query(query, customerID, frequency)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query digest subscribers. CustomerID: %d, Frequency: %d", customerID, frequency), err}
+	}
+	subscribers := []DigestSubscriber{}
+	for rows.Next() {
+		subscriber := DigestSubscriber{}
+		var lastSentOn sql.NullTime
+		err = rows.Scan(&subscriber.UserID, &subscriber.UserName, &subscriber.Email, &lastSentOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read digest subscriber row. CustomerID: %d", customerID), err}
+		}
+		if lastSentOn.Valid {
+			subscriber.LastSentOn = &lastSentOn.Time
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+	return &subscribers, nil
+}
+
+/*MarkDigestAsSent sets the last time the digest email was sent to the user*/
+func MarkDigestAsSent(userID int, sentOn time.Time) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "UPDATE digestsettings SET lastsenton = $1 WHERE userid = $2"
+	_, err = db.Exec(query, sentOn, userID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot mark digest as sent. UserID: %d", userID), err}
+	}
+	return nil
+}
+
+/*GetTopStoriesSince returns customer's highest ranked stories which are submitted after the given time*/
+func GetTopStoriesSince(customerID int, since time.Time, count int) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + storyColumns + ", users.username, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.submittedon >= $2 ORDER BY stories.rank DESC LIMIT $3"
+	rows, err := db.Query(query, customerID, since, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot get top stories. CustomerID: %d, Since: %v", customerID, since), err}
+	}
+	stories, err := MapSQLRowsToStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read top story rows. CustomerID: %d", customerID), err}
+	}
+	return stories, nil
+}
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+/*AddEmailConfirmation saves the token which is mailed to the new account to confirm its email address. The account cannot sign in until it is confirmed.*/
+func AddEmailConfirmation(userID int, token string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO emailconfirmations (token, userid, createdon) VALUES ($1, $2, $3)"
+	_, err = db.Exec(query, token, userID, time.Now())
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot insert email confirmation. UserID: %d", userID), err}
+	}
+	return nil
+}
+
+/*IsEmailConfirmationPending checks whether the account has not confirmed its email address yet*/
+func IsEmailConfirmationPending(userID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM emailconfirmations WHERE userid = $1"
+	confirmationCount, err := count(query, userID)
+	if err != nil {
+		return false, err
+	}
+	return confirmationCount > 0, nil
+}
+
+/*ConfirmEmail confirms the email address of the account which the token is mailed to. Returns nil if there is no such token.*/
+func ConfirmEmail(token string) (*int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{"DB connection error", err}
+	}
+	defer db.Close()
+	var userID int
+	err = db.QueryRow("DELETE FROM emailconfirmations WHERE token = $1 RETURNING userid", token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &DBError{"Cannot confirm email", err}
+	}
+	return &userID, nil
+}
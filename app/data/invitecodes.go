@@ -4,33 +4,68 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/rs/xid"
 )
 
+const (
+	/*InvitePending represents the invites which can still be used*/
+	InvitePending = "pending"
+	/*InviteUsed represents the invites which an account is created with*/
+	InviteUsed = "used"
+	/*InviteExpired represents the invites which are not used before their expiry*/
+	InviteExpired = "expired"
+	/*InviteRevoked represents the invites which are revoked by the inviter or an admin*/
+	InviteRevoked = "revoked"
+)
+
+const inviteCodeColumns = "invitecodes.code, invitecodes.inviteruserid, invitecodes.invitedemail, invitecodes.used, invitecodes.createdon, invitecodes.expireson, invitecodes.revoked, invitecodes.lastsenton," +
+	" COALESCE(invitecodes.fullname, ''), invitecodes.role"
+
 /*InviteCodeInfo represents the invited code info.*/
 type InviteCodeInfo struct {
 	Code                string
 	InviterUserID       int
+	InviterUserName     string
 	InvitedEmailAddress string
 	Used                bool
 	CreatedOn           time.Time
+	ExpiresOn           *time.Time
+	Revoked             bool
+	LastSentOn          *time.Time
+	FullName            string
+	Role                string
+}
+
+/*Status returns whether the invite is pending, used, expired or revoked*/
+func (info *InviteCodeInfo) Status() string {
+	switch {
+	case info.Used:
+		return InviteUsed
+	case info.Revoked:
+		return InviteRevoked
+	case info.ExpiresOn != nil && time.Now().After(*info.ExpiresOn):
+		return InviteExpired
+	}
+	return InvitePending
 }
 
-/*CreateInviteCode creates an invite code.*/
-func CreateInviteCode(inviterUserID int, invitedEmail string) (string, error) {
+/*CreateInviteCode creates an invite code. The code does not expire if expiresOn is nil.*/
+func CreateInviteCode(inviterUserID int, invitedEmail string, expiresOn *time.Time) (string, error) {
 	db, err := connectToDB()
 	if err != nil {
 		return "", &DBError{fmt.Sprintf("Cannot conenct to db to create new invite code. InviterUserID: %d, InvitedEmail: %s", inviterUserID, invitedEmail), err}
 	}
 	defer db.Close()
 	inviteCode := xid.New().String()
-	sql := "INSERT INTO invitecodes (code, inviteruserid, invitedemail, createdon) VALUES ($1, $2, $3, $4) RETURNING code"
+	sql := "INSERT INTO invitecodes (code, inviteruserid, invitedemail, createdon, expireson, lastsenton) VALUES ($1, $2, $3, $4, $5, $4) RETURNING code"
 	_, err = db.Exec(
 		sql,
 		inviteCode,
 		inviterUserID,
 		invitedEmail,
-		time.Now())
+		time.Now(),
+		nullTime(expiresOn))
 	if err != nil {
 		return "", &DBError{fmt.Sprintf("Cannot create a new intvite code. InviterUserID: %d, InvitedEmail: %s", inviterUserID, invitedEmail), err}
 	}
@@ -116,7 +151,7 @@ func GetInviteCodeInfoByCode(inviteCode string) (*InviteCodeInfo, error) {
 		return nil, err
 	}
 	defer db.Close()
-	query := "SELECT code, inviteruserid, invitedemail, used, createdon FROM invitecodes WHERE code = $1"
+	query := "SELECT " + inviteCodeColumns + " FROM invitecodes WHERE code = $1"
 	row := db.QueryRow(query, inviteCode)
 	inviteCodeInfo, err := MapSQLRowToInviteCodeInfo(row)
 	if err != nil {
@@ -124,3 +159,88 @@ func GetInviteCodeInfoByCode(inviteCode string) (*InviteCodeInfo, error) {
 	}
 	return inviteCodeInfo, nil
 }
+
+/*GetInviteCodesByInviter returns the invites which the user created, the latest first*/
+func GetInviteCodesByInviter(inviterUserID int) (*[]InviteCodeInfo, error) {
+	query := "SELECT " + inviteCodeColumns + ", users.username FROM invitecodes INNER JOIN users ON users.id = invitecodes.inviteruserid" +
+		" WHERE invitecodes.inviteruserid = $1 ORDER BY invitecodes.createdon DESC"
+	return getInviteCodes(query, inviterUserID)
+}
+
+/*GetInviteCodesByCustomer returns the latest invites which the members of the customer created*/
+func GetInviteCodesByCustomer(customerID, limit int) (*[]InviteCodeInfo, error) {
+	query := "SELECT " + inviteCodeColumns + ", users.username FROM invitecodes INNER JOIN users ON users.id = invitecodes.inviteruserid" +
+		" WHERE users.customerid = $1 ORDER BY invitecodes.createdon DESC LIMIT $2"
+	return getInviteCodes(query, customerID, limit)
+}
+
+func getInviteCodes(query string, args ...interface{}) (*[]InviteCodeInfo, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. Args: %v", args), err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query invite codes. Args: %v", args), err}
+	}
+	defer rows.Close()
+	infos := []InviteCodeInfo{}
+	for rows.Next() {
+		var info InviteCodeInfo
+		var expiresOn, lastSentOn pq.NullTime
+		err = rows.Scan(&info.Code, &info.InviterUserID, &info.InvitedEmailAddress, &info.Used, &info.CreatedOn,
+			&expiresOn, &info.Revoked, &lastSentOn, &info.FullName, &info.Role, &info.InviterUserName)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read invite code row. Args: %v", args), err}
+		}
+		info.ExpiresOn = timePointer(expiresOn)
+		info.LastSentOn = timePointer(lastSentOn)
+		infos = append(infos, info)
+	}
+	return &infos, nil
+}
+
+/*GetPendingInviteEmails returns which of the given email addresses have an invite from a member of the customer which can still be used. The addresses are compared and returned in lower case.*/
+func GetPendingInviteEmails(customerID int, emails []string) (map[string]bool, error) {
+	query := "SELECT DISTINCT LOWER(invitecodes.invitedemail) FROM invitecodes INNER JOIN users ON users.id = invitecodes.inviteruserid" +
+		" WHERE users.customerid = $1 AND LOWER(invitecodes.invitedemail) = ANY($2) AND NOT invitecodes.used AND NOT invitecodes.revoked" +
+		" AND (invitecodes.expireson IS NULL OR invitecodes.expireson > now())"
+	return getEmailSet(query, customerID, lowerEmails(emails))
+}
+
+/*CountInviteCodesSince returns the number of the invites which the user created since given time. Revoked invites which are not used are not counted.*/
+func CountInviteCodesSince(inviterUserID int, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM invitecodes WHERE inviteruserid = $1 AND createdon >= $2 AND (used OR NOT revoked)"
+	return count(query, inviterUserID, since)
+}
+
+/*RevokeInviteCode revokes the invite code so that it cannot be used anymore*/
+func RevokeInviteCode(inviteCode string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. InviteCode: %s", inviteCode), err}
+	}
+	defer db.Close()
+	query := "UPDATE invitecodes SET revoked = true WHERE code = $1 AND NOT used"
+	_, err = db.Exec(query, inviteCode)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot revoke invite code. InviteCode: %s", inviteCode), err}
+	}
+	return nil
+}
+
+/*RenewInviteCode sets the new expiry of the invite code which is sent again*/
+func RenewInviteCode(inviteCode string, expiresOn *time.Time) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. InviteCode: %s", inviteCode), err}
+	}
+	defer db.Close()
+	query := "UPDATE invitecodes SET expireson = $2, lastsenton = $3 WHERE code = $1"
+	_, err = db.Exec(query, inviteCode, nullTime(expiresOn), time.Now())
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot renew invite code. InviteCode: %s", inviteCode), err}
+	}
+	return nil
+}
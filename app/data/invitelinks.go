@@ -0,0 +1,145 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/xid"
+)
+
+const inviteLinkColumns = "invitelinks.code, invitelinks.customerid, COALESCE(invitelinks.createdby, 0), COALESCE(users.username, ''), invitelinks.createdon," +
+	" invitelinks.expireson, invitelinks.maxuses, invitelinks.usecount, invitelinks.revoked"
+
+/*InviteLink represents a reusable invite link which many people can join the customer with*/
+type InviteLink struct {
+	Code          string
+	CustomerID    int
+	CreatedBy     int
+	CreatedByName string
+	CreatedOn     time.Time
+	ExpiresOn     *time.Time
+	MaxUses       int
+	UseCount      int
+	Revoked       bool
+}
+
+/*Status returns whether the link is pending, used up, expired or revoked*/
+func (link *InviteLink) Status() string {
+	switch {
+	case link.Revoked:
+		return InviteRevoked
+	case link.MaxUses > 0 && link.UseCount >= link.MaxUses:
+		return InviteUsed
+	case link.ExpiresOn != nil && time.Now().After(*link.ExpiresOn):
+		return InviteExpired
+	}
+	return InvitePending
+}
+
+/*CreateInviteLink creates a reusable invite link. MaxUses 0 allows unlimited uses and nil ExpiresOn keeps the link valid until it is revoked.*/
+func CreateInviteLink(link *InviteLink) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", link.CustomerID), err}
+	}
+	defer db.Close()
+	link.Code = xid.New().String()
+	query := "INSERT INTO invitelinks (code, customerid, createdby, createdon, expireson, maxuses) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err = db.Exec(query, link.Code, link.CustomerID, nullInt(link.CreatedBy), link.CreatedOn, nullTime(link.ExpiresOn), link.MaxUses)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot create invite link. CustomerID: %d", link.CustomerID), err}
+	}
+	return nil
+}
+
+/*GetInviteLinkByCode returns the invite link. Returns nil if there is no such link.*/
+func GetInviteLinkByCode(code string) (*InviteLink, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. Code: %s", code), err}
+	}
+	defer db.Close()
+	query := "SELECT " + inviteLinkColumns + " FROM invitelinks LEFT JOIN users ON users.id = invitelinks.createdby WHERE invitelinks.code = $1"
+	link, err := scanInviteLink(db.QueryRow(query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read invite link. Code: %s", code), err}
+	}
+	return link, nil
+}
+
+/*GetInviteLinks returns the invite links of the customer, the latest first*/
+func GetInviteLinks(customerID int) (*[]InviteLink, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + inviteLinkColumns + " FROM invitelinks LEFT JOIN users ON users.id = invitelinks.createdby" +
+		" WHERE invitelinks.customerid = $1 ORDER BY invitelinks.createdon DESC"
+	rows, err := db.Query(query, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query invite links. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	links := []InviteLink{}
+	for rows.Next() {
+		link, err := scanInviteLink(rows)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read invite link row. CustomerID: %d", customerID), err}
+		}
+		links = append(links, *link)
+	}
+	return &links, nil
+}
+
+func scanInviteLink(row interface{ Scan(...interface{}) error }) (*InviteLink, error) {
+	link := &InviteLink{}
+	var expiresOn pq.NullTime
+	err := row.Scan(&link.Code, &link.CustomerID, &link.CreatedBy, &link.CreatedByName, &link.CreatedOn,
+		&expiresOn, &link.MaxUses, &link.UseCount, &link.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	link.ExpiresOn = timePointer(expiresOn)
+	return link, nil
+}
+
+/*RevokeInviteLink revokes the invite link of the customer so that nobody can join with it anymore*/
+func RevokeInviteLink(customerID int, code string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Code: %s", customerID, code), err}
+	}
+	defer db.Close()
+	query := "UPDATE invitelinks SET revoked = true WHERE customerid = $1 AND code = $2"
+	_, err = db.Exec(query, customerID, code)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot revoke invite link. CustomerID: %d, Code: %s", customerID, code), err}
+	}
+	return nil
+}
+
+/*UseInviteLink counts a signup with the invite link. Returns false if the link is revoked, expired or used up in the meantime.*/
+func UseInviteLink(code string) (bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("DB connection error. Code: %s", code), err}
+	}
+	defer db.Close()
+	query := "UPDATE invitelinks SET usecount = usecount + 1 WHERE code = $1 AND NOT revoked" +
+		" AND (maxuses = 0 OR usecount < maxuses) AND (expireson IS NULL OR expireson > now())"
+	result, err := db.Exec(query, code)
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot use invite link. Code: %s", code), err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot read affected invite links. Code: %s", code), err}
+	}
+	return affected > 0, nil
+}
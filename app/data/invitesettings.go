@@ -0,0 +1,83 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*SignupInviteOnly represents the customers which only invited people can join*/
+	SignupInviteOnly = "invite"
+	/*SignupInviteLinks represents the customers which people can join with an invite or a reusable invite link*/
+	SignupInviteLinks = "links"
+	/*SignupAllowedDomains represents the customers which anyone with an email address of an allowed domain can join*/
+	SignupAllowedDomains = "domains"
+	/*SignupApproval represents the customers which anyone can ask to join and admins approve*/
+	SignupApproval = "approval"
+
+	defaultInviteExpiryDays      = 7
+	defaultMemberInvitesPerMonth = 5
+)
+
+/*InviteSettings represents how long the invites of the customer are valid, how many invites the members can send and how people can sign up. 0 disables a rule.*/
+type InviteSettings struct {
+	CustomerID            int
+	ExpiryDays            int
+	MemberInvitesPerMonth int
+	AdminInvitesPerMonth  int
+	MinInviteKarma        int
+	KarmaPerExtraInvite   int
+	SignupMode            string
+	AllowedDomains        []string
+	UpdatedOn             time.Time
+}
+
+/*GetInviteSettings returns the invite settings of the customer. Returns the default settings if customer has not set them yet.*/
+func GetInviteSettings(customerID int) (*InviteSettings, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, expirydays, memberinvitespermonth, admininvitespermonth, mininvitekarma, karmaperextrainvite, signupmode, alloweddomains, updatedon" +
+		" FROM invitesettings WHERE customerid = $1"
+	settings := &InviteSettings{}
+	err = db.QueryRow(query, customerID).Scan(&settings.CustomerID, &settings.ExpiryDays, &settings.MemberInvitesPerMonth, &settings.AdminInvitesPerMonth,
+		&settings.MinInviteKarma, &settings.KarmaPerExtraInvite, &settings.SignupMode, pq.Array(&settings.AllowedDomains), &settings.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &InviteSettings{
+				CustomerID:            customerID,
+				ExpiryDays:            defaultInviteExpiryDays,
+				MemberInvitesPerMonth: defaultMemberInvitesPerMonth,
+				SignupMode:            SignupInviteOnly,
+				AllowedDomains:        []string{},
+			}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read invite settings. CustomerID: %d", customerID), err}
+	}
+	return settings, nil
+}
+
+/*SaveInviteSettings inserts or updates the invite settings of the customer*/
+func SaveInviteSettings(settings *InviteSettings) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", settings.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO invitesettings (customerid, expirydays, memberinvitespermonth, admininvitespermonth, mininvitekarma, karmaperextrainvite, signupmode, alloweddomains, updatedon)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (customerid) DO UPDATE SET expirydays = EXCLUDED.expirydays," +
+		" memberinvitespermonth = EXCLUDED.memberinvitespermonth, admininvitespermonth = EXCLUDED.admininvitespermonth," +
+		" mininvitekarma = EXCLUDED.mininvitekarma, karmaperextrainvite = EXCLUDED.karmaperextrainvite," +
+		" signupmode = EXCLUDED.signupmode, alloweddomains = EXCLUDED.alloweddomains, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, settings.CustomerID, settings.ExpiryDays, settings.MemberInvitesPerMonth, settings.AdminInvitesPerMonth,
+		settings.MinInviteKarma, settings.KarmaPerExtraInvite, settings.SignupMode, pq.Array(settings.AllowedDomains), settings.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save invite settings. CustomerID: %d", settings.CustomerID), err}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// inviterJoins joins the personal invite or the invite link which the user signed up with. users.invitecode holds the code of either.
+const inviterJoins = " LEFT JOIN invitecodes ON invitecodes.code = users.invitecode LEFT JOIN invitelinks ON invitelinks.code = users.invitecode"
+
+// inviterIDSQL returns the id of the member who sent the invite or created the invite link
+const inviterIDSQL = "COALESCE(invitecodes.inviteruserid, invitelinks.createdby)"
+
+/*InviteTreeMember represents a member of the customer and the member who invited them*/
+type InviteTreeMember struct {
+	UserID        int
+	UserName      string
+	Karma         int
+	RegisteredOn  time.Time
+	InviterUserID int
+	ViaLink       bool
+	BanKinds      []string
+}
+
+/*GetInviteTreeMembers returns the members of the customer with their inviters and active bans, the oldest first. Accounts which wait for approval are not returned.*/
+func GetInviteTreeMembers(customerID int) (*[]InviteTreeMember, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT users.id, users.username, users.karma, users.registeredon, COALESCE(" + inviterIDSQL + ", 0), invitelinks.code IS NOT NULL," +
+		" ARRAY(SELECT bannedusers.kind FROM bannedusers WHERE bannedusers.userid = users.id AND " + activeBanSQL + " ORDER BY bannedusers.kind)" +
+		" FROM users" + inviterJoins +
+		" WHERE users.customerid = $1 AND NOT EXISTS (SELECT 1 FROM signuprequests WHERE signuprequests.userid = users.id)" +
+		" ORDER BY users.registeredon ASC"
+	rows, err := db.Query(query, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query invite tree. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	members := []InviteTreeMember{}
+	for rows.Next() {
+		var member InviteTreeMember
+		err = rows.Scan(&member.UserID, &member.UserName, &member.Karma, &member.RegisteredOn, &member.InviterUserID, &member.ViaLink,
+			pq.Array(&member.BanKinds))
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read invite tree row. CustomerID: %d", customerID), err}
+		}
+		members = append(members, member)
+	}
+	return &members, nil
+}
+
+/*GetInviter returns the user name of the member who invited the user and whether the user joined with an invite link. Returns empty user name if nobody invited the user.*/
+func GetInviter(userID int) (string, bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return "", false, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT COALESCE(inviters.username, ''), invitelinks.code IS NOT NULL FROM users" + inviterJoins +
+		" LEFT JOIN users AS inviters ON inviters.id = " + inviterIDSQL + " WHERE users.id = $1"
+	var inviterName string
+	var viaLink bool
+	err = db.QueryRow(query, userID).Scan(&inviterName, &viaLink)
+	if err != nil {
+		return "", false, &DBError{fmt.Sprintf("Cannot read inviter. UserID: %d", userID), err}
+	}
+	return inviterName, viaLink, nil
+}
+
+/*GetInviteCodeCounts returns the number of the personal invites which the members of the customer sent and how many of them are used*/
+func GetInviteCodeCounts(customerID int) (int, int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return 0, 0, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE invitecodes.used) FROM invitecodes" +
+		" INNER JOIN users ON users.id = invitecodes.inviteruserid WHERE users.customerid = $1"
+	var sent, used int
+	err = db.QueryRow(query, customerID).Scan(&sent, &used)
+	if err != nil {
+		return 0, 0, &DBError{fmt.Sprintf("Cannot count invite codes. CustomerID: %d", customerID), err}
+	}
+	return sent, used, nil
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+	"time"
+)
+
+/*KarmaEntry represents an entry of the append-only karma ledger. Karma of a user is the sum of points of the user's entries.*/
+type KarmaEntry struct {
+	ID            int
+	UserID        int
+	Source        enums.KarmaSource
+	Points        int
+	StoryID       int
+	StoryTitle    string
+	CommentID     int
+	ActorUserID   int
+	ActorUserName string
+	Reason        string
+	CreatedOn     time.Time
+}
+
+// syncKarmaSQL keeps the karma counter of the user in step with the entry which is appended to the ledger in the same transaction
+const syncKarmaSQL = "UPDATE users SET karma = karma + $1 WHERE id = $2"
+
+// recordVoteKarma appends the karma which the author of the voted story or comment earns (1) or loses (-1) by the vote of the voter
+func recordVoteKarma(tran *sql.Tx, source enums.KarmaSource, targetID, voterID, points int) error {
+	table, column := "stories", "storyid"
+	if source == enums.CommentVoteKarma {
+		table, column = "comments", "commentid"
+	}
+	query := fmt.Sprintf("INSERT INTO karmaledger (userid, source, points, %s, actoruserid, createdon) SELECT userid, $1, $2, id, $3, $4 FROM %s WHERE id = $5 RETURNING userid", column, table)
+	var userID int
+	err := tran.QueryRow(query, source, points, voterID, time.Now(), targetID).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	_, err = tran.Exec(syncKarmaSQL, points, userID)
+	return err
+}
+
+/*AddKarmaEntry appends the entry which an admin issued to the ledger and updates the karma of the user*/
+func AddKarmaEntry(entry *KarmaEntry) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", entry.UserID), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot begin transaction. UserID: %d", entry.UserID), err}
+	}
+	query := "INSERT INTO karmaledger (userid, source, points, actoruserid, reason, createdon) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err = tran.Exec(query, entry.UserID, entry.Source, entry.Points, nullInt(entry.ActorUserID), nullString(entry.Reason), entry.CreatedOn)
+	if err != nil {
+		tran.Rollback()
+		return &DBError{fmt.Sprintf("Cannot insert karma entry. UserID: %d, Source: %s", entry.UserID, entry.Source), err}
+	}
+	_, err = tran.Exec(syncKarmaSQL, entry.Points, entry.UserID)
+	if err != nil {
+		tran.Rollback()
+		return &DBError{fmt.Sprintf("Cannot update user's karma. UserID: %d", entry.UserID), err}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot commit transaction. UserID: %d", entry.UserID), err}
+	}
+	return nil
+}
+
+/*GetKarmaHistory returns the karma entries of the user, latest first. Entries of comment votes carry the story of the comment.*/
+func GetKarmaHistory(userID, pageNumber, pageSize int) (*[]KarmaEntry, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT karmaledger.id, karmaledger.userid, karmaledger.source, karmaledger.points, COALESCE(stories.id, 0), COALESCE(stories.title, ''), COALESCE(karmaledger.commentid, 0)," +
+		" COALESCE(karmaledger.actoruserid, 0), COALESCE(actors.username, ''), COALESCE(karmaledger.reason, ''), karmaledger.createdon" +
+		" FROM karmaledger LEFT JOIN comments ON comments.id = karmaledger.commentid" +
+		" LEFT JOIN stories ON stories.id = COALESCE(karmaledger.storyid, comments.storyid)" +
+		" LEFT JOIN users actors ON actors.id = karmaledger.actoruserid" +
+		" WHERE karmaledger.userid = $1 ORDER BY karmaledger.createdon DESC, karmaledger.id DESC LIMIT $2 OFFSET $3"
+	rows, err := db.Query(query, userID, pageSize, (pageNumber-1)*pageSize)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query karma history. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	entries := []KarmaEntry{}
+	for rows.Next() {
+		var entry KarmaEntry
+		err = rows.Scan(&entry.ID, &entry.UserID, &entry.Source, &entry.Points, &entry.StoryID, &entry.StoryTitle, &entry.CommentID,
+			&entry.ActorUserID, &entry.ActorUserName, &entry.Reason, &entry.CreatedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read karma entry row. UserID: %d", userID), err}
+		}
+		entries = append(entries, entry)
+	}
+	return &entries, nil
+}
+
+// reconcileVoteKarmaSQL appends the entries which bring the recorded karma of every (story or comment, voter) pair to what the existing upvotes earn.
+// Missing entries of upvotes are dated by the vote and reversals of removed votes are dated by the rebuild.
+const reconcileVoteKarmaSQL = "WITH expected AS (SELECT %[2]s AS targetid, userid AS voterid, votedon, 1 AS points FROM %[3]s WHERE votetype = $3)," +
+	" recorded AS (SELECT %[2]s AS targetid, actoruserid AS voterid, SUM(points) AS points FROM karmaledger WHERE source = $2 AND %[2]s IS NOT NULL GROUP BY %[2]s, actoruserid)" +
+	" INSERT INTO karmaledger (userid, source, points, %[2]s, actoruserid, createdon)" +
+	" SELECT %[1]s.userid, $2, COALESCE(expected.points, 0) - COALESCE(recorded.points, 0), %[1]s.id, voterid, COALESCE(expected.votedon, $4)" +
+	" FROM expected FULL OUTER JOIN recorded USING (targetid, voterid)" +
+	" INNER JOIN %[1]s ON %[1]s.id = targetid INNER JOIN users ON users.id = %[1]s.userid" +
+	" WHERE users.customerid = $1 AND COALESCE(expected.points, 0) <> COALESCE(recorded.points, 0)"
+
+/*RebuildKarma appends the missing entries of story and comment votes to the ledger of the customer's users and derives their karma from the ledger. Returns the number of users whose karma is corrected.*/
+func RebuildKarma(customerID int) (int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("Cannot begin transaction. CustomerID: %d", customerID), err}
+	}
+	now := time.Now()
+	for _, vote := range []struct {
+		source               enums.KarmaSource
+		table, column, votes string
+	}{
+		{enums.StoryVoteKarma, "stories", "storyid", "storyvotes"},
+		{enums.CommentVoteKarma, "comments", "commentid", "commentvotes"},
+	} {
+		query := fmt.Sprintf(reconcileVoteKarmaSQL, vote.table, vote.column, vote.votes)
+		_, err = tran.Exec(query, customerID, vote.source, enums.UpVote, now)
+		if err != nil {
+			tran.Rollback()
+			return 0, &DBError{fmt.Sprintf("Cannot reconcile karma of votes. CustomerID: %d, Source: %s", customerID, vote.source), err}
+		}
+	}
+	query := "UPDATE users SET karma = ledger.points FROM (SELECT users.id, COALESCE(SUM(karmaledger.points), 0) AS points FROM users" +
+		" LEFT JOIN karmaledger ON karmaledger.userid = users.id WHERE users.customerid = $1 GROUP BY users.id) ledger" +
+		" WHERE users.id = ledger.id AND users.karma <> ledger.points"
+	result, err := tran.Exec(query, customerID)
+	if err != nil {
+		tran.Rollback()
+		return 0, &DBError{fmt.Sprintf("Cannot update karma of users. CustomerID: %d", customerID), err}
+	}
+	corrected, err := result.RowsAffected()
+	if err != nil {
+		tran.Rollback()
+		return 0, &DBError{fmt.Sprintf("Cannot read updated users count. CustomerID: %d", customerID), err}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("Cannot commit transaction. CustomerID: %d", customerID), err}
+	}
+	return int(corrected), nil
+}
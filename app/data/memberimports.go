@@ -0,0 +1,348 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+const (
+	/*ImportPreview represents the imports which are validated and wait for the admin to send the invites*/
+	ImportPreview = "preview"
+	/*ImportSending represents the imports whose invitation mails are being sent*/
+	ImportSending = "sending"
+	/*ImportCompleted represents the imports whose invitation mails are all sent or failed*/
+	ImportCompleted = "completed"
+
+	/*ImportRowValid represents the rows which can be invited*/
+	ImportRowValid = "valid"
+	/*ImportRowInvalid represents the rows which have an invalid address, name or role*/
+	ImportRowInvalid = "invalid"
+	/*ImportRowDuplicate represents the rows whose address is repeated in the file*/
+	ImportRowDuplicate = "duplicate"
+	/*ImportRowMember represents the rows whose address already has an account*/
+	ImportRowMember = "member"
+	/*ImportRowInvited represents the rows whose address already has a pending invite*/
+	ImportRowInvited = "invited"
+	/*ImportRowQueued represents the rows whose invite is created and whose mail waits to be sent*/
+	ImportRowQueued = "queued"
+	/*ImportRowSent represents the rows whose invitation mail is sent*/
+	ImportRowSent = "sent"
+	/*ImportRowFailed represents the rows whose invitation mail could not be sent*/
+	ImportRowFailed = "failed"
+)
+
+const memberImportColumns = "memberimports.id, memberimports.customerid, COALESCE(memberimports.createdby, 0), COALESCE(users.username, ''), memberimports.createdon," +
+	" memberimports.filename, COALESCE(memberimports.memo, ''), memberimports.status, COUNT(memberimportrows.id)," +
+	" COUNT(memberimportrows.id) FILTER (WHERE memberimportrows.status = 'valid')," +
+	" COUNT(memberimportrows.id) FILTER (WHERE memberimportrows.status = 'queued')," +
+	" COUNT(memberimportrows.id) FILTER (WHERE memberimportrows.status = 'sent')," +
+	" COUNT(memberimportrows.id) FILTER (WHERE memberimportrows.status = 'failed')"
+
+const memberImportJoins = " FROM memberimports LEFT JOIN users ON users.id = memberimports.createdby" +
+	" LEFT JOIN memberimportrows ON memberimportrows.importid = memberimports.id"
+
+/*MemberImport represents a csv file of addresses which an admin uploaded to invite at once*/
+type MemberImport struct {
+	ID            int
+	CustomerID    int
+	CreatedBy     int
+	CreatedByName string
+	CreatedOn     time.Time
+	FileName      string
+	Memo          string
+	Status        string
+	RowCount      int
+	ValidCount    int
+	QueuedCount   int
+	SentCount     int
+	FailedCount   int
+}
+
+/*MemberImportRow represents a line of an imported csv file and the result of inviting it*/
+type MemberImportRow struct {
+	ID         int
+	ImportID   int
+	LineNumber int
+	Email      string
+	FullName   string
+	Role       string
+	Status     string
+	Message    string
+	InviteCode string
+}
+
+/*CreateMemberImport saves the validated rows of the uploaded file and sets the id of the import*/
+func CreateMemberImport(memberImport *MemberImport, rows []MemberImportRow) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", memberImport.CustomerID), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot start the transaction. CustomerID: %d", memberImport.CustomerID), err}
+	}
+	query := "INSERT INTO memberimports (customerid, createdby, createdon, filename, status) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = tran.QueryRow(query, memberImport.CustomerID, nullInt(memberImport.CreatedBy), memberImport.CreatedOn,
+		memberImport.FileName, ImportPreview).Scan(&memberImport.ID)
+	if err != nil {
+		tran.Rollback()
+		return &DBError{fmt.Sprintf("Cannot insert member import. CustomerID: %d", memberImport.CustomerID), err}
+	}
+	query = "INSERT INTO memberimportrows (importid, linenumber, email, fullname, role, status, message) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	for _, row := range rows {
+		_, err = tran.Exec(query, memberImport.ID, row.LineNumber, row.Email, nullString(row.FullName), row.Role, row.Status, nullString(row.Message))
+		if err != nil {
+			tran.Rollback()
+			return &DBError{fmt.Sprintf("Cannot insert member import row. ImportID: %d, LineNumber: %d", memberImport.ID, row.LineNumber), err}
+		}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot commit transaction. CustomerID: %d", memberImport.CustomerID), err}
+	}
+	memberImport.Status = ImportPreview
+	return nil
+}
+
+/*GetMemberImport returns the import of the customer. Returns nil if there is no such import.*/
+func GetMemberImport(customerID, importID int) (*MemberImport, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, ImportID: %d", customerID, importID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + memberImportColumns + memberImportJoins +
+		" WHERE memberimports.customerid = $1 AND memberimports.id = $2 GROUP BY memberimports.id, users.username"
+	memberImport, err := scanMemberImport(db.QueryRow(query, customerID, importID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read member import. CustomerID: %d, ImportID: %d", customerID, importID), err}
+	}
+	return memberImport, nil
+}
+
+/*GetMemberImports returns the latest imports of the customer*/
+func GetMemberImports(customerID, limit int) (*[]MemberImport, error) {
+	query := "SELECT " + memberImportColumns + memberImportJoins +
+		" WHERE memberimports.customerid = $1 GROUP BY memberimports.id, users.username ORDER BY memberimports.createdon DESC LIMIT $2"
+	return getMemberImports(query, customerID, limit)
+}
+
+/*GetSendingMemberImports returns the imports of every customer whose invitation mails are being sent*/
+func GetSendingMemberImports() (*[]MemberImport, error) {
+	query := "SELECT " + memberImportColumns + memberImportJoins +
+		" WHERE memberimports.status = $1 GROUP BY memberimports.id, users.username ORDER BY memberimports.createdon ASC"
+	return getMemberImports(query, ImportSending)
+}
+
+func getMemberImports(query string, args ...interface{}) (*[]MemberImport, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. Args: %v", args), err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query member imports. Args: %v", args), err}
+	}
+	defer rows.Close()
+	memberImports := []MemberImport{}
+	for rows.Next() {
+		memberImport, err := scanMemberImport(rows)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read member import row. Args: %v", args), err}
+		}
+		memberImports = append(memberImports, *memberImport)
+	}
+	return &memberImports, nil
+}
+
+func scanMemberImport(row interface{ Scan(...interface{}) error }) (*MemberImport, error) {
+	memberImport := &MemberImport{}
+	err := row.Scan(&memberImport.ID, &memberImport.CustomerID, &memberImport.CreatedBy, &memberImport.CreatedByName, &memberImport.CreatedOn,
+		&memberImport.FileName, &memberImport.Memo, &memberImport.Status, &memberImport.RowCount,
+		&memberImport.ValidCount, &memberImport.QueuedCount, &memberImport.SentCount, &memberImport.FailedCount)
+	if err != nil {
+		return nil, err
+	}
+	return memberImport, nil
+}
+
+/*GetMemberImportRows returns the rows of the import in the order of the file*/
+func GetMemberImportRows(importID int) (*[]MemberImportRow, error) {
+	query := "SELECT id, importid, linenumber, email, COALESCE(fullname, ''), role, status, COALESCE(message, ''), COALESCE(invitecode, '')" +
+		" FROM memberimportrows WHERE importid = $1 ORDER BY linenumber ASC"
+	return getMemberImportRows(query, importID)
+}
+
+/*GetQueuedMemberImportRows returns the rows of the import whose invitation mails wait to be sent*/
+func GetQueuedMemberImportRows(importID int) (*[]MemberImportRow, error) {
+	query := "SELECT id, importid, linenumber, email, COALESCE(fullname, ''), role, status, COALESCE(message, ''), COALESCE(invitecode, '')" +
+		" FROM memberimportrows WHERE importid = $1 AND status = 'queued' ORDER BY linenumber ASC"
+	return getMemberImportRows(query, importID)
+}
+
+func getMemberImportRows(query string, importID int) (*[]MemberImportRow, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. ImportID: %d", importID), err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, importID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query member import rows. ImportID: %d", importID), err}
+	}
+	defer rows.Close()
+	importRows := []MemberImportRow{}
+	for rows.Next() {
+		var row MemberImportRow
+		err = rows.Scan(&row.ID, &row.ImportID, &row.LineNumber, &row.Email, &row.FullName, &row.Role, &row.Status, &row.Message, &row.InviteCode)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read member import row. ImportID: %d", importID), err}
+		}
+		importRows = append(importRows, row)
+	}
+	return &importRows, nil
+}
+
+/*DeleteMemberImport deletes the import of the customer whose invites are not sent yet. Returns false if there is no such import.*/
+func DeleteMemberImport(customerID, importID int) (bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, ImportID: %d", customerID, importID), err}
+	}
+	defer db.Close()
+	query := "DELETE FROM memberimports WHERE customerid = $1 AND id = $2 AND status = $3"
+	result, err := db.Exec(query, customerID, importID, ImportPreview)
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot delete member import. CustomerID: %d, ImportID: %d", customerID, importID), err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot read deleted member imports. CustomerID: %d, ImportID: %d", customerID, importID), err}
+	}
+	return affected > 0, nil
+}
+
+/*QueueMemberImport creates the invite codes of the valid rows on behalf of the inviter and queues their invitation mails. Returns the number of the queued rows, which is 0 if the import is already queued.*/
+func QueueMemberImport(importID, inviterUserID int, memo string, expiresOn *time.Time) (int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("DB connection error. ImportID: %d", importID), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("Cannot start the transaction. ImportID: %d", importID), err}
+	}
+	query := "UPDATE memberimports SET status = $2, memo = $3 WHERE id = $1 AND status = $4"
+	result, err := tran.Exec(query, importID, ImportSending, nullString(memo), ImportPreview)
+	if err != nil {
+		tran.Rollback()
+		return 0, &DBError{fmt.Sprintf("Cannot update member import. ImportID: %d", importID), err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		tran.Rollback()
+		if err != nil {
+			return 0, &DBError{fmt.Sprintf("Cannot read updated member imports. ImportID: %d", importID), err}
+		}
+		return 0, nil
+	}
+	query = "SELECT id, email, COALESCE(fullname, ''), role FROM memberimportrows WHERE importid = $1 AND status = $2 ORDER BY linenumber ASC"
+	rows, err := tran.Query(query, importID, ImportRowValid)
+	if err != nil {
+		tran.Rollback()
+		return 0, &DBError{fmt.Sprintf("Cannot query valid member import rows. ImportID: %d", importID), err}
+	}
+	validRows := []MemberImportRow{}
+	for rows.Next() {
+		var row MemberImportRow
+		err = rows.Scan(&row.ID, &row.Email, &row.FullName, &row.Role)
+		if err != nil {
+			rows.Close()
+			tran.Rollback()
+			return 0, &DBError{fmt.Sprintf("Cannot read valid member import row. ImportID: %d", importID), err}
+		}
+		validRows = append(validRows, row)
+	}
+	rows.Close()
+	now := time.Now()
+	for _, row := range validRows {
+		inviteCode := xid.New().String()
+		query = "INSERT INTO invitecodes (code, inviteruserid, invitedemail, createdon, expireson, fullname, role) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+		_, err = tran.Exec(query, inviteCode, inviterUserID, row.Email, now, nullTime(expiresOn), nullString(row.FullName), row.Role)
+		if err != nil {
+			tran.Rollback()
+			return 0, &DBError{fmt.Sprintf("Cannot create invite code. ImportID: %d, Email: %s", importID, row.Email), err}
+		}
+		query = "UPDATE memberimportrows SET status = $2, invitecode = $3 WHERE id = $1"
+		_, err = tran.Exec(query, row.ID, ImportRowQueued, inviteCode)
+		if err != nil {
+			tran.Rollback()
+			return 0, &DBError{fmt.Sprintf("Cannot queue member import row. ImportID: %d, RowID: %d", importID, row.ID), err}
+		}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return 0, &DBError{fmt.Sprintf("Cannot commit transaction. ImportID: %d", importID), err}
+	}
+	return len(validRows), nil
+}
+
+/*MarkMemberImportRowSent records that the invitation mail of the row is sent*/
+func MarkMemberImportRowSent(row *MemberImportRow) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. RowID: %d", row.ID), err}
+	}
+	defer db.Close()
+	query := "UPDATE memberimportrows SET status = $2, message = NULL WHERE id = $1"
+	_, err = db.Exec(query, row.ID, ImportRowSent)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot mark member import row as sent. RowID: %d", row.ID), err}
+	}
+	query = "UPDATE invitecodes SET lastsenton = $2 WHERE code = $1"
+	_, err = db.Exec(query, row.InviteCode, time.Now())
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot update invite code. RowID: %d, InviteCode: %s", row.ID, row.InviteCode), err}
+	}
+	return nil
+}
+
+/*MarkMemberImportRowFailed records that the invitation mail of the row could not be sent*/
+func MarkMemberImportRowFailed(rowID int, message string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. RowID: %d", rowID), err}
+	}
+	defer db.Close()
+	query := "UPDATE memberimportrows SET status = $2, message = $3 WHERE id = $1"
+	_, err = db.Exec(query, rowID, ImportRowFailed, message)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot mark member import row as failed. RowID: %d", rowID), err}
+	}
+	return nil
+}
+
+/*CompleteMemberImport marks the import as completed if none of its invitation mails waits to be sent*/
+func CompleteMemberImport(importID int) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. ImportID: %d", importID), err}
+	}
+	defer db.Close()
+	query := "UPDATE memberimports SET status = $2 WHERE id = $1 AND status = $3" +
+		" AND NOT EXISTS (SELECT 1 FROM memberimportrows WHERE importid = $1 AND status = $4)"
+	_, err = db.Exec(query, importID, ImportCompleted, ImportSending, ImportRowQueued)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot complete member import. ImportID: %d", importID), err}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*MemberSortJoined represents the member listing which shows the latest members first*/
+	MemberSortJoined = "joined"
+	/*MemberSortKarma represents the member listing which shows the members with the most karma first*/
+	MemberSortKarma = "karma"
+	/*MemberSortActivity represents the member listing which shows the recently active members first*/
+	MemberSortActivity = "activity"
+)
+
+// lastActiveSQL returns the latest time when the user signed in, posted, commented or voted. GREATEST skips the nulls.
+const lastActiveSQL = "GREATEST((SELECT MAX(usersessions.signedinon) FROM usersessions WHERE usersessions.userid = users.id)," +
+	" (SELECT MAX(stories.submittedon) FROM stories WHERE stories.userid = users.id)," +
+	" (SELECT MAX(comments.commentedon) FROM comments WHERE comments.userid = users.id)," +
+	" (SELECT MAX(storyvotes.votedon) FROM storyvotes WHERE storyvotes.userid = users.id)," +
+	" (SELECT MAX(commentvotes.votedon) FROM commentvotes WHERE commentvotes.userid = users.id))"
+
+const memberColumns = "users.id, users.username, COALESCE(users.fullname, ''), users.email, users.role, users.karma, users.registeredon, " + lastActiveSQL + " AS lastactiveon," +
+	" customers.id IS NOT NULL, EXISTS (SELECT 1 FROM signuprequests WHERE signuprequests.userid = users.id) OR EXISTS (SELECT 1 FROM emailconfirmations WHERE emailconfirmations.userid = users.id)," +
+	" ARRAY(SELECT bannedusers.kind FROM bannedusers WHERE bannedusers.userid = users.id AND " + activeBanSQL + " ORDER BY bannedusers.kind)"
+
+// memberFrom joins the customer whose owner the user is
+const memberFrom = " FROM users LEFT JOIN customers ON customers.id = users.customerid AND customers.email = users.email"
+
+const memberSearchSQL = " AND (users.username ILIKE $2 OR users.email ILIKE $2 OR users.fullname ILIKE $2)"
+
+var memberOrders = map[string]string{
+	MemberSortJoined:   " ORDER BY users.registeredon DESC, users.id DESC",
+	MemberSortKarma:    " ORDER BY users.karma DESC, users.id DESC",
+	MemberSortActivity: " ORDER BY lastactiveon DESC NULLS LAST, users.id DESC",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+/*Member represents a user of the customer as listed to the admins. LastActiveOn is nil if the user has never signed in or posted since they joined.*/
+type Member struct {
+	ID           int
+	UserName     string
+	FullName     string
+	Email        string
+	Role         string
+	Karma        int
+	RegisteredOn time.Time
+	LastActiveOn *time.Time
+	IsOwner      bool
+	IsPending    bool
+	BanKinds     []string
+}
+
+/*MemberActivity represents the number of the contributions of a member*/
+type MemberActivity struct {
+	StoryCount       int
+	CommentCount     int
+	StoryVoteCount   int
+	CommentVoteCount int
+	InvitesSent      int
+	InvitesUsed      int
+	InviteeCount     int
+}
+
+/*GetMembers returns a page of the members of the customer whose user name, email or full name contains the search text. All members are returned if the search text is empty.*/
+func GetMembers(customerID int, search, sort string, limit, offset int) (*[]Member, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	order, ok := memberOrders[sort]
+	if !ok {
+		order = memberOrders[MemberSortJoined]
+	}
+	query := "SELECT " + memberColumns + memberFrom + " WHERE users.customerid = $1"
+	args := []interface{}{customerID}
+	if search != "" {
+		query += memberSearchSQL
+		args = append(args, likePattern(search))
+	}
+	args = append(args, limit, offset)
+	query += order + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query members. CustomerID: %d, Search: %s", customerID, search), err}
+	}
+	defer rows.Close()
+	members := []Member{}
+	for rows.Next() {
+		member, err := mapSQLRowToMember(rows)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read member row. CustomerID: %d", customerID), err}
+		}
+		members = append(members, *member)
+	}
+	return &members, nil
+}
+
+/*GetMemberCount returns the number of the members of the customer whose user name, email or full name contains the search text*/
+func GetMemberCount(customerID int, search string) (int, error) {
+	query := "SELECT COUNT(*) FROM users WHERE users.customerid = $1"
+	if search == "" {
+		return count(query, customerID)
+	}
+	return count(query+memberSearchSQL, customerID, likePattern(search))
+}
+
+/*GetMember returns the member of the customer. Returns nil if the customer has no such member.*/
+func GetMember(customerID, userID int) (*Member, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + memberColumns + memberFrom + " WHERE users.customerid = $1 AND users.id = $2"
+	member, err := mapSQLRowToMember(db.QueryRow(query, customerID, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read member. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	return member, nil
+}
+
+/*GetMemberActivity counts the stories, comments, votes and invites of the member. InviteeCount counts the members who joined with their invites or invite links.*/
+func GetMemberActivity(userID int) (*MemberActivity, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT (SELECT COUNT(*) FROM stories WHERE userid = $1), (SELECT COUNT(*) FROM comments WHERE userid = $1)," +
+		" (SELECT COUNT(*) FROM storyvotes WHERE userid = $1), (SELECT COUNT(*) FROM commentvotes WHERE userid = $1)," +
+		" (SELECT COUNT(*) FROM invitecodes WHERE inviteruserid = $1), (SELECT COUNT(*) FROM invitecodes WHERE inviteruserid = $1 AND used)," +
+		" (SELECT COUNT(*) FROM users" + inviterJoins + " WHERE " + inviterIDSQL + " = $1)"
+	activity := &MemberActivity{}
+	err = db.QueryRow(query, userID).Scan(&activity.StoryCount, &activity.CommentCount, &activity.StoryVoteCount, &activity.CommentVoteCount,
+		&activity.InvitesSent, &activity.InvitesUsed, &activity.InviteeCount)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot count member activity. UserID: %d", userID), err}
+	}
+	return activity, nil
+}
+
+/*DeleteMember deletes the member of the customer with their stories, comments, votes and invites. The vote and comment counts which the member's votes and comments added and the karma which their upvotes gave are taken back. Returns false if the customer has no such member.*/
+func DeleteMember(customerID, userID int) (bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot begin transaction. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	var id int
+	err = tran.QueryRow("SELECT id FROM users WHERE id = $1 AND customerid = $2 FOR UPDATE", userID, customerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		tran.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tran.Rollback()
+		return false, &DBError{fmt.Sprintf("Cannot lock member. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	err = reverseUpvoteKarma(tran, userID)
+	if err != nil {
+		tran.Rollback()
+		return false, &DBError{fmt.Sprintf("Cannot take back upvote karma. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	statements := []struct {
+		query   string
+		args    []interface{}
+		message string
+	}{
+		{"UPDATE stories SET upvotes = stories.upvotes - votes.up, downvotes = stories.downvotes - votes.down" +
+			" FROM (SELECT storyid, COUNT(*) FILTER (WHERE votetype = $2) AS up, COUNT(*) FILTER (WHERE votetype = $3) AS down FROM storyvotes WHERE userid = $1 GROUP BY storyid) AS votes" +
+			" WHERE stories.id = votes.storyid", []interface{}{userID, enums.UpVote, enums.DownVote}, "Cannot take back story votes"},
+		{"UPDATE comments SET upvotes = comments.upvotes - votes.up, downvotes = comments.downvotes - votes.down" +
+			" FROM (SELECT commentid, COUNT(*) FILTER (WHERE votetype = $2) AS up, COUNT(*) FILTER (WHERE votetype = $3) AS down FROM commentvotes WHERE userid = $1 GROUP BY commentid) AS votes" +
+			" WHERE comments.id = votes.commentid", []interface{}{userID, enums.UpVote, enums.DownVote}, "Cannot take back comment votes"},
+		{removedCommentsSQL + " UPDATE stories SET commentcount = stories.commentcount - counts.removed" +
+			" FROM (SELECT storyid, COUNT(*) AS removed FROM removedcomments GROUP BY storyid) AS counts WHERE stories.id = counts.storyid", []interface{}{userID}, "Cannot decrease comment counts"},
+		{removedCommentsSQL + " UPDATE comments SET replycount = comments.replycount - counts.removed" +
+			" FROM (SELECT parentid, COUNT(*) AS removed FROM removedcomments WHERE parentid NOT IN (SELECT id FROM removedcomments) GROUP BY parentid) AS counts" +
+			" WHERE comments.id = counts.parentid", []interface{}{userID}, "Cannot decrease reply counts"},
+	}
+	for _, statement := range statements {
+		_, err = tran.Exec(statement.query, statement.args...)
+		if err != nil {
+			tran.Rollback()
+			return false, &DBError{fmt.Sprintf("%s. CustomerID: %d, UserID: %d", statement.message, customerID, userID), err}
+		}
+	}
+	_, err = tran.Exec("DELETE FROM resetpasswordtokens WHERE userid = $1", userID)
+	if err != nil {
+		tran.Rollback()
+		return false, &DBError{fmt.Sprintf("Cannot delete reset password tokens. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	_, err = tran.Exec("DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		tran.Rollback()
+		return false, &DBError{fmt.Sprintf("Cannot delete member. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot commit transaction. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	return true, nil
+}
+
+// reverseUpvoteKarma appends -1 karma entries for the stories and comments of the other users which the user upvoted, as RemoveStoryVote and RemoveCommentVote do for one vote
+func reverseUpvoteKarma(tran *sql.Tx, userID int) error {
+	sources := []struct {
+		source enums.KarmaSource
+		query  string
+	}{
+		{enums.StoryVoteKarma, "SELECT storyvotes.storyid FROM storyvotes INNER JOIN stories ON stories.id = storyvotes.storyid" +
+			" WHERE storyvotes.userid = $1 AND storyvotes.votetype = $2 AND stories.userid <> $1"},
+		{enums.CommentVoteKarma, "SELECT commentvotes.commentid FROM commentvotes INNER JOIN comments ON comments.id = commentvotes.commentid" +
+			" WHERE commentvotes.userid = $1 AND commentvotes.votetype = $2 AND comments.userid <> $1"},
+	}
+	for _, source := range sources {
+		rows, err := tran.Query(source.query, userID, enums.UpVote)
+		if err != nil {
+			return err
+		}
+		targetIDs := []int{}
+		for rows.Next() {
+			var targetID int
+			err = rows.Scan(&targetID)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			targetIDs = append(targetIDs, targetID)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		// the rows are read before the entries are appended since the transaction runs one statement at a time
+		for _, targetID := range targetIDs {
+			err = recordVoteKarma(tran, source.source, targetID, userID, -1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removedCommentsSQL selects the comments of the user and the replies under them which are deleted with the user
+const removedCommentsSQL = "WITH RECURSIVE removedcomments AS (SELECT id, storyid, parentid FROM comments WHERE userid = $1" +
+	" UNION SELECT comments.id, comments.storyid, comments.parentid FROM comments INNER JOIN removedcomments ON comments.parentid = removedcomments.id)"
+
+func likePattern(search string) string {
+	return "%" + likeEscaper.Replace(search) + "%"
+}
+
+func mapSQLRowToMember(row interface{ Scan(...interface{}) error }) (*Member, error) {
+	member := &Member{}
+	var lastActiveOn pq.NullTime
+	err := row.Scan(&member.ID, &member.UserName, &member.FullName, &member.Email, &member.Role, &member.Karma, &member.RegisteredOn, &lastActiveOn,
+		&member.IsOwner, &member.IsPending, pq.Array(&member.BanKinds))
+	if err != nil {
+		return nil, err
+	}
+	member.LastActiveOn = timePointer(lastActiveOn)
+	return member, nil
+}
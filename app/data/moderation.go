@@ -0,0 +1,293 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	/*UserBanPermanent represents the bans which keep the user from signing in and posting until they are lifted*/
+	UserBanPermanent = "ban"
+	/*UserBanSuspension represents the bans which keep the user from signing in and posting until they end*/
+	UserBanSuspension = "suspension"
+	/*UserBanShadow represents the bans which hide the stories and comments of the user from everyone else without telling the user*/
+	UserBanShadow = "shadowban"
+
+	/*ModerationUserBanned represents the log entries of permanent bans*/
+	ModerationUserBanned = "banned"
+	/*ModerationUserSuspended represents the log entries of suspensions*/
+	ModerationUserSuspended = "suspended"
+	/*ModerationUserShadowbanned represents the log entries of shadowbans*/
+	ModerationUserShadowbanned = "shadowbanned"
+	/*ModerationBanLifted represents the log entries of lifted user bans*/
+	ModerationBanLifted = "ban lifted"
+	/*ModerationIPBanned represents the log entries of ip address and network bans*/
+	ModerationIPBanned = "ip banned"
+	/*ModerationIPBanLifted represents the log entries of lifted ip address and network bans*/
+	ModerationIPBanLifted = "ip ban lifted"
+	/*ModerationReportsResolved represents the log entries of resolved reports*/
+	ModerationReportsResolved = "reports resolved"
+	/*ModerationContentReviewed represents the log entries of reviewed held stories and comments*/
+	ModerationContentReviewed = "content reviewed"
+	/*ModerationSignupApproved represents the log entries of approved signup requests*/
+	ModerationSignupApproved = "signup approved"
+	/*ModerationSignupRejected represents the log entries of rejected signup requests*/
+	ModerationSignupRejected = "signup rejected"
+	/*ModerationRoleChanged represents the log entries of the members whose role is changed*/
+	ModerationRoleChanged = "role changed"
+	/*ModerationPasswordResetSent represents the log entries of the reset password mails which admins sent to members*/
+	ModerationPasswordResetSent = "password reset sent"
+	/*ModerationMemberDeleted represents the log entries of deleted members*/
+	ModerationMemberDeleted = "member deleted"
+)
+
+// activeBanSQL filters the bans which have not ended yet
+const activeBanSQL = "(bannedusers.endson IS NULL OR bannedusers.endson > now())"
+
+/*UserBan represents a ban, suspension or shadowban of a user*/
+type UserBan struct {
+	UserID       int
+	UserName     string
+	CustomerID   int
+	Kind         string
+	Reason       string
+	BannedBy     int
+	BannedByName string
+	BannedOn     time.Time
+	EndsOn       *time.Time
+}
+
+/*IPBan represents a ban of an ip address or a network in CIDR notation*/
+type IPBan struct {
+	ID           int
+	CustomerID   int
+	CIDR         string
+	Reason       string
+	BannedBy     int
+	BannedByName string
+	BannedOn     time.Time
+	EndsOn       *time.Time
+}
+
+/*ModerationLogEntry represents an action of a moderator*/
+type ModerationLogEntry struct {
+	ID             int
+	CustomerID     int
+	ActorUserID    int
+	ActorUserName  string
+	Action         string
+	TargetUserID   int
+	TargetUserName string
+	Target         string
+	Details        string
+	CreatedOn      time.Time
+}
+
+/*BanUser bans, suspends or shadowbans the user. An existing ban of the same kind is replaced.*/
+func BanUser(ban *UserBan) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, UserID: %d", ban.CustomerID, ban.UserID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO bannedusers (userid, customerid, reason, bannedby, bannedon, kind, endson) VALUES ($1, $2, $3, $4, $5, $6, $7)" +
+		" ON CONFLICT (userid, kind) DO UPDATE SET reason = EXCLUDED.reason, bannedby = EXCLUDED.bannedby, bannedon = EXCLUDED.bannedon, endson = EXCLUDED.endson"
+	_, err = db.Exec(query, ban.UserID, ban.CustomerID, nullString(ban.Reason), nullInt(ban.BannedBy), ban.BannedOn, ban.Kind, nullTime(ban.EndsOn))
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot ban user. CustomerID: %d, UserID: %d, Kind: %s", ban.CustomerID, ban.UserID, ban.Kind), err}
+	}
+	return nil
+}
+
+/*LiftUserBan removes the ban of the user with given kind*/
+func LiftUserBan(userID int, kind string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "DELETE FROM bannedusers WHERE userid = $1 AND kind = $2"
+	_, err = db.Exec(query, userID, kind)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot lift user ban. UserID: %d, Kind: %s", userID, kind), err}
+	}
+	return nil
+}
+
+/*GetActiveUserBan returns the permanent ban or the suspension of the user which has not ended yet. Shadowbans are not returned since they are not told to the user. Returns nil if the user is not banned.*/
+func GetActiveUserBan(userID int) (*UserBan, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT userid, customerid, kind, COALESCE(reason, ''), bannedon, endson FROM bannedusers" +
+		" WHERE userid = $1 AND kind IN ($2, $3) AND " + activeBanSQL + " ORDER BY endson DESC NULLS FIRST LIMIT 1"
+	ban := &UserBan{}
+	var endsOn sql.NullTime
+	err = db.QueryRow(query, userID, UserBanPermanent, UserBanSuspension).Scan(&ban.UserID, &ban.CustomerID, &ban.Kind, &ban.Reason, &ban.BannedOn, &endsOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read user ban. UserID: %d", userID), err}
+	}
+	if endsOn.Valid {
+		ban.EndsOn = &endsOn.Time
+	}
+	return ban, nil
+}
+
+/*IsUserShadowbanned checks whether the user has a shadowban which has not been lifted*/
+func IsUserShadowbanned(userID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM bannedusers WHERE userid = $1 AND kind = $2 AND " + activeBanSQL
+	banCount, err := count(query, userID, UserBanShadow)
+	if err != nil {
+		return false, err
+	}
+	return banCount > 0, nil
+}
+
+/*GetUserBans returns the bans of the customer which have not ended yet, the latest first*/
+func GetUserBans(customerID int) (*[]UserBan, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT bannedusers.userid, users.username, bannedusers.customerid, bannedusers.kind, COALESCE(bannedusers.reason, ''), COALESCE(bannedusers.bannedby, 0)," +
+		" COALESCE(moderators.username, ''), bannedusers.bannedon, bannedusers.endson" +
+		" FROM bannedusers INNER JOIN users ON users.id = bannedusers.userid LEFT JOIN users moderators ON moderators.id = bannedusers.bannedby" +
+		" WHERE bannedusers.customerid = $1 AND " + activeBanSQL + " ORDER BY bannedusers.bannedon DESC"
+	rows, err := db.Query(query, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query user bans. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	bans := []UserBan{}
+	for rows.Next() {
+		var ban UserBan
+		var endsOn sql.NullTime
+		err = rows.Scan(&ban.UserID, &ban.UserName, &ban.CustomerID, &ban.Kind, &ban.Reason, &ban.BannedBy, &ban.BannedByName, &ban.BannedOn, &endsOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read user ban row. CustomerID: %d", customerID), err}
+		}
+		if endsOn.Valid {
+			ban.EndsOn = &endsOn.Time
+		}
+		bans = append(bans, ban)
+	}
+	return &bans, nil
+}
+
+/*AddIPBan bans the ip address or network of the customer. An existing ban of the same network is replaced.*/
+func AddIPBan(ban *IPBan) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, CIDR: %s", ban.CustomerID, ban.CIDR), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO ipbans (customerid, cidr, reason, bannedby, bannedon, endson) VALUES ($1, $2, $3, $4, $5, $6)" +
+		" ON CONFLICT (customerid, cidr) DO UPDATE SET reason = EXCLUDED.reason, bannedby = EXCLUDED.bannedby, bannedon = EXCLUDED.bannedon, endson = EXCLUDED.endson RETURNING id"
+	err = db.QueryRow(query, ban.CustomerID, ban.CIDR, nullString(ban.Reason), nullInt(ban.BannedBy), ban.BannedOn, nullTime(ban.EndsOn)).Scan(&ban.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot insert ip ban. CustomerID: %d, CIDR: %s", ban.CustomerID, ban.CIDR), err}
+	}
+	return nil
+}
+
+/*DeleteIPBan removes the ip ban of the customer. Returns the removed ban or nil if there is no such ban.*/
+func DeleteIPBan(customerID, id int) (*IPBan, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, IPBanID: %d", customerID, id), err}
+	}
+	defer db.Close()
+	query := "DELETE FROM ipbans WHERE customerid = $1 AND id = $2 RETURNING id, customerid, cidr"
+	ban := &IPBan{}
+	err = db.QueryRow(query, customerID, id).Scan(&ban.ID, &ban.CustomerID, &ban.CIDR)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot delete ip ban. CustomerID: %d, IPBanID: %d", customerID, id), err}
+	}
+	return ban, nil
+}
+
+/*GetIPBans returns the ip bans of the customer which have not ended yet, the latest first*/
+func GetIPBans(customerID int) (*[]IPBan, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT ipbans.id, ipbans.customerid, ipbans.cidr, COALESCE(ipbans.reason, ''), COALESCE(ipbans.bannedby, 0), COALESCE(users.username, ''), ipbans.bannedon, ipbans.endson" +
+		" FROM ipbans LEFT JOIN users ON users.id = ipbans.bannedby" +
+		" WHERE ipbans.customerid = $1 AND (ipbans.endson IS NULL OR ipbans.endson > now()) ORDER BY ipbans.bannedon DESC"
+	rows, err := db.Query(query, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query ip bans. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	bans := []IPBan{}
+	for rows.Next() {
+		var ban IPBan
+		var endsOn sql.NullTime
+		err = rows.Scan(&ban.ID, &ban.CustomerID, &ban.CIDR, &ban.Reason, &ban.BannedBy, &ban.BannedByName, &ban.BannedOn, &endsOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read ip ban row. CustomerID: %d", customerID), err}
+		}
+		if endsOn.Valid {
+			ban.EndsOn = &endsOn.Time
+		}
+		bans = append(bans, ban)
+	}
+	return &bans, nil
+}
+
+/*AddModerationLogEntry records the action of a moderator*/
+func AddModerationLogEntry(entry *ModerationLogEntry) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Action: %s", entry.CustomerID, entry.Action), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO moderationlog (customerid, actoruserid, action, targetuserid, target, details, createdon) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	_, err = db.Exec(query, entry.CustomerID, nullInt(entry.ActorUserID), entry.Action, nullInt(entry.TargetUserID),
+		nullString(entry.Target), nullString(entry.Details), entry.CreatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot insert moderation log entry. CustomerID: %d, Action: %s", entry.CustomerID, entry.Action), err}
+	}
+	return nil
+}
+
+/*GetModerationLog returns the latest moderation log entries of the customer*/
+func GetModerationLog(customerID, limit int) (*[]ModerationLogEntry, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT moderationlog.id, moderationlog.customerid, COALESCE(moderationlog.actoruserid, 0), COALESCE(actors.username, ''), moderationlog.action," +
+		" COALESCE(moderationlog.targetuserid, 0), COALESCE(targets.username, ''), COALESCE(moderationlog.target, ''), COALESCE(moderationlog.details, ''), moderationlog.createdon" +
+		" FROM moderationlog LEFT JOIN users actors ON actors.id = moderationlog.actoruserid LEFT JOIN users targets ON targets.id = moderationlog.targetuserid" +
+		" WHERE moderationlog.customerid = $1 ORDER BY moderationlog.createdon DESC, moderationlog.id DESC LIMIT $2"
+	rows, err := db.Query(query, customerID, limit)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query moderation log. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	entries := []ModerationLogEntry{}
+	for rows.Next() {
+		var entry ModerationLogEntry
+		err = rows.Scan(&entry.ID, &entry.CustomerID, &entry.ActorUserID, &entry.ActorUserName, &entry.Action,
+			&entry.TargetUserID, &entry.TargetUserName, &entry.Target, &entry.Details, &entry.CreatedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read moderation log row. CustomerID: %d", customerID), err}
+		}
+		entries = append(entries, entry)
+	}
+	return &entries, nil
+}
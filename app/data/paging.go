@@ -0,0 +1,85 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+/*StoryKey represents the position of a story in a listing which is ordered by a time, newest first. Stories with the same time are ordered by id.*/
+type StoryKey struct {
+	Time time.Time
+	ID   int
+}
+
+/*Keyset represents a page request of a keyset paginated listing. Stories older than After are listed if it is set, stories newer than Before are listed if it is set, the newest stories otherwise.*/
+type Keyset struct {
+	After  *StoryKey
+	Before *StoryKey
+	Limit  int
+}
+
+/*KeysetPage represents a page of a keyset paginated listing. Keys are in the same order with the stories.*/
+type KeysetPage struct {
+	Stories     []Story
+	Keys        []StoryKey
+	HasNext     bool
+	HasPrevious bool
+}
+
+// queryStoryKeyset appends the keyset condition, order and limit to the query which selects story columns, user name and the key column. Query must end with a WHERE clause.
+func queryStoryKeyset(query, keyColumn string, keyset *Keyset, args ...interface{}) (*KeysetPage, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{"DB connection error.", err}
+	}
+	defer db.Close()
+
+	backward := keyset.Before != nil
+	order := "DESC"
+	if keyset.After != nil {
+		args = append(args, keyset.After.Time, keyset.After.ID)
+		query += fmt.Sprintf(" AND (%s, stories.id) < ($%d, $%d)", keyColumn, len(args)-1, len(args))
+	} else if backward {
+		args = append(args, keyset.Before.Time, keyset.Before.ID)
+		query += fmt.Sprintf(" AND (%s, stories.id) > ($%d, $%d)", keyColumn, len(args)-1, len(args))
+		order = "ASC"
+	}
+	// One more story is read to find out whether there is a page after this one
+	args = append(args, keyset.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s %s, stories.id %s LIMIT $%d", keyColumn, order, order, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query stories by keyset. KeyColumn: %s", keyColumn), err}
+	}
+	defer rows.Close()
+	page := &KeysetPage{Stories: []Story{}, Keys: []StoryKey{}}
+	for rows.Next() {
+		var story Story
+		var key StoryKey
+		err = scanStory(rows, &story, &key.Time)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = story.ID
+		page.Stories = append(page.Stories, story)
+		page.Keys = append(page.Keys, key)
+	}
+	hasMore := len(page.Stories) > keyset.Limit
+	if hasMore {
+		page.Stories = page.Stories[:keyset.Limit]
+		page.Keys = page.Keys[:keyset.Limit]
+	}
+	if backward {
+		for i, j := 0, len(page.Stories)-1; i < j; i, j = i+1, j-1 {
+			page.Stories[i], page.Stories[j] = page.Stories[j], page.Stories[i]
+			page.Keys[i], page.Keys[j] = page.Keys[j], page.Keys[i]
+		}
+		page.HasPrevious = hasMore
+		page.HasNext = true
+		return page, nil
+	}
+	page.HasNext = hasMore
+	page.HasPrevious = keyset.After != nil
+	return page, nil
+}
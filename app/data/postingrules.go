@@ -0,0 +1,98 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+/*PostingRules represents the probation of new members of the customer and the limits of how often users can post. 0 disables a rule.*/
+type PostingRules struct {
+	CustomerID        int
+	ProbationDays     int
+	ProbationKarma    int
+	RestrictedDomains []string
+	StoriesPerHour    int
+	StoriesPerDay     int
+	CommentsPerHour   int
+	CommentsPerDay    int
+	KarmaPerExtraPost int
+	UpdatedOn         time.Time
+}
+
+/*GetPostingRules returns the posting rules of the customer. Returns the rules without restrictions if customer has not set them yet.*/
+func GetPostingRules(customerID int) (*PostingRules, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, probationdays, probationkarma, restricteddomains, storiesperhour, storiesperday, commentsperhour, commentsperday, karmaperextrapost, updatedon" +
+		" FROM postingrules WHERE customerid = $1"
+	rules := &PostingRules{}
+	err = db.QueryRow(query, customerID).Scan(&rules.CustomerID, &rules.ProbationDays, &rules.ProbationKarma, pq.Array(&rules.RestrictedDomains),
+		&rules.StoriesPerHour, &rules.StoriesPerDay, &rules.CommentsPerHour, &rules.CommentsPerDay, &rules.KarmaPerExtraPost, &rules.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &PostingRules{CustomerID: customerID, RestrictedDomains: []string{}}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read posting rules. CustomerID: %d", customerID), err}
+	}
+	return rules, nil
+}
+
+/*SavePostingRules inserts or updates the posting rules of the customer*/
+func SavePostingRules(rules *PostingRules) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", rules.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO postingrules (customerid, probationdays, probationkarma, restricteddomains, storiesperhour, storiesperday, commentsperhour, commentsperday, karmaperextrapost, updatedon)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (customerid) DO UPDATE SET probationdays = EXCLUDED.probationdays, probationkarma = EXCLUDED.probationkarma," +
+		" restricteddomains = EXCLUDED.restricteddomains, storiesperhour = EXCLUDED.storiesperhour, storiesperday = EXCLUDED.storiesperday," +
+		" commentsperhour = EXCLUDED.commentsperhour, commentsperday = EXCLUDED.commentsperday, karmaperextrapost = EXCLUDED.karmaperextrapost, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, rules.CustomerID, rules.ProbationDays, rules.ProbationKarma, pq.Array(rules.RestrictedDomains),
+		rules.StoriesPerHour, rules.StoriesPerDay, rules.CommentsPerHour, rules.CommentsPerDay, rules.KarmaPerExtraPost, rules.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save posting rules. CustomerID: %d", rules.CustomerID), err}
+	}
+	return nil
+}
+
+/*GetUserStoryTimesSince returns the submission times of the stories of the user since given time, the oldest first*/
+func GetUserStoryTimesSince(userID int, since time.Time) ([]time.Time, error) {
+	query := "SELECT submittedon FROM stories WHERE userid = $1 AND submittedon >= $2 ORDER BY submittedon ASC"
+	return getPostTimes(query, userID, since)
+}
+
+/*GetUserCommentTimesSince returns the times of the comments of the user since given time, the oldest first*/
+func GetUserCommentTimesSince(userID int, since time.Time) ([]time.Time, error) {
+	query := "SELECT commentedon FROM comments WHERE userid = $1 AND commentedon >= $2 ORDER BY commentedon ASC"
+	return getPostTimes(query, userID, since)
+}
+
+func getPostTimes(query string, userID int, since time.Time) ([]time.Time, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, userID, since)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query post times. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	times := []time.Time{}
+	for rows.Next() {
+		var postedOn time.Time
+		err = rows.Scan(&postedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read post time row. UserID: %d", userID), err}
+		}
+		times = append(times, postedOn)
+	}
+	return times, nil
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*DefaultGravity represents the exponent applied to the age of a story by gravity ranking*/
+	DefaultGravity = DefaultTimeExponent
+	/*DefaultCommentPenalty represents the comment count under which stories are boosted by gravity ranking*/
+	DefaultCommentPenalty = 40
+)
+
+/*RankingSettings represents the ranking algorithm which is selected by the customer and its parameters*/
+type RankingSettings struct {
+	CustomerID     int
+	Algorithm      enums.RankingAlgorithm
+	Gravity        float64
+	CommentPenalty int
+	UpdatedOn      time.Time
+}
+
+/*GetRankingSettings returns the ranking settings of the customer. Returns the default settings if the customer has not saved any.*/
+func GetRankingSettings(customerID int) (*RankingSettings, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, algorithm, gravity, commentpenalty, updatedon FROM rankingsettings WHERE customerid = $1"
+	var settings RankingSettings
+	err = db.QueryRow(query, customerID).Scan(
+		&settings.CustomerID,
+		&settings.Algorithm,
+		&settings.Gravity,
+		&settings.CommentPenalty,
+		&settings.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &RankingSettings{
+				CustomerID:     customerID,
+				Algorithm:      enums.GravityRanking,
+				Gravity:        DefaultGravity,
+				CommentPenalty: DefaultCommentPenalty,
+			}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read ranking settings. CustomerID: %d", customerID), err}
+	}
+	return &settings, nil
+}
+
+/*SaveRankingSettings creates or updates the ranking settings of the customer*/
+func SaveRankingSettings(settings *RankingSettings) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", settings.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO rankingsettings (customerid, algorithm, gravity, commentpenalty, updatedon) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (customerid) DO UPDATE SET algorithm = EXCLUDED.algorithm, gravity = EXCLUDED.gravity, commentpenalty = EXCLUDED.commentpenalty, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, settings.CustomerID, settings.Algorithm, settings.Gravity, settings.CommentPenalty, settings.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save ranking settings. CustomerID: %d", settings.CustomerID), err}
+	}
+	return nil
+}
+
+/*GetStoriesSubmittedSince returns the stories of the customer which are submitted after given time to recalculate their ranks*/
+func GetStoriesSubmittedSince(customerID int, since time.Time) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + storyColumns + ", users.username FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND stories.submittedon >= $2"
+	rows, err := db.Query(query, customerID, since)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query stories to rank. CustomerID: %d", customerID), err}
+	}
+	stories, err := MapSQLRowsToRecentStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. CustomerID: %d", customerID), err}
+	}
+	return stories, nil
+}
+
+/*UpdateStoryRanks saves the precalculated ranks of the stories. Keys of the map are story ids.*/
+func UpdateStoryRanks(ranks map[int]float64) error {
+	if len(ranks) == 0 {
+		return nil
+	}
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{"DB connection error.", err}
+	}
+	defer db.Close()
+	ids := make([]int64, 0, len(ranks))
+	values := make([]float64, 0, len(ranks))
+	for id, rank := range ranks {
+		ids = append(ids, int64(id))
+		values = append(values, rank)
+	}
+	query := "UPDATE stories SET rank = ranks.rank FROM (SELECT UNNEST($1::integer[]) AS id, UNNEST($2::double precision[]) AS rank) ranks WHERE stories.id = ranks.id"
+	_, err = db.Exec(query, pq.Array(ids), pq.Array(values))
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot update story ranks. Count: %d", len(ranks)), err}
+	}
+	return nil
+}
+
+/*GetRankedStoryIDs returns the ids of the customer's stories with the highest ranks ordered by rank*/
+func GetRankedStoryIDs(customerID, count int) ([]int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT stories.id FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " ORDER BY stories.rank DESC LIMIT $2"
+	rows, err := db.Query(query, customerID, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query ranked story ids. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read ranked story id. CustomerID: %d", customerID), err}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
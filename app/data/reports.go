@@ -0,0 +1,290 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*ReportTargetStory represents the reports of stories*/
+	ReportTargetStory = "story"
+	/*ReportTargetComment represents the reports of comments*/
+	ReportTargetComment = "comment"
+
+	/*ReportOpen represents the reports which are waiting for a moderator decision*/
+	ReportOpen = "open"
+	/*ReportDismissed represents the reports whose target is found acceptable*/
+	ReportDismissed = "dismissed"
+	/*ReportRemoved represents the reports whose target is removed*/
+	ReportRemoved = "removed"
+	/*ReportAuthorBanned represents the reports whose target is removed and whose author is banned*/
+	ReportAuthorBanned = "banned"
+
+	/*DefaultReportHideThreshold represents the number of reports which hide a story or comment until a moderator decides. 0 disables hiding.*/
+	DefaultReportHideThreshold = 5
+)
+
+/*DefaultReportReasons represents the reasons users can report with if the customer has not set them yet*/
+var DefaultReportReasons = []string{"spam", "abusive", "off-topic"}
+
+/*ReportSettings represents the reasons users of the customer can report stories and comments with and the number of reports which hide them*/
+type ReportSettings struct {
+	CustomerID    int
+	Reasons       []string
+	HideThreshold int
+	UpdatedOn     time.Time
+}
+
+/*Report represents a report of a user telling the moderators that a story or comment breaks the rules*/
+type Report struct {
+	ID         int
+	CustomerID int
+	TargetType string
+	TargetID   int
+	StoryID    int
+	UserID     int
+	Reason     string
+	ReportedOn time.Time
+	Status     string
+}
+
+/*ReportTarget represents the story or comment which is reported. Held is true if screening holds or rejected it.*/
+type ReportTarget struct {
+	Type       string
+	ID         int
+	StoryID    int
+	StoryTitle string
+	Text       string
+	AuthorID   int
+	AuthorName string
+	Hidden     bool
+	Held       bool
+}
+
+/*ReportReasonCount represents the number of open reports of a target with a reason*/
+type ReportReasonCount struct {
+	Reason string
+	Count  int
+}
+
+/*ReportQueueItem represents the open reports of a story or comment grouped together*/
+type ReportQueueItem struct {
+	Target          ReportTarget
+	ReportCount     int
+	Reasons         []ReportReasonCount
+	FirstReportedOn time.Time
+	LastReportedOn  time.Time
+}
+
+/*Reporter represents the user who reported a story or comment*/
+type Reporter struct {
+	UserID   int
+	UserName string
+	Email    string
+}
+
+/*GetReportSettings returns the report settings of the customer. Returns the default settings if customer has not set them yet.*/
+func GetReportSettings(customerID int) (*ReportSettings, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, reasons, hidethreshold, updatedon FROM reportsettings WHERE customerid = $1"
+	settings := &ReportSettings{}
+	err = db.QueryRow(query, customerID).Scan(&settings.CustomerID, pq.Array(&settings.Reasons), &settings.HideThreshold, &settings.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ReportSettings{
+				CustomerID:    customerID,
+				Reasons:       DefaultReportReasons,
+				HideThreshold: DefaultReportHideThreshold,
+			}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read report settings. CustomerID: %d", customerID), err}
+	}
+	return settings, nil
+}
+
+/*SaveReportSettings creates or updates the report settings of the customer*/
+func SaveReportSettings(settings *ReportSettings) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", settings.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO reportsettings (customerid, reasons, hidethreshold, updatedon) VALUES ($1, $2, $3, $4) ON CONFLICT (customerid) DO UPDATE SET reasons = EXCLUDED.reasons, hidethreshold = EXCLUDED.hidethreshold, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, settings.CustomerID, pq.Array(settings.Reasons), settings.HideThreshold, settings.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save report settings. CustomerID: %d", settings.CustomerID), err}
+	}
+	return nil
+}
+
+/*GetReportTarget returns the story or comment of the customer which is reported. Returns nil if there is no such story or comment.*/
+func GetReportTarget(customerID int, targetType string, targetID int) (*ReportTarget, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, TargetType: %s, TargetID: %d", customerID, targetType, targetID), err}
+	}
+	defer db.Close()
+	var query string
+	switch targetType {
+	case ReportTargetStory:
+		query = "SELECT stories.id, stories.id, stories.title, COALESCE(stories.text, ''), users.id, users.username, stories.hidden, " + screenedHiddenSQL +
+			" FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.id = $1 AND users.customerid = $2"
+	case ReportTargetComment:
+		query = "SELECT comments.id, stories.id, stories.title, comments.comment, users.id, users.username, comments.hidden, " + screenedHiddenSQL +
+			" FROM comments INNER JOIN stories ON stories.id = comments.storyid INNER JOIN users ON users.id = comments.userid WHERE comments.id = $1 AND users.customerid = $2"
+	default:
+		return nil, nil
+	}
+	target := &ReportTarget{Type: targetType}
+	err = db.QueryRow(query, targetID, customerID, targetType, HeldContentApproved).Scan(&target.ID, &target.StoryID, &target.StoryTitle, &target.Text, &target.AuthorID, &target.AuthorName,
+		&target.Hidden, &target.Held)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read report target. CustomerID: %d, TargetType: %s, TargetID: %d", customerID, targetType, targetID), err}
+	}
+	return target, nil
+}
+
+// screenedHiddenSQL checks whether screening holds the target or rejected it, so it is hidden regardless of its reports
+const screenedHiddenSQL = "EXISTS (SELECT 1 FROM heldcontent WHERE heldcontent.targettype = $3 AND heldcontent.targetid = $1 AND heldcontent.status <> $4)"
+
+/*AddReport saves the report unless the user has already reported the target. Returns whether the report is saved and the number of open reports of the target.*/
+func AddReport(report *Report) (bool, int, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return false, 0, &DBError{fmt.Sprintf("DB connection error. UserID: %d, TargetType: %s, TargetID: %d", report.UserID, report.TargetType, report.TargetID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO reports (customerid, targettype, targetid, storyid, userid, reason, reportedon, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (targettype, targetid, userid) DO NOTHING"
+	result, err := db.Exec(query, report.CustomerID, report.TargetType, report.TargetID, report.StoryID, report.UserID, report.Reason, report.ReportedOn, ReportOpen)
+	if err != nil {
+		return false, 0, &DBError{fmt.Sprintf("Cannot insert report. UserID: %d, TargetType: %s, TargetID: %d", report.UserID, report.TargetType, report.TargetID), err}
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, 0, &DBError{fmt.Sprintf("Cannot read inserted report count. UserID: %d, TargetType: %s, TargetID: %d", report.UserID, report.TargetType, report.TargetID), err}
+	}
+	query = "SELECT COUNT(*) FROM reports WHERE targettype = $1 AND targetid = $2 AND status = $3"
+	openCount, err := count(query, report.TargetType, report.TargetID, ReportOpen)
+	if err != nil {
+		return false, 0, err
+	}
+	return inserted > 0, openCount, nil
+}
+
+/*GetUserReportCountSince returns the number of reports the user made since given time*/
+func GetUserReportCountSince(userID int, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM reports WHERE userid = $1 AND reportedon >= $2"
+	return count(query, userID, since)
+}
+
+/*SetReportTargetHidden hides or shows the story or comment in listings and pages*/
+func SetReportTargetHidden(targetType string, targetID int, hidden bool) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. TargetType: %s, TargetID: %d", targetType, targetID), err}
+	}
+	defer db.Close()
+	query := "UPDATE stories SET hidden = $1 WHERE id = $2"
+	if targetType == ReportTargetComment {
+		query = "UPDATE comments SET hidden = $1 WHERE id = $2"
+	}
+	_, err = db.Exec(query, hidden, targetID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot update hidden state. TargetType: %s, TargetID: %d, Hidden: %t", targetType, targetID, hidden), err}
+	}
+	return nil
+}
+
+/*GetReportQueue returns the open reports of the customer grouped by their targets. Targets with more reports come first.*/
+func GetReportQueue(customerID, count int) (*[]ReportQueueItem, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT reports.targettype, reports.targetid, stories.id, stories.title, COALESCE(comments.comment, stories.text, ''), authors.id, authors.username," +
+		" CASE WHEN reports.targettype = 'comment' THEN comments.hidden ELSE stories.hidden END," +
+		" COUNT(*), array_agg(reports.reason), MIN(reports.reportedon), MAX(reports.reportedon)" +
+		" FROM reports INNER JOIN stories ON stories.id = reports.storyid" +
+		" LEFT JOIN comments ON reports.targettype = 'comment' AND comments.id = reports.targetid" +
+		" INNER JOIN users authors ON authors.id = COALESCE(comments.userid, stories.userid)" +
+		" WHERE reports.customerid = $1 AND reports.status = $2" +
+		" GROUP BY reports.targettype, reports.targetid, stories.id, comments.id, authors.id" +
+		" ORDER BY COUNT(*) DESC, MAX(reports.reportedon) DESC LIMIT $3"
+	rows, err := db.Query(query, customerID, ReportOpen, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query report queue. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	items := []ReportQueueItem{}
+	for rows.Next() {
+		var item ReportQueueItem
+		var reasons []string
+		target := &item.Target
+		err = rows.Scan(&target.Type, &target.ID, &target.StoryID, &target.StoryTitle, &target.Text, &target.AuthorID, &target.AuthorName, &target.Hidden,
+			&item.ReportCount, pq.Array(&reasons), &item.FirstReportedOn, &item.LastReportedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read report queue row. CustomerID: %d", customerID), err}
+		}
+		item.Reasons = countReportReasons(reasons)
+		items = append(items, item)
+	}
+	return &items, nil
+}
+
+// countReportReasons returns the number of reports per reason, the most reported reason first
+func countReportReasons(reasons []string) []ReportReasonCount {
+	counts := map[string]int{}
+	for _, reason := range reasons {
+		counts[reason]++
+	}
+	reasonCounts := []ReportReasonCount{}
+	for reason, count := range counts {
+		reasonCounts = append(reasonCounts, ReportReasonCount{reason, count})
+	}
+	sort.Slice(reasonCounts, func(i, j int) bool {
+		if reasonCounts[i].Count != reasonCounts[j].Count {
+			return reasonCounts[i].Count > reasonCounts[j].Count
+		}
+		return reasonCounts[i].Reason < reasonCounts[j].Reason
+	})
+	return reasonCounts
+}
+
+/*ResolveReports closes the open reports of the target with the status. Returns the users who reported the target.*/
+func ResolveReports(customerID int, targetType string, targetID int, status string, resolvedBy int) (*[]Reporter, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, TargetType: %s, TargetID: %d", customerID, targetType, targetID), err}
+	}
+	defer db.Close()
+	query := "WITH resolved AS (UPDATE reports SET status = $4, resolvedon = $5, resolvedby = $6" +
+		" WHERE customerid = $1 AND targettype = $2 AND targetid = $3 AND status = $7 RETURNING userid)" +
+		" SELECT users.id, users.username, users.email FROM resolved INNER JOIN users ON users.id = resolved.userid"
+	rows, err := db.Query(query, customerID, targetType, targetID, status, time.Now(), resolvedBy, ReportOpen)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot resolve reports. CustomerID: %d, TargetType: %s, TargetID: %d", customerID, targetType, targetID), err}
+	}
+	defer rows.Close()
+	reporters := []Reporter{}
+	for rows.Next() {
+		var reporter Reporter
+		err = rows.Scan(&reporter.UserID, &reporter.UserName, &reporter.Email)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read reporter row. CustomerID: %d, TargetType: %s, TargetID: %d", customerID, targetType, targetID), err}
+		}
+		reporters = append(reporters, reporter)
+	}
+	return &reporters, nil
+}
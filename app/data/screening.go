@@ -0,0 +1,348 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*HeldContentHeld represents the stories and comments which are waiting for a moderator decision*/
+	HeldContentHeld = "held"
+	/*HeldContentApproved represents the held stories and comments which are published by a moderator*/
+	HeldContentApproved = "approved"
+	/*HeldContentRejected represents the held stories and comments which are found to be spam by a moderator*/
+	HeldContentRejected = "rejected"
+
+	/*DefaultNewAccountDays represents the age in days which accounts are screened by new account heuristics until*/
+	DefaultNewAccountDays = 3
+	/*DefaultMaxNewAccountLinks represents the number of links a new account can post in a story or comment without being held*/
+	DefaultMaxNewAccountLinks = 2
+	/*DefaultMinReputation represents the domain reputation which links to lower reputation domains are held below*/
+	DefaultMinReputation = 0.3
+	/*DefaultSpamThreshold represents the spam probability which stories and comments are held above*/
+	DefaultSpamThreshold = 0.9
+)
+
+// urlDomainSQL extracts the lowercased host of a story url without www prefix, the same as shared.URLDomain does
+const urlDomainSQL = "lower(substring(stories.url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:www\\.)?([^/:?#]+)'))"
+
+/*ScreeningSettings represents the rules stories and comments of the customer are screened by before they are published*/
+type ScreeningSettings struct {
+	CustomerID         int
+	BannedDomains      []string
+	BannedPhrases      []string
+	NewAccountDays     int
+	MaxNewAccountLinks int
+	MinReputation      float64
+	SpamThreshold      float64
+	UpdatedOn          time.Time
+}
+
+/*HeldContent represents a story or comment which is held by screening until a moderator approves or rejects it*/
+type HeldContent struct {
+	ID         int
+	CustomerID int
+	TargetType string
+	TargetID   int
+	StoryID    int
+	StoryTitle string
+	Text       string
+	UserID     int
+	UserName   string
+	Domain     string
+	Reasons    []string
+	SpamScore  float64
+	HeldOn     time.Time
+	Status     string
+	ReviewedOn time.Time
+	ReviewedBy int
+}
+
+/*DomainReputation represents the moderation outcomes of the stories linking to a domain*/
+type DomainReputation struct {
+	Domain    string
+	Published int
+	Removed   int
+}
+
+/*SpamClassifier represents the number of documents the spam classifier of the customer is trained with*/
+type SpamClassifier struct {
+	CustomerID    int
+	SpamDocuments int
+	HamDocuments  int
+}
+
+/*SpamTokenCount represents the number of spam and ham documents a token is seen in*/
+type SpamTokenCount struct {
+	SpamCount int
+	HamCount  int
+}
+
+/*GetScreeningSettings returns the screening settings of the customer. Returns the default settings if customer has not set them yet.*/
+func GetScreeningSettings(customerID int) (*ScreeningSettings, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, banneddomains, bannedphrases, newaccountdays, maxnewaccountlinks, minreputation, spamthreshold, updatedon FROM screeningsettings WHERE customerid = $1"
+	settings := &ScreeningSettings{}
+	err = db.QueryRow(query, customerID).Scan(&settings.CustomerID, pq.Array(&settings.BannedDomains), pq.Array(&settings.BannedPhrases),
+		&settings.NewAccountDays, &settings.MaxNewAccountLinks, &settings.MinReputation, &settings.SpamThreshold, &settings.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ScreeningSettings{
+				CustomerID:         customerID,
+				BannedDomains:      []string{},
+				BannedPhrases:      []string{},
+				NewAccountDays:     DefaultNewAccountDays,
+				MaxNewAccountLinks: DefaultMaxNewAccountLinks,
+				MinReputation:      DefaultMinReputation,
+				SpamThreshold:      DefaultSpamThreshold,
+			}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read screening settings. CustomerID: %d", customerID), err}
+	}
+	return settings, nil
+}
+
+/*SaveScreeningSettings creates or updates the screening settings of the customer*/
+func SaveScreeningSettings(settings *ScreeningSettings) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", settings.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO screeningsettings (customerid, banneddomains, bannedphrases, newaccountdays, maxnewaccountlinks, minreputation, spamthreshold, updatedon)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (customerid) DO UPDATE SET banneddomains = EXCLUDED.banneddomains, bannedphrases = EXCLUDED.bannedphrases," +
+		" newaccountdays = EXCLUDED.newaccountdays, maxnewaccountlinks = EXCLUDED.maxnewaccountlinks, minreputation = EXCLUDED.minreputation," +
+		" spamthreshold = EXCLUDED.spamthreshold, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, settings.CustomerID, pq.Array(settings.BannedDomains), pq.Array(settings.BannedPhrases),
+		settings.NewAccountDays, settings.MaxNewAccountLinks, settings.MinReputation, settings.SpamThreshold, settings.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save screening settings. CustomerID: %d", settings.CustomerID), err}
+	}
+	return nil
+}
+
+/*GetDomainReputation returns the number of published stories linking to the domain and the number of them which are removed by moderators or rejected by screening review*/
+func GetDomainReputation(customerID int, domain string) (*DomainReputation, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Domain: %s", customerID, domain), err}
+	}
+	defer db.Close()
+	query := "SELECT" +
+		" (SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND NOT stories.hidden AND " + urlDomainSQL + " = $2)," +
+		" (SELECT COUNT(*) FROM heldcontent WHERE customerid = $1 AND domain = $2 AND status = $3) +" +
+		" (SELECT COUNT(DISTINCT stories.id) FROM reports INNER JOIN stories ON stories.id = reports.targetid" +
+		" WHERE reports.customerid = $1 AND reports.targettype = $4 AND reports.status IN ($5, $6) AND " + urlDomainSQL + " = $2)"
+	reputation := &DomainReputation{Domain: domain}
+	err = db.QueryRow(query, customerID, domain, HeldContentRejected, ReportTargetStory, ReportRemoved, ReportAuthorBanned).Scan(&reputation.Published, &reputation.Removed)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read domain reputation. CustomerID: %d, Domain: %s", customerID, domain), err}
+	}
+	return reputation, nil
+}
+
+/*CountUserDuplicateContent returns the number of stories and comments of the user since given time whose text is the same as given text*/
+func CountUserDuplicateContent(userID int, text string, since time.Time) (int, error) {
+	query := "SELECT (SELECT COUNT(*) FROM stories WHERE userid = $1 AND text = $2 AND submittedon >= $3) +" +
+		" (SELECT COUNT(*) FROM comments WHERE userid = $1 AND comment = $2 AND commentedon >= $3)"
+	return count(query, userID, text, since)
+}
+
+/*CountUserDomainStories returns the number of stories the user submitted since given time linking to the domain*/
+func CountUserDomainStories(userID int, domain string, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM stories WHERE userid = $1 AND submittedon >= $2 AND " + urlDomainSQL + " = $3"
+	return count(query, userID, since, domain)
+}
+
+/*AddHeldContent adds the story or comment to the screening queue of the customer*/
+func AddHeldContent(item *HeldContent) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. TargetType: %s, TargetID: %d", item.TargetType, item.TargetID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO heldcontent (customerid, targettype, targetid, storyid, userid, domain, reasons, spamscore, heldon, status)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id"
+	err = db.QueryRow(query, item.CustomerID, item.TargetType, item.TargetID, item.StoryID, item.UserID, nullString(item.Domain),
+		pq.Array(item.Reasons), item.SpamScore, item.HeldOn, HeldContentHeld).Scan(&item.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot insert held content. TargetType: %s, TargetID: %d", item.TargetType, item.TargetID), err}
+	}
+	return nil
+}
+
+const heldContentColumns = "heldcontent.id, heldcontent.customerid, heldcontent.targettype, heldcontent.targetid, heldcontent.storyid, stories.title," +
+	" COALESCE(comments.comment, stories.text, ''), heldcontent.userid, users.username, COALESCE(heldcontent.domain, ''), heldcontent.reasons," +
+	" heldcontent.spamscore, heldcontent.heldon, heldcontent.status, heldcontent.reviewedon, heldcontent.reviewedby"
+
+const heldContentJoins = " FROM heldcontent INNER JOIN stories ON stories.id = heldcontent.storyid" +
+	" LEFT JOIN comments ON heldcontent.targettype = 'comment' AND comments.id = heldcontent.targetid" +
+	" INNER JOIN users ON users.id = heldcontent.userid"
+
+/*GetHeldContents returns the latest stories and comments of the customer in the screening queue with given status*/
+func GetHeldContents(customerID int, status string, count int) (*[]HeldContent, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Status: %s", customerID, status), err}
+	}
+	defer db.Close()
+	query := "SELECT " + heldContentColumns + heldContentJoins +
+		" WHERE heldcontent.customerid = $1 AND heldcontent.status = $2 ORDER BY heldcontent.heldon DESC LIMIT $3"
+	rows, err := db.Query(query, customerID, status, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query held contents. CustomerID: %d, Status: %s", customerID, status), err}
+	}
+	defer rows.Close()
+	items := []HeldContent{}
+	for rows.Next() {
+		item, err := scanHeldContent(rows)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read held content row. CustomerID: %d, Status: %s", customerID, status), err}
+		}
+		items = append(items, *item)
+	}
+	return &items, nil
+}
+
+/*GetHeldContentByID returns the held story or comment of the customer. Returns nil if there is no such item.*/
+func GetHeldContentByID(customerID, id int) (*HeldContent, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, HeldContentID: %d", customerID, id), err}
+	}
+	defer db.Close()
+	query := "SELECT " + heldContentColumns + heldContentJoins + " WHERE heldcontent.customerid = $1 AND heldcontent.id = $2"
+	item, err := scanHeldContent(db.QueryRow(query, customerID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read held content. CustomerID: %d, HeldContentID: %d", customerID, id), err}
+	}
+	return item, nil
+}
+
+func scanHeldContent(row interface{ Scan(...interface{}) error }) (*HeldContent, error) {
+	item := &HeldContent{}
+	var reviewedOn sql.NullTime
+	var reviewedBy sql.NullInt32
+	err := row.Scan(&item.ID, &item.CustomerID, &item.TargetType, &item.TargetID, &item.StoryID, &item.StoryTitle, &item.Text,
+		&item.UserID, &item.UserName, &item.Domain, pq.Array(&item.Reasons), &item.SpamScore, &item.HeldOn, &item.Status, &reviewedOn, &reviewedBy)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedOn.Valid {
+		item.ReviewedOn = reviewedOn.Time
+	}
+	if reviewedBy.Valid {
+		item.ReviewedBy = int(reviewedBy.Int32)
+	}
+	return item, nil
+}
+
+/*ReviewHeldContent records the decision of the moderator on the held story or comment*/
+func ReviewHeldContent(id int, status string, reviewedBy int) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. HeldContentID: %d", id), err}
+	}
+	defer db.Close()
+	query := "UPDATE heldcontent SET status = $1, reviewedon = $2, reviewedby = $3 WHERE id = $4"
+	_, err = db.Exec(query, status, time.Now(), reviewedBy, id)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot review held content. HeldContentID: %d, Status: %s", id, status), err}
+	}
+	return nil
+}
+
+/*GetSpamClassifier returns the number of documents the spam classifier of the customer is trained with*/
+func GetSpamClassifier(customerID int) (*SpamClassifier, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, spamdocuments, hamdocuments FROM spamclassifiers WHERE customerid = $1"
+	classifier := &SpamClassifier{}
+	err = db.QueryRow(query, customerID).Scan(&classifier.CustomerID, &classifier.SpamDocuments, &classifier.HamDocuments)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &SpamClassifier{CustomerID: customerID}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read spam classifier. CustomerID: %d", customerID), err}
+	}
+	return classifier, nil
+}
+
+/*GetSpamTokenCounts returns the spam and ham counts of the tokens which the classifier of the customer has seen*/
+func GetSpamTokenCounts(customerID int, tokens []string) (map[string]SpamTokenCount, error) {
+	counts := map[string]SpamTokenCount{}
+	if len(tokens) == 0 {
+		return counts, nil
+	}
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT token, spamcount, hamcount FROM spamtokens WHERE customerid = $1 AND token = ANY($2)"
+	rows, err := db.Query(query, customerID, pq.Array(tokens))
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query spam tokens. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var token string
+		var count SpamTokenCount
+		err = rows.Scan(&token, &count.SpamCount, &count.HamCount)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read spam token row. CustomerID: %d", customerID), err}
+		}
+		counts[token] = count
+	}
+	return counts, nil
+}
+
+/*TrainSpamClassifier counts the tokens of a document as spam or ham for the classifier of the customer. Tokens must be unique.*/
+func TrainSpamClassifier(customerID int, tokens []string, spam bool) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	spamCount, hamCount := 0, 1
+	if spam {
+		spamCount, hamCount = 1, 0
+	}
+	tran, err := db.Begin()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot start the transaction. CustomerID: %d", customerID), err}
+	}
+	query := "INSERT INTO spamclassifiers (customerid, spamdocuments, hamdocuments, updatedon) VALUES ($1, $2, $3, $4)" +
+		" ON CONFLICT (customerid) DO UPDATE SET spamdocuments = spamclassifiers.spamdocuments + EXCLUDED.spamdocuments," +
+		" hamdocuments = spamclassifiers.hamdocuments + EXCLUDED.hamdocuments, updatedon = EXCLUDED.updatedon"
+	_, err = tran.Exec(query, customerID, spamCount, hamCount, time.Now())
+	if err != nil {
+		tran.Rollback()
+		return &DBError{fmt.Sprintf("Cannot update spam classifier. CustomerID: %d", customerID), err}
+	}
+	query = "INSERT INTO spamtokens (customerid, token, spamcount, hamcount) SELECT $1, token, $3, $4 FROM unnest($2::text[]) AS token" +
+		" ON CONFLICT (customerid, token) DO UPDATE SET spamcount = spamtokens.spamcount + EXCLUDED.spamcount, hamcount = spamtokens.hamcount + EXCLUDED.hamcount"
+	_, err = tran.Exec(query, customerID, pq.Array(tokens), spamCount, hamCount)
+	if err != nil {
+		tran.Rollback()
+		return &DBError{fmt.Sprintf("Cannot update spam tokens. CustomerID: %d", customerID), err}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot commit transaction. CustomerID: %d", customerID), err}
+	}
+	return nil
+}
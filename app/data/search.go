@@ -0,0 +1,139 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	/*SearchResultStory represents the search results which are stories*/
+	SearchResultStory = "story"
+	/*SearchResultComment represents the search results which are comments*/
+	SearchResultComment = "comment"
+
+	/*HighlightStart marks the start of a matching term in search result headlines*/
+	HighlightStart = "\x02"
+	/*HighlightEnd marks the end of a matching term in search result headlines*/
+	HighlightEnd = "\x03"
+)
+
+/*SearchQuery represents the search text and filters. Empty filters are not applied.*/
+type SearchQuery struct {
+	CustomerID int
+	Text       string
+	Author     string
+	Tag        string
+	Kind       string
+	Type       string
+	From       *time.Time
+	To         *time.Time
+	PageNumber int
+	PageSize   int
+}
+
+/*SearchResult represents a story or comment which matches the search query. Headlines contain matching terms between HighlightStart and HighlightEnd.*/
+type SearchResult struct {
+	Type          string
+	StoryID       int
+	CommentID     int
+	Title         string
+	TitleHeadline string
+	Headline      string
+	URL           string
+	UserName      string
+	Points        int
+	CommentCount  int
+	CreatedOn     time.Time
+	Rank          float64
+}
+
+// searchHeadlineOptions wraps the matching terms with the highlight markers so that the headline can be html escaped before highlighting
+const searchHeadlineOptions = "'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=30, MinWords=10'"
+
+// searchTitleHeadlineOptions highlights the whole title instead of fragments
+const searchTitleHeadlineOptions = "'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true'"
+
+// searchVoteBoost blends the text relevance with the votes of the story or comment
+const searchVoteBoost = "(1 + LN(1 + GREATEST(%[1]s.upvotes - %[1]s.downvotes, 0)))"
+
+/*Search returns the stories and comments of the customer which match the query ordered by relevance and votes*/
+func Search(searchQuery *SearchQuery) (*[]SearchResult, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", searchQuery.CustomerID), err}
+	}
+	defer db.Close()
+
+	filters := " AND ($3::text IS NULL OR users.username = $3)" +
+		" AND ($4::text IS NULL OR stories.tags @> ARRAY[$4::text])" +
+		" AND ($5::text IS NULL OR stories.kind = $5)"
+	storiesSQL := "SELECT 'story' AS type, stories.id, 0, stories.title," +
+		" ts_headline('english', stories.title, q, " + searchTitleHeadlineOptions + ")," +
+		" ts_headline('english', COALESCE(stories.text, ''), q, " + searchHeadlineOptions + ")," +
+		" stories.url, users.username, stories.upvotes - stories.downvotes, stories.commentcount, stories.submittedon," +
+		" ts_rank_cd(stories.searchvector, q) * " + fmt.Sprintf(searchVoteBoost, "stories") + " AS rank" +
+		" FROM stories INNER JOIN users ON users.id = stories.userid, websearch_to_tsquery('english', $2) q" +
+		" WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.searchvector @@ q" + filters +
+		" AND ($6::timestamptz IS NULL OR stories.submittedon >= $6) AND ($7::timestamptz IS NULL OR stories.submittedon < $7)"
+	commentsSQL := "SELECT 'comment' AS type, stories.id, comments.id, stories.title, stories.title," +
+		" ts_headline('english', comments.comment, q, " + searchHeadlineOptions + ")," +
+		" stories.url, users.username, comments.upvotes - comments.downvotes, 0, comments.commentedon," +
+		" ts_rank_cd(comments.searchvector, q) * " + fmt.Sprintf(searchVoteBoost, "comments") + " AS rank" +
+		" FROM comments INNER JOIN stories ON stories.id = comments.storyid INNER JOIN users ON users.id = comments.userid, websearch_to_tsquery('english', $2) q" +
+		" WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND " + visibleCommentsSQL + " AND comments.searchvector @@ q" + filters +
+		" AND ($6::timestamptz IS NULL OR comments.commentedon >= $6) AND ($7::timestamptz IS NULL OR comments.commentedon < $7)"
+
+	var query string
+	switch searchQuery.Type {
+	case SearchResultStory:
+		query = storiesSQL
+	case SearchResultComment:
+		query = commentsSQL
+	default:
+		query = storiesSQL + " UNION ALL " + commentsSQL
+	}
+	query = "SELECT * FROM (" + query + ") results ORDER BY rank DESC LIMIT $8 OFFSET $9"
+
+	rows, err := db.Query(
+		query,
+		searchQuery.CustomerID,
+		searchQuery.Text,
+		nullString(searchQuery.Author),
+		nullString(searchQuery.Tag),
+		nullString(searchQuery.Kind),
+		nullTime(searchQuery.From),
+		nullTime(searchQuery.To),
+		searchQuery.PageSize,
+		(searchQuery.PageNumber-1)*searchQuery.PageSize)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot search. CustomerID: %d, Text: %s", searchQuery.CustomerID, searchQuery.Text), err}
+	}
+	defer rows.Close()
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		var storyURL sql.NullString
+		err = rows.Scan(
+			&result.Type,
+			&result.StoryID,
+			&result.CommentID,
+			&result.Title,
+			&result.TitleHeadline,
+			&result.Headline,
+			&storyURL,
+			&result.UserName,
+			&result.Points,
+			&result.CommentCount,
+			&result.CreatedOn,
+			&result.Rank)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read search result row. CustomerID: %d", searchQuery.CustomerID), err}
+		}
+		result.URL = storyURL.String
+		result.Headline = strings.TrimSpace(result.Headline)
+		results = append(results, result)
+	}
+	return &results, nil
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+/*SignupRequest represents an account which waits for the approval of an admin before it can sign in*/
+type SignupRequest struct {
+	UserID      int
+	UserName    string
+	Email       string
+	CustomerID  int
+	RequestedOn time.Time
+}
+
+/*AddSignupRequest marks the new account as waiting for approval*/
+func AddSignupRequest(customerID, userID int) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO signuprequests (userid, customerid, requestedon) VALUES ($1, $2, $3)"
+	_, err = db.Exec(query, userID, customerID, time.Now())
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot insert signup request. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	return nil
+}
+
+/*IsSignupPending checks whether the account still waits for approval*/
+func IsSignupPending(userID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM signuprequests WHERE userid = $1"
+	requestCount, err := count(query, userID)
+	if err != nil {
+		return false, err
+	}
+	return requestCount > 0, nil
+}
+
+/*GetSignupRequests returns the accounts of the customer which wait for approval, the oldest first*/
+func GetSignupRequests(customerID int) (*[]SignupRequest, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT signuprequests.userid, users.username, users.email, signuprequests.customerid, signuprequests.requestedon" +
+		" FROM signuprequests INNER JOIN users ON users.id = signuprequests.userid WHERE signuprequests.customerid = $1 ORDER BY signuprequests.requestedon ASC"
+	rows, err := db.Query(query, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query signup requests. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	requests := []SignupRequest{}
+	for rows.Next() {
+		var request SignupRequest
+		err = rows.Scan(&request.UserID, &request.UserName, &request.Email, &request.CustomerID, &request.RequestedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read signup request row. CustomerID: %d", customerID), err}
+		}
+		requests = append(requests, request)
+	}
+	return &requests, nil
+}
+
+/*ApproveSignupRequest lets the waiting account of the customer sign in. Returns false if there is no such request.*/
+func ApproveSignupRequest(customerID, userID int) (bool, error) {
+	query := "DELETE FROM signuprequests WHERE customerid = $1 AND userid = $2"
+	return execSignupRequest(query, customerID, userID)
+}
+
+/*RejectSignupRequest deletes the waiting account of the customer. Returns false if there is no such request.*/
+func RejectSignupRequest(customerID, userID int) (bool, error) {
+	query := "DELETE FROM users WHERE id IN (SELECT userid FROM signuprequests WHERE customerid = $1 AND userid = $2)"
+	return execSignupRequest(query, customerID, userID)
+}
+
+func execSignupRequest(query string, customerID, userID int) (bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	defer db.Close()
+	result, err := db.Exec(query, customerID, userID)
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot update signup request. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, &DBError{fmt.Sprintf("Cannot read affected signup requests. CustomerID: %d, UserID: %d", customerID, userID), err}
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,41 @@
+-- Table: public.bannedusers
+
+-- DROP TABLE public.bannedusers;
+
+CREATE TABLE public.bannedusers
+(
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    reason character varying(500) COLLATE pg_catalog."default",
+    bannedby integer,
+    bannedon timestamp with time zone NOT NULL,
+    kind character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'ban'::character varying,
+    endson timestamp with time zone,
+    CONSTRAINT bannedusers_pkey PRIMARY KEY (userid, kind),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT bannedby_fk FOREIGN KEY (bannedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.bannedusers
+    OWNER to postgres;
+
+-- Index: ix_bannedusers_customerid
+
+-- DROP INDEX public.ix_bannedusers_customerid;
+
+CREATE INDEX ix_bannedusers_customerid
+    ON public.bannedusers USING btree
+    (customerid ASC NULLS LAST, kind COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
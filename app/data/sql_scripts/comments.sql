@@ -13,6 +13,7 @@ CREATE TABLE public.comments
     commentedon timestamp with time zone NOT NULL,
     id serial NOT NULL,
     downvotes integer NOT NULL,
+    hidden boolean NOT NULL DEFAULT false,
     CONSTRAINT comments_pkey PRIMARY KEY (id),
     CONSTRAINT "parentId_fk" FOREIGN KEY (parentid)
         REFERENCES public.comments (id) MATCH SIMPLE
@@ -0,0 +1,19 @@
+-- Table: public.commentsettings
+
+-- DROP TABLE public.commentsettings;
+
+CREATE TABLE public.commentsettings
+(
+    userid integer NOT NULL,
+    sort character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'best'::character varying,
+    CONSTRAINT commentsettings_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.commentsettings
+    OWNER to postgres;
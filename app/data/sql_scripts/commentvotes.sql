@@ -6,6 +6,8 @@ CREATE TABLE public.commentvotes
     commentid integer NOT NULL,
     userid integer NOT NULL,
     votetype integer NOT NULL,
+    votedon timestamp with time zone NOT NULL DEFAULT now(),
+    ipaddress character varying(45) COLLATE pg_catalog."default",
     CONSTRAINT commentvotes_pk PRIMARY KEY (commentid, userid, votetype),
     CONSTRAINT commentid_fk FOREIGN KEY (commentid)
         REFERENCES public.comments (id) MATCH SIMPLE
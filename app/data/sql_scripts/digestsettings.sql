@@ -0,0 +1,29 @@
+-- Table: public.digestsettings
+
+-- DROP TABLE public.digestsettings;
+
+CREATE TABLE public.digestsettings
+(
+    userid integer NOT NULL,
+    frequency integer NOT NULL DEFAULT 0,
+    lastsenton timestamp with time zone,
+    CONSTRAINT digestsettings_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.digestsettings
+    OWNER to postgres;
+
+-- Index: ix_digestsettings_frequency
+
+-- DROP INDEX public.ix_digestsettings_frequency;
+
+CREATE INDEX ix_digestsettings_frequency
+    ON public.digestsettings USING btree
+    (frequency)
+    TABLESPACE pg_default;
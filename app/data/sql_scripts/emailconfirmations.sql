@@ -0,0 +1,21 @@
+-- Table: public.emailconfirmations
+
+-- DROP TABLE public.emailconfirmations;
+
+CREATE TABLE public.emailconfirmations
+(
+    token character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    userid integer NOT NULL,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT emailconfirmations_pkey PRIMARY KEY (token),
+    CONSTRAINT emailconfirmations_userid_key UNIQUE (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.emailconfirmations
+    OWNER to postgres;
@@ -0,0 +1,61 @@
+-- Table: public.heldcontent
+
+-- DROP TABLE public.heldcontent;
+
+CREATE TABLE public.heldcontent
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    targettype character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    storyid integer NOT NULL,
+    userid integer NOT NULL,
+    domain character varying(255) COLLATE pg_catalog."default",
+    reasons text[] COLLATE pg_catalog."default" NOT NULL,
+    spamscore double precision NOT NULL DEFAULT 0,
+    heldon timestamp with time zone NOT NULL,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'held'::character varying,
+    reviewedon timestamp with time zone,
+    reviewedby integer,
+    CONSTRAINT heldcontent_pkey PRIMARY KEY (id),
+    CONSTRAINT heldcontent_target_key UNIQUE (targettype, targetid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT reviewedby_fk FOREIGN KEY (reviewedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.heldcontent
+    OWNER to postgres;
+
+-- Index: ix_heldcontent_status
+
+-- DROP INDEX public.ix_heldcontent_status;
+
+CREATE INDEX ix_heldcontent_status
+    ON public.heldcontent USING btree
+    (customerid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST, heldon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_heldcontent_domain
+
+-- DROP INDEX public.ix_heldcontent_domain;
+
+CREATE INDEX ix_heldcontent_domain
+    ON public.heldcontent USING btree
+    (customerid ASC NULLS LAST, domain COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
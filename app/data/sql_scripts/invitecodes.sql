@@ -9,6 +9,11 @@ CREATE TABLE public.invitecodes
     createdon timestamp with time zone NOT NULL,
     invitedemail character varying(50) COLLATE pg_catalog."default" NOT NULL,
     used boolean NOT NULL DEFAULT false,
+    expireson timestamp with time zone,
+    revoked boolean NOT NULL DEFAULT false,
+    lastsenton timestamp with time zone,
+    fullname character varying(50) COLLATE pg_catalog."default",
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
     CONSTRAINT invitecodes_pkey PRIMARY KEY (code),
     CONSTRAINT userid_fk FOREIGN KEY (inviteruserid)
         REFERENCES public.users (id) MATCH SIMPLE
@@ -0,0 +1,38 @@
+-- Table: public.invitelinks
+
+-- DROP TABLE public.invitelinks;
+
+CREATE TABLE public.invitelinks
+(
+    code character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    customerid integer NOT NULL,
+    createdby integer,
+    createdon timestamp with time zone NOT NULL,
+    expireson timestamp with time zone,
+    maxuses integer NOT NULL DEFAULT 0,
+    usecount integer NOT NULL DEFAULT 0,
+    revoked boolean NOT NULL DEFAULT false,
+    CONSTRAINT invitelinks_pkey PRIMARY KEY (code),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT createdby_fk FOREIGN KEY (createdby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.invitelinks
+    OWNER to postgres;
+
+-- Index: ix_invitelinks_customerid
+
+-- DROP INDEX public.ix_invitelinks_customerid;
+
+CREATE INDEX ix_invitelinks_customerid
+    ON public.invitelinks USING btree
+    (customerid ASC NULLS LAST)
+    TABLESPACE pg_default;
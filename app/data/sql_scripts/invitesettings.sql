@@ -0,0 +1,26 @@
+-- Table: public.invitesettings
+
+-- DROP TABLE public.invitesettings;
+
+CREATE TABLE public.invitesettings
+(
+    customerid integer NOT NULL,
+    expirydays integer NOT NULL DEFAULT 7,
+    memberinvitespermonth integer NOT NULL DEFAULT 5,
+    admininvitespermonth integer NOT NULL DEFAULT 0,
+    mininvitekarma integer NOT NULL DEFAULT 0,
+    karmaperextrainvite integer NOT NULL DEFAULT 0,
+    signupmode character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'invite'::character varying,
+    alloweddomains text[] COLLATE pg_catalog."default" NOT NULL DEFAULT '{}'::text[],
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT invitesettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.invitesettings
+    OWNER to postgres;
@@ -0,0 +1,29 @@
+-- Table: public.ipbans
+
+-- DROP TABLE public.ipbans;
+
+CREATE TABLE public.ipbans
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    cidr character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    reason character varying(500) COLLATE pg_catalog."default",
+    bannedby integer,
+    bannedon timestamp with time zone NOT NULL,
+    endson timestamp with time zone,
+    CONSTRAINT ipbans_pkey PRIMARY KEY (id),
+    CONSTRAINT ipbans_customer_cidr_key UNIQUE (customerid, cidr),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT bannedby_fk FOREIGN KEY (bannedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.ipbans
+    OWNER to postgres;
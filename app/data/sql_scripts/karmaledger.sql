@@ -0,0 +1,47 @@
+-- Table: public.karmaledger
+
+-- DROP TABLE public.karmaledger;
+
+CREATE TABLE public.karmaledger
+(
+    id serial NOT NULL,
+    userid integer NOT NULL,
+    source character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    points integer NOT NULL,
+    storyid integer,
+    commentid integer,
+    actoruserid integer,
+    reason character varying(500) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT karmaledger_pkey PRIMARY KEY (id),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT commentid_fk FOREIGN KEY (commentid)
+        REFERENCES public.comments (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT actoruserid_fk FOREIGN KEY (actoruserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.karmaledger
+    OWNER to postgres;
+
+-- Index: ix_karmaledger_userid
+
+-- DROP INDEX public.ix_karmaledger_userid;
+
+CREATE INDEX ix_karmaledger_userid
+    ON public.karmaledger USING btree
+    (userid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
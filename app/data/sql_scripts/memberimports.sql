@@ -0,0 +1,73 @@
+-- Table: public.memberimports
+
+-- DROP TABLE public.memberimports;
+
+CREATE TABLE public.memberimports
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    createdby integer,
+    createdon timestamp with time zone NOT NULL,
+    filename character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    memo text COLLATE pg_catalog."default",
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'preview',
+    CONSTRAINT memberimports_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT createdby_fk FOREIGN KEY (createdby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.memberimports
+    OWNER to postgres;
+
+-- Index: ix_memberimports_customerid
+
+-- DROP INDEX public.ix_memberimports_customerid;
+
+CREATE INDEX ix_memberimports_customerid
+    ON public.memberimports USING btree
+    (customerid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Table: public.memberimportrows
+
+-- DROP TABLE public.memberimportrows;
+
+CREATE TABLE public.memberimportrows
+(
+    id serial NOT NULL,
+    importid integer NOT NULL,
+    linenumber integer NOT NULL,
+    email character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    fullname character varying(50) COLLATE pg_catalog."default",
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default",
+    invitecode character varying(20) COLLATE pg_catalog."default",
+    CONSTRAINT memberimportrows_pkey PRIMARY KEY (id),
+    CONSTRAINT importid_fk FOREIGN KEY (importid)
+        REFERENCES public.memberimports (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.memberimportrows
+    OWNER to postgres;
+
+-- Index: ix_memberimportrows_importid
+
+-- DROP INDEX public.ix_memberimportrows_importid;
+
+CREATE INDEX ix_memberimportrows_importid
+    ON public.memberimportrows USING btree
+    (importid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,42 @@
+-- Table: public.moderationlog
+
+-- DROP TABLE public.moderationlog;
+
+CREATE TABLE public.moderationlog
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    actoruserid integer,
+    action character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    targetuserid integer,
+    target character varying(255) COLLATE pg_catalog."default",
+    details character varying(500) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT moderationlog_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT actoruserid_fk FOREIGN KEY (actoruserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT targetuserid_fk FOREIGN KEY (targetuserid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.moderationlog
+    OWNER to postgres;
+
+-- Index: ix_moderationlog_customerid
+
+-- DROP INDEX public.ix_moderationlog_customerid;
+
+CREATE INDEX ix_moderationlog_customerid
+    ON public.moderationlog USING btree
+    (customerid ASC NULLS LAST, createdon DESC NULLS LAST)
+    TABLESPACE pg_default;
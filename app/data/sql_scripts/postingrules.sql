@@ -0,0 +1,27 @@
+-- Table: public.postingrules
+
+-- DROP TABLE public.postingrules;
+
+CREATE TABLE public.postingrules
+(
+    customerid integer NOT NULL,
+    probationdays integer NOT NULL DEFAULT 0,
+    probationkarma integer NOT NULL DEFAULT 0,
+    restricteddomains text[] COLLATE pg_catalog."default" NOT NULL DEFAULT '{}'::text[],
+    storiesperhour integer NOT NULL DEFAULT 0,
+    storiesperday integer NOT NULL DEFAULT 0,
+    commentsperhour integer NOT NULL DEFAULT 0,
+    commentsperday integer NOT NULL DEFAULT 0,
+    karmaperextrapost integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT postingrules_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.postingrules
+    OWNER to postgres;
@@ -0,0 +1,22 @@
+-- Table: public.rankingsettings
+
+-- DROP TABLE public.rankingsettings;
+
+CREATE TABLE public.rankingsettings
+(
+    customerid integer NOT NULL,
+    algorithm character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'gravity'::character varying,
+    gravity double precision NOT NULL DEFAULT 0.1,
+    commentpenalty integer NOT NULL DEFAULT 40,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT rankingsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.rankingsettings
+    OWNER to postgres;
@@ -0,0 +1,59 @@
+-- Table: public.reports
+
+-- DROP TABLE public.reports;
+
+CREATE TABLE public.reports
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    targettype character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    storyid integer NOT NULL,
+    userid integer NOT NULL,
+    reason character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    reportedon timestamp with time zone NOT NULL,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open'::character varying,
+    resolvedon timestamp with time zone,
+    resolvedby integer,
+    CONSTRAINT reports_pkey PRIMARY KEY (id),
+    CONSTRAINT reports_target_user_key UNIQUE (targettype, targetid, userid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT resolvedby_fk FOREIGN KEY (resolvedby)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.reports
+    OWNER to postgres;
+
+-- Index: ix_reports_status
+
+-- DROP INDEX public.ix_reports_status;
+
+CREATE INDEX ix_reports_status
+    ON public.reports USING btree
+    (customerid ASC NULLS LAST, status COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_reports_userid
+
+-- DROP INDEX public.ix_reports_userid;
+
+CREATE INDEX ix_reports_userid
+    ON public.reports USING btree
+    (userid ASC NULLS LAST, reportedon DESC NULLS LAST)
+    TABLESPACE pg_default;
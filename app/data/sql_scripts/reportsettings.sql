@@ -0,0 +1,21 @@
+-- Table: public.reportsettings
+
+-- DROP TABLE public.reportsettings;
+
+CREATE TABLE public.reportsettings
+(
+    customerid integer NOT NULL,
+    reasons text[] COLLATE pg_catalog."default" NOT NULL,
+    hidethreshold integer NOT NULL,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT reportsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.reportsettings
+    OWNER to postgres;
@@ -0,0 +1,25 @@
+-- Table: public.screeningsettings
+
+-- DROP TABLE public.screeningsettings;
+
+CREATE TABLE public.screeningsettings
+(
+    customerid integer NOT NULL,
+    banneddomains text[] COLLATE pg_catalog."default" NOT NULL,
+    bannedphrases text[] COLLATE pg_catalog."default" NOT NULL,
+    newaccountdays integer NOT NULL,
+    maxnewaccountlinks integer NOT NULL,
+    minreputation double precision NOT NULL,
+    spamthreshold double precision NOT NULL,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT screeningsettings_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.screeningsettings
+    OWNER to postgres;
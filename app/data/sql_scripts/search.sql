@@ -0,0 +1,37 @@
+-- Column: public.stories.searchvector
+
+-- ALTER TABLE public.stories DROP COLUMN searchvector;
+
+ALTER TABLE public.stories
+    ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english'::regconfig, COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english'::regconfig, COALESCE(text, '')), 'B') ||
+        setweight(to_tsvector('simple'::regconfig, COALESCE(substring(url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)'), '')), 'C')
+    ) STORED;
+
+-- Index: ix_stories_searchvector
+
+-- DROP INDEX public.ix_stories_searchvector;
+
+CREATE INDEX ix_stories_searchvector
+    ON public.stories USING gin
+    (searchvector)
+    TABLESPACE pg_default;
+
+-- Column: public.comments.searchvector
+
+-- ALTER TABLE public.comments DROP COLUMN searchvector;
+
+ALTER TABLE public.comments
+    ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+        to_tsvector('english'::regconfig, COALESCE(comment, ''))
+    ) STORED;
+
+-- Index: ix_comments_searchvector
+
+-- DROP INDEX public.ix_comments_searchvector;
+
+CREATE INDEX ix_comments_searchvector
+    ON public.comments USING gin
+    (searchvector)
+    TABLESPACE pg_default;
@@ -0,0 +1,33 @@
+-- Table: public.signuprequests
+
+-- DROP TABLE public.signuprequests;
+
+CREATE TABLE public.signuprequests
+(
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    requestedon timestamp with time zone NOT NULL,
+    CONSTRAINT signuprequests_pkey PRIMARY KEY (userid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.signuprequests
+    OWNER to postgres;
+
+-- Index: ix_signuprequests_customerid
+
+-- DROP INDEX public.ix_signuprequests_customerid;
+
+CREATE INDEX ix_signuprequests_customerid
+    ON public.signuprequests USING btree
+    (customerid ASC NULLS LAST)
+    TABLESPACE pg_default;
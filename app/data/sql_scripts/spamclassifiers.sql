@@ -0,0 +1,21 @@
+-- Table: public.spamclassifiers
+
+-- DROP TABLE public.spamclassifiers;
+
+CREATE TABLE public.spamclassifiers
+(
+    customerid integer NOT NULL,
+    spamdocuments integer NOT NULL DEFAULT 0,
+    hamdocuments integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT spamclassifiers_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.spamclassifiers
+    OWNER to postgres;
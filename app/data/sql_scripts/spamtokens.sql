@@ -0,0 +1,21 @@
+-- Table: public.spamtokens
+
+-- DROP TABLE public.spamtokens;
+
+CREATE TABLE public.spamtokens
+(
+    customerid integer NOT NULL,
+    token character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    spamcount integer NOT NULL DEFAULT 0,
+    hamcount integer NOT NULL DEFAULT 0,
+    CONSTRAINT spamtokens_pkey PRIMARY KEY (customerid, token),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.spamtokens
+    OWNER to postgres;
@@ -17,6 +17,15 @@ CREATE TABLE public.stories
     with time zone NOT NULL,
     tags text[] COLLATE pg_catalog."default",
     downvotes integer NOT NULL DEFAULT 0,
+    metadescription text COLLATE pg_catalog."default",
+    metaimageurl character varying(500) COLLATE pg_catalog."default",
+    metasitename character varying(250) COLLATE pg_catalog."default",
+    metacanonicalurl character varying(500) COLLATE pg_catalog."default",
+    metapublishedon timestamp with time zone,
+    canonicalurl character varying(500) COLLATE pg_catalog."default",
+    kind character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'link'::character varying,
+    rank double precision NOT NULL DEFAULT 0,
+    hidden boolean NOT NULL DEFAULT false,
     CONSTRAINT stories_pkey PRIMARY KEY
     (id),
     CONSTRAINT fk_userid FOREIGN KEY
@@ -43,6 +52,14 @@ TABLESPACE
     ON public.stories USING btree
     (submittedon DESC NULLS LAST)
     TABLESPACE pg_default;
+    -- Index: ix_rank
+
+    -- DROP INDEX public.ix_rank;
+
+    CREATE INDEX ix_rank
+    ON public.stories USING btree
+    (rank DESC NULLS LAST)
+    TABLESPACE pg_default;
     -- Index: ix_tags
 
     -- DROP INDEX public.ix_tags;
@@ -50,4 +67,12 @@ TABLESPACE
     CREATE INDEX ix_tags
     ON public.stories USING btree
     (tags COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
+    -- Index: ix_canonicalurl
+
+    -- DROP INDEX public.ix_canonicalurl;
+
+    CREATE INDEX ix_canonicalurl
+    ON public.stories USING btree
+    (canonicalurl COLLATE pg_catalog."default" ASC NULLS LAST, submittedon DESC NULLS LAST)
     TABLESPACE pg_default;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- Table: public.storykinds
+
+-- DROP TABLE public.storykinds;
+
+CREATE TABLE public.storykinds
+(
+    customerid integer NOT NULL,
+    name character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    titleprefix character varying(25) COLLATE pg_catalog."default",
+    voteexponent double precision NOT NULL DEFAULT 0.8,
+    timeexponent double precision NOT NULL DEFAULT 0.1,
+    rankmultiplier double precision NOT NULL DEFAULT 1.0,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT storykinds_pkey PRIMARY KEY (customerid, name),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.storykinds
+    OWNER to postgres;
+
+-- Index: ix_stories_kind
+
+-- DROP INDEX public.ix_stories_kind;
+
+CREATE INDEX ix_stories_kind
+    ON public.stories USING btree
+    (kind COLLATE pg_catalog."default" ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,24 @@
+-- Table: public.storyvisits
+
+-- DROP TABLE public.storyvisits;
+
+CREATE TABLE public.storyvisits
+(
+    userid integer NOT NULL,
+    storyid integer NOT NULL,
+    visitedon timestamp with time zone NOT NULL,
+    CONSTRAINT storyvisits_pkey PRIMARY KEY (userid, storyid),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT storyid_fk FOREIGN KEY (storyid)
+        REFERENCES public.stories (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.storyvisits
+    OWNER to postgres;
@@ -6,6 +6,8 @@ CREATE TABLE public.storyvotes
     storyid integer NOT NULL,
     userid integer NOT NULL,
     votetype integer NOT NULL,
+    votedon timestamp with time zone NOT NULL DEFAULT now(),
+    ipaddress character varying(45) COLLATE pg_catalog."default",
     CONSTRAINT storyvotes_pk PRIMARY KEY (storyid, userid, votetype),
     CONSTRAINT storyid_fk FOREIGN KEY (storyid)
         REFERENCES public.stories (id) MATCH SIMPLE
@@ -0,0 +1,52 @@
+-- Table: public.tags
+
+-- DROP TABLE public.tags;
+
+CREATE TABLE public.tags
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    name character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    description character varying(100) COLLATE pg_catalog."default",
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT tags_pkey PRIMARY KEY (id),
+    CONSTRAINT tags_customerid_name_key UNIQUE (customerid, name),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.tags
+    OWNER to postgres;
+
+-- Table: public.hiddentags
+
+-- DROP TABLE public.hiddentags;
+
+CREATE TABLE public.hiddentags
+(
+    userid integer NOT NULL,
+    tag character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT hiddentags_pkey PRIMARY KEY (userid, tag),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.hiddentags
+    OWNER to postgres;
+
+-- Index: ix_stories_tags_gin
+
+-- DROP INDEX public.ix_stories_tags_gin;
+
+CREATE INDEX ix_stories_tags_gin
+    ON public.stories USING gin
+    (tags)
+    TABLESPACE pg_default;
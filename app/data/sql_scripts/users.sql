@@ -23,6 +23,7 @@ CREATE TABLE public.users
     registeredon timestamp
     with time zone NOT NULL,
     customerid integer,
+    role character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
     CONSTRAINT users_pkey PRIMARY KEY
     (id),
     CONSTRAINT unique_email UNIQUE
@@ -0,0 +1,37 @@
+-- Table: public.usersessions
+
+-- DROP TABLE public.usersessions;
+
+CREATE TABLE public.usersessions
+(
+    id serial NOT NULL,
+    userid integer NOT NULL,
+    customerid integer NOT NULL,
+    signedinon timestamp with time zone NOT NULL,
+    expireson timestamp with time zone NOT NULL,
+    ipaddress character varying(45) COLLATE pg_catalog."default",
+    useragent character varying(255) COLLATE pg_catalog."default",
+    CONSTRAINT usersessions_pkey PRIMARY KEY (id),
+    CONSTRAINT userid_fk FOREIGN KEY (userid)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.usersessions
+    OWNER to postgres;
+
+-- Index: ix_usersessions_userid
+
+-- DROP INDEX public.ix_usersessions_userid;
+
+CREATE INDEX ix_usersessions_userid
+    ON public.usersessions USING btree
+    (userid ASC NULLS LAST, signedinon DESC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,78 @@
+-- Table: public.voteflags
+
+-- DROP TABLE public.voteflags;
+
+CREATE TABLE public.voteflags
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    reason character varying(25) COLLATE pg_catalog."default" NOT NULL,
+    fingerprint character varying(200) COLLATE pg_catalog."default" NOT NULL,
+    evidence text COLLATE pg_catalog."default" NOT NULL,
+    userids integer[] NOT NULL,
+    storyid integer,
+    status character varying(25) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open'::character varying,
+    detectedon timestamp with time zone NOT NULL,
+    resolvedon timestamp with time zone,
+    CONSTRAINT voteflags_pkey PRIMARY KEY (id),
+    CONSTRAINT voteflags_fingerprint_key UNIQUE (customerid, fingerprint),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voteflags
+    OWNER to postgres;
+
+-- Index: ix_voteflags_status
+
+-- DROP INDEX public.ix_voteflags_status;
+
+CREATE INDEX ix_voteflags_status
+    ON public.voteflags USING btree
+    (customerid, status COLLATE pg_catalog."default")
+    TABLESPACE pg_default;
+
+-- Table: public.voteflagvotes
+
+-- DROP TABLE public.voteflagvotes;
+
+CREATE TABLE public.voteflagvotes
+(
+    flagid integer NOT NULL,
+    targettype character varying(10) COLLATE pg_catalog."default" NOT NULL,
+    targetid integer NOT NULL,
+    userid integer NOT NULL,
+    votetype integer NOT NULL,
+    CONSTRAINT voteflagvotes_pkey PRIMARY KEY (flagid, targettype, targetid, userid),
+    CONSTRAINT flagid_fk FOREIGN KEY (flagid)
+        REFERENCES public.voteflags (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voteflagvotes
+    OWNER to postgres;
+
+-- Index: ix_storyvotes_votedon
+
+-- DROP INDEX public.ix_storyvotes_votedon;
+
+CREATE INDEX ix_storyvotes_votedon
+    ON public.storyvotes USING btree
+    (votedon DESC NULLS LAST)
+    TABLESPACE pg_default;
+
+-- Index: ix_commentvotes_votedon
+
+-- DROP INDEX public.ix_commentvotes_votedon;
+
+CREATE INDEX ix_commentvotes_votedon
+    ON public.commentvotes USING btree
+    (votedon DESC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,21 @@
+-- Table: public.voterules
+
+-- DROP TABLE public.voterules;
+
+CREATE TABLE public.voterules
+(
+    customerid integer NOT NULL,
+    minaccountagedays integer NOT NULL DEFAULT 0,
+    minkarma integer NOT NULL DEFAULT 0,
+    updatedon timestamp with time zone NOT NULL,
+    CONSTRAINT voterules_pkey PRIMARY KEY (customerid),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.voterules
+    OWNER to postgres;
@@ -0,0 +1,69 @@
+-- Table: public.webhooks
+
+-- DROP TABLE public.webhooks;
+
+CREATE TABLE public.webhooks
+(
+    id serial NOT NULL,
+    customerid integer NOT NULL,
+    url character varying(500) COLLATE pg_catalog."default" NOT NULL,
+    secret character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    events text[] COLLATE pg_catalog."default" NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    createdon timestamp with time zone NOT NULL,
+    CONSTRAINT webhooks_pkey PRIMARY KEY (id),
+    CONSTRAINT customerid_fk FOREIGN KEY (customerid)
+        REFERENCES public.customers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.webhooks
+    OWNER to postgres;
+
+-- Index: ix_webhooks_customerid
+
+-- DROP INDEX public.ix_webhooks_customerid;
+
+CREATE INDEX ix_webhooks_customerid
+    ON public.webhooks USING btree
+    (customerid)
+    TABLESPACE pg_default;
+
+-- Table: public.webhookdeliveries
+
+-- DROP TABLE public.webhookdeliveries;
+
+CREATE TABLE public.webhookdeliveries
+(
+    id serial NOT NULL,
+    webhookid integer NOT NULL,
+    event character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    payload text COLLATE pg_catalog."default" NOT NULL,
+    responsecode integer,
+    error text COLLATE pg_catalog."default",
+    attempts integer NOT NULL DEFAULT 0,
+    createdon timestamp with time zone NOT NULL,
+    deliveredon timestamp with time zone,
+    CONSTRAINT webhookdeliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT webhookid_fk FOREIGN KEY (webhookid)
+        REFERENCES public.webhooks (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+)
+
+TABLESPACE pg_default;
+
+ALTER TABLE public.webhookdeliveries
+    OWNER to postgres;
+
+-- Index: ix_webhookdeliveries_webhookid
+
+-- DROP INDEX public.ix_webhookdeliveries_webhookid;
+
+CREATE INDEX ix_webhookdeliveries_webhookid
+    ON public.webhookdeliveries USING btree
+    (webhookid, createdon DESC)
+    TABLESPACE pg_default;
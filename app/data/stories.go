@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"fmt"
 	"linkwind/app/enums"
-	"math"
 	"time"
 
 	"github.com/lib/pq"
@@ -12,20 +11,47 @@ import (
 
 /*Story represents the story which contains shared article or link info*/
 type Story struct {
-	ID                 int
-	URL                string
-	Title              string
-	Text               string
-	Tags               []string
-	UpVotes            int
-	DownVotes          int
-	CommentCount       int
-	UserID             int
-	UserName           string
-	SubmittedOn        time.Time
-	CalculateStoryRank float64
+	ID           int
+	URL          string
+	Title        string
+	Text         string
+	Tags         []string
+	UpVotes      int
+	DownVotes    int
+	CommentCount int
+	UserID       int
+	UserName     string
+	SubmittedOn  time.Time
+	Rank         float64
+	CanonicalURL string
+	Kind         string
+	Metadata     LinkMetadata
+	Hidden       bool
+	// AuthorShadowbanned is true if the story is visible only to its author since the author is shadowbanned
+	AuthorShadowbanned bool
 }
 
+/*LinkMetadata represents the OpenGraph metadata of the story url which is captured on submit*/
+type LinkMetadata struct {
+	Description  string
+	ImageURL     string
+	SiteName     string
+	CanonicalURL string
+	PublishedOn  *time.Time
+}
+
+// storyColumns represents the story columns in the order which story mappers read them
+const storyColumns = "stories.id, stories.url, stories.title, stories.text, stories.upvotes, stories.commentcount, stories.userid, stories.submittedon, stories.tags, stories.downvotes, stories.metadescription, stories.metaimageurl, stories.metasitename, stories.metacanonicalurl, stories.metapublishedon, stories.canonicalurl, stories.kind, stories.hidden, " + storyAuthorShadowbannedSQL
+
+// storyAuthorShadowbannedSQL checks whether the author of the story is shadowbanned
+const storyAuthorShadowbannedSQL = "EXISTS (SELECT 1 FROM bannedusers WHERE bannedusers.userid = stories.userid AND bannedusers.kind = 'shadowban')"
+
+// visibleStoriesSQL filters the stories which are listed to everyone. Hidden stories and the stories of shadowbanned users are left out.
+const visibleStoriesSQL = "NOT stories.hidden AND NOT " + storyAuthorShadowbannedSQL
+
+// visibleStoriesToSQL filters the stories which are listed to the viewer whose id is the second parameter. Authors also see their own hidden stories.
+const visibleStoriesToSQL = "((" + visibleStoriesSQL + ") OR stories.userid = $2)"
+
 /*StoryError represents any error related to story*/
 type StoryError struct {
 	Message       string
@@ -41,15 +67,15 @@ func (err *StoryError) Error() string {
 		err.OriginalError)
 }
 
-/*CreateStory creates a story on database*/
+/*CreateStory creates a story on database and sets the id of created story*/
 func CreateStory(story *Story) error {
 	db, err := connectToDB()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
-	sql := "INSERT INTO stories (url, title, text, tags, upvotes, downvotes,  commentcount, userid, submittedon) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)"
-	_, err = db.Exec(
+	sql := "INSERT INTO stories (url, title, text, tags, upvotes, downvotes,  commentcount, userid, submittedon, metadescription, metaimageurl, metasitename, metacanonicalurl, metapublishedon, canonicalurl, kind, hidden) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) RETURNING id"
+	err = db.QueryRow(
 		sql,
 		story.URL,
 		story.Title,
@@ -59,7 +85,15 @@ func CreateStory(story *Story) error {
 		0,
 		0,
 		story.UserID,
-		time.Now())
+		time.Now(),
+		nullString(story.Metadata.Description),
+		nullString(story.Metadata.ImageURL),
+		nullString(story.Metadata.SiteName),
+		nullString(story.Metadata.CanonicalURL),
+		nullTime(story.Metadata.PublishedOn),
+		nullString(story.CanonicalURL),
+		story.Kind,
+		story.Hidden).Scan(&story.ID)
 	if err != nil {
 		return &StoryError{"Cannot create story!", story, err}
 	}
@@ -74,7 +108,7 @@ func GetStories(customerID, pageNumber, pageRowCount int) (*[]Story, error) {
 	}
 	defer db.Close()
 
-	sql := "SELECT stories.*, users.UserName, stories.calculatestoryrank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 ORDER BY stories.calculatestoryrank DESC LIMIT $2 OFFSET $3"
+	sql := "SELECT " + storyColumns + ", users.UserName, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " ORDER BY stories.rank DESC LIMIT $2 OFFSET $3"
 	rows, err := db.Query(sql, customerID, pageRowCount, (pageNumber-1)*pageRowCount)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot get stories. PageNumber: %d, PageRowCount: %d", pageNumber, pageRowCount), err}
@@ -86,9 +120,76 @@ func GetStories(customerID, pageNumber, pageRowCount int) (*[]Story, error) {
 	return stories, nil
 }
 
+/*GetStoriesByTag returns the ranked stories of the customer which are tagged with given tag*/
+func GetStoriesByTag(customerID int, tag string, pageNumber, pageRowCount int) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sql := "SELECT " + storyColumns + ", users.UserName, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.tags @> $2 ORDER BY stories.rank DESC LIMIT $3 OFFSET $4"
+	rows, err := db.Query(sql, customerID, pq.Array([]string{tag}), pageRowCount, (pageNumber-1)*pageRowCount)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot get stories by tag. Tag: %s, PageNumber: %d, PageRowCount: %d", tag, pageNumber, pageRowCount), err}
+	}
+	stories, err := MapSQLRowsToStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. Tag: %s, PageNumber: %d, PageRowCount: %d", tag, pageNumber, pageRowCount), err}
+	}
+	return stories, nil
+}
+
+/*GetCustomerStoriesCountByTag returns the number of customer's stories which are tagged with given tag*/
+func GetCustomerStoriesCountByTag(customerID int, tag string) (int, error) {
+	sql := "SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.tags @> $2"
+	return count(sql, customerID, pq.Array([]string{tag}))
+}
+
+/*GetStoriesExcludingTags returns the ranked stories of the customer except the ones tagged with any of given tags*/
+func GetStoriesExcludingTags(customerID int, tags []string, pageNumber, pageRowCount int) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sql := "SELECT " + storyColumns + ", users.UserName, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND NOT (COALESCE(stories.tags, '{}') && $2) ORDER BY stories.rank DESC LIMIT $3 OFFSET $4"
+	rows, err := db.Query(sql, customerID, pq.Array(tags), pageRowCount, (pageNumber-1)*pageRowCount)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot get stories excluding tags. PageNumber: %d, PageRowCount: %d", pageNumber, pageRowCount), err}
+	}
+	stories, err := MapSQLRowsToStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. PageNumber: %d, PageRowCount: %d", pageNumber, pageRowCount), err}
+	}
+	return stories, nil
+}
+
+/*GetCustomerStoriesCountExcludingTags returns the number of customer's stories except the ones tagged with any of given tags*/
+func GetCustomerStoriesCountExcludingTags(customerID int, tags []string) (int, error) {
+	sql := "SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND NOT (COALESCE(stories.tags, '{}') && $2)"
+	return count(sql, customerID, pq.Array(tags))
+}
+
+/*UpdateStory updates the title, text, tags and kind of the story*/
+func UpdateStory(story *Story) error {
+	db, err := connectToDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	sql := "UPDATE stories SET title = $1, text = $2, tags = $3, kind = $4 WHERE id = $5"
+	_, err = db.Exec(sql, story.Title, story.Text, pq.Array(story.Tags), story.Kind, story.ID)
+	if err != nil {
+		return &StoryError{"Cannot update story!", story, err}
+	}
+	return nil
+}
+
 /*GetCustomerStoriesCount returns stories count number*/
 func GetCustomerStoriesCount(customerID int) (int, error) {
-	sql := "SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1"
+	sql := "SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL
 	return count(sql, customerID)
 }
 
@@ -99,7 +200,7 @@ func GetStoryByID(storyID int) (*Story, error) {
 		return nil, err
 	}
 	defer db.Close()
-	sql := "SELECT stories.*, users.UserName FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.id = $1"
+	sql := "SELECT " + storyColumns + ", users.UserName FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.id = $1"
 	row := db.QueryRow(sql, storyID)
 	story, err := MapSQLRowToStory(row)
 	if err != nil {
@@ -108,8 +209,100 @@ func GetStoryByID(storyID int) (*Story, error) {
 	return story, nil
 }
 
-/*VoteStory votes (upvote, downvote) the story on database*/
-func VoteStory(userID, storyID int, voteType enums.VoteType) error {
+/*GetStoriesByIDs returns the stories in the order of given ids*/
+func GetStoriesByIDs(ids []int) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	sql := "SELECT " + storyColumns + ", users.UserName, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.id = ANY($1) ORDER BY array_position($1, stories.id)"
+	storyIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		storyIDs[i] = int64(id)
+	}
+	rows, err := db.Query(sql, pq.Array(storyIDs))
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot get stories by ids. Count: %d", len(ids)), err}
+	}
+	stories, err := MapSQLRowsToStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. Count: %d", len(ids)), err}
+	}
+	return stories, nil
+}
+
+/*UserStoryState represents the vote and saved state of a story for a user*/
+type UserStoryState struct {
+	VoteType *enums.VoteType
+	IsSaved  bool
+}
+
+/*GetUserStoryStates returns the vote and saved state of the stories for the user in one query. Keys of the map are story ids.*/
+func GetUserStoryStates(userID int, storyIDs []int) (map[int]UserStoryState, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT ids.id, storyvotes.votetype, saved.storyid IS NOT NULL FROM UNNEST($2::integer[]) AS ids(id) LEFT JOIN storyvotes ON storyvotes.storyid = ids.id AND storyvotes.userid = $1 LEFT JOIN saved ON saved.storyid = ids.id AND saved.userid = $1"
+	ids := make([]int64, len(storyIDs))
+	for i, id := range storyIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := db.Query(query, userID, pq.Array(ids))
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query story states. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	states := make(map[int]UserStoryState, len(storyIDs))
+	for rows.Next() {
+		var storyID int
+		var state UserStoryState
+		err = rows.Scan(&storyID, &state.VoteType, &state.IsSaved)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read story state row. UserID: %d", userID), err}
+		}
+		states[storyID] = state
+	}
+	return states, nil
+}
+
+/*GetStoryByCanonicalURL returns the latest visible story of the customer which is submitted with the canonical url after given time. Returns nil if there is no such story.*/
+func GetStoryByCanonicalURL(customerID int, canonicalURL string, since time.Time) (*Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, CanonicalURL: %s", customerID, canonicalURL), err}
+	}
+	defer db.Close()
+	sql := "SELECT " + storyColumns + ", users.username FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND stories.canonicalurl = $2 AND stories.submittedon >= $3" +
+		" AND " + visibleStoriesSQL + " ORDER BY stories.submittedon DESC LIMIT 1"
+	rows, err := db.Query(sql, customerID, canonicalURL, since)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query story by canonical url. CustomerID: %d, CanonicalURL: %s", customerID, canonicalURL), err}
+	}
+	stories, err := MapSQLRowsToRecentStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. CustomerID: %d, CanonicalURL: %s", customerID, canonicalURL), err}
+	}
+	if len(*stories) == 0 {
+		return nil, nil
+	}
+	return &(*stories)[0], nil
+}
+
+/*ExistsStoryInCustomer checks whether the story is submitted on the customer's platform*/
+func ExistsStoryInCustomer(customerID, storyID int) (bool, error) {
+	sql := "SELECT COUNT(stories.id) FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.id = $1 AND users.customerid = $2"
+	storyCount, err := count(sql, storyID, customerID)
+	if err != nil {
+		return false, err
+	}
+	return storyCount > 0, nil
+}
+
+/*VoteStory votes (upvote, downvote) the story on database. IP address of the voter is kept for vote analysis.*/
+func VoteStory(userID, storyID int, voteType enums.VoteType, ipAddress string) error {
 	voteUpdateSQL := "UPDATE stories SET upvotes = upvotes + 1 WHERE id = $1"
 	if voteType == enums.DownVote {
 		voteUpdateSQL = "UPDATE stories SET downvotes = downvotes + 1 WHERE id = $1"
@@ -123,8 +316,8 @@ func VoteStory(userID, storyID int, voteType enums.VoteType) error {
 	if err != nil {
 		return &DBError{fmt.Sprintf("Cannot begin transaction. UserID: %d, StoryID: %d", userID, storyID), err}
 	}
-	sql := "INSERT INTO storyvotes(storyid, userid, votetype) VALUES($1, $2, $3)"
-	_, err = tran.Exec(sql, storyID, userID, voteType)
+	sql := "INSERT INTO storyvotes(storyid, userid, votetype, votedon, ipaddress) VALUES($1, $2, $3, $4, $5)"
+	_, err = tran.Exec(sql, storyID, userID, voteType, time.Now(), nullString(ipAddress))
 	if err != nil {
 		tran.Rollback()
 		return &DBError{fmt.Sprintf("Error occurred while inserting storyvotes. UserID: %d, StoryID: %d", userID, storyID), err}
@@ -135,8 +328,7 @@ func VoteStory(userID, storyID int, voteType enums.VoteType) error {
 		return &DBError{fmt.Sprintf("Error occurred while increasing story upvotes. UserID: %d, StoryID: %d", userID, storyID), err}
 	}
 	if voteType == enums.UpVote {
-		sql = "UPDATE users SET karma = karma + 1 WHERE id = (SELECT userid FROM stories WHERE id = $1)"
-		_, err = tran.Exec(sql, storyID)
+		err = recordVoteKarma(tran, enums.StoryVoteKarma, storyID, userID, 1)
 		if err != nil {
 			tran.Rollback()
 			return &DBError{fmt.Sprintf("Error occurred while increasing user's karma. UserID: %d, StoryID: %d", userID, storyID), err}
@@ -196,8 +388,7 @@ func RemoveStoryVote(userID, storyID int, voteType enums.VoteType) error {
 		return &DBError{fmt.Sprintf("Cannot update story's vote. UserID: %d, StoryID: %d", userID, storyID), err}
 	}
 	if voteType == enums.UpVote {
-		sql = "UPDATE users SET karma = karma - 1 WHERE id = (SELECT userid FROM stories WHERE id = $1)"
-		_, err = tran.Exec(sql, storyID)
+		err = recordVoteKarma(tran, enums.StoryVoteKarma, storyID, userID, -1)
 		if err != nil {
 			tran.Rollback()
 			return &DBError{fmt.Sprintf("Error occurred while decreasing user's karma. UserID: %d, StoryID: %d", userID, storyID), err}
@@ -267,7 +458,7 @@ func GetRecentStories(customerID, pageNumber, pageRowCount int) (*[]Story, error
 		return nil, &DBError{fmt.Sprintf("DB connection error. PageNumber: %d, PageRowCount: %d", pageNumber, pageRowCount), err}
 	}
 	defer db.Close()
-	sql := "SELECT stories.*, users.username FROM stories INNER JOIN users ON stories.userid = users.id WHERE users.customerid = $1 ORDER BY stories.submittedon DESC LIMIT $2 OFFSET $3"
+	sql := "SELECT " + storyColumns + ", users.username FROM stories INNER JOIN users ON stories.userid = users.id WHERE users.customerid = $1 AND " + visibleStoriesSQL + " ORDER BY stories.submittedon DESC LIMIT $2 OFFSET $3"
 	rows, err := db.Query(sql, customerID, pageRowCount, (pageNumber-1)*pageRowCount)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot get stories. PageNumber: %d, PageRowCount: %d", pageNumber, pageRowCount), err}
@@ -279,65 +470,55 @@ func GetRecentStories(customerID, pageNumber, pageRowCount int) (*[]Story, error
 	return stories, nil
 }
 
-/*GetUserSavedStories returns the paging user's favorite stories*/
-func GetUserSavedStories(userID int, pageNumber int, pageRowCount int) (*[]Story, error) {
-	db, err := connectToDB()
-	if err != nil {
-		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d PageNo: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
-	}
-	defer db.Close()
-	sql := "SELECT stories.*, users.username, stories.calculatestoryrank FROM stories INNER JOIN saved ON stories.id = saved.storyid INNER JOIN users ON users.id = stories.userid WHERE saved.userid = $1 ORDER BY savedon DESC LIMIT $2 OFFSET $3"
-	rows, err := db.Query(sql, userID, pageRowCount, (pageNumber-1)*pageRowCount)
-	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot query user's saved stories. UserID: %d, PageNumber: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
-	}
-	stories, err := MapSQLRowsToStories(rows)
+/*GetRecentStoriesByKeyset returns the page of customer's stories ordered by submission time*/
+func GetRecentStoriesByKeyset(customerID int, keyset *Keyset) (*KeysetPage, error) {
+	sql := "SELECT " + storyColumns + ", users.username, stories.submittedon FROM stories INNER JOIN users ON stories.userid = users.id WHERE users.customerid = $1 AND " + visibleStoriesSQL
+	page, err := queryStoryKeyset(sql, "stories.submittedon", keyset, customerID)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot map sql rows to story struct array. UserID: %d, PageNumber: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
+		return nil, &DBError{fmt.Sprintf("Cannot get recent stories. CustomerID: %d", customerID), err}
 	}
-	return stories, nil
-}
-
-/*GetUserSavedStoriesCount gets the total number of user's saved stories.*/
-func GetUserSavedStoriesCount(userID int) (int, error) {
-	sql := "SELECT COUNT(stories.id) FROM stories INNER JOIN saved ON stories.id = saved.storyid INNER JOIN users ON users.id = stories.userid WHERE saved.userid = $1"
-	return count(sql, userID)
+	return page, nil
 }
 
-/*GetUserUpvotedStories returns the paging user's upvoted stories*/
-func GetUserUpvotedStories(userID int, pageNumber int, pageRowCount int) (*[]Story, error) {
-	db, err := connectToDB()
-	if err != nil {
-		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d PageNo: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
-	}
-	defer db.Close()
-	sql := "SELECT stories.*, users.username, stories.calculatestoryrank FROM stories INNER JOIN storyvotes ON stories.id = storyvotes.storyid INNER JOIN users ON users.id = stories.userid WHERE storyvotes.userid = $1 ORDER BY stories.submittedon DESC LIMIT $2 OFFSET $3"
-	rows, err := db.Query(sql, userID, pageRowCount, (pageNumber-1)*pageRowCount)
+/*GetUserSavedStoriesByKeyset returns the page of user's saved stories ordered by the time they are saved. Hidden stories are only returned to their author.*/
+func GetUserSavedStoriesByKeyset(userID, viewerUserID int, keyset *Keyset) (*KeysetPage, error) {
+	sql := "SELECT " + storyColumns + ", users.username, saved.savedon FROM stories INNER JOIN saved ON stories.id = saved.storyid INNER JOIN users ON users.id = stories.userid WHERE saved.userid = $1 AND " + visibleStoriesToSQL
+	page, err := queryStoryKeyset(sql, "saved.savedon", keyset, userID, viewerUserID)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot query user's saved stories. UserID: %d, PageNumber: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
+		return nil, &DBError{fmt.Sprintf("Cannot query user's saved stories. UserID: %d", userID), err}
 	}
-	stories, err := MapSQLRowsToStories(rows)
+	return page, nil
+}
+
+/*GetUserUpvotedStoriesByKeyset returns the page of user's upvoted stories ordered by submission time. Hidden stories are only returned to their author.*/
+func GetUserUpvotedStoriesByKeyset(userID, viewerUserID int, keyset *Keyset) (*KeysetPage, error) {
+	sql := "SELECT " + storyColumns + ", users.username, stories.submittedon FROM stories INNER JOIN storyvotes ON stories.id = storyvotes.storyid INNER JOIN users ON users.id = stories.userid WHERE storyvotes.userid = $1 AND " + visibleStoriesToSQL
+	page, err := queryStoryKeyset(sql, "stories.submittedon", keyset, userID, viewerUserID)
 	if err != nil {
-		return nil, &DBError{fmt.Sprintf("Cannot map sql rows to story struct array. UserID: %d, PageNumber: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
+		return nil, &DBError{fmt.Sprintf("Cannot query user's upvoted stories. UserID: %d", userID), err}
 	}
-	return stories, nil
+	return page, nil
 }
 
-/*GetUserUpvotedStoriesCount gets the total number of user's upvoted stories.*/
-func GetUserUpvotedStoriesCount(userID int) (int, error) {
-	sql := "SELECT COUNT(stories.id) FROM stories INNER JOIN storyvotes ON stories.id = storyvotes.storyid INNER JOIN users ON users.id = stories.userid WHERE storyvotes.userid = $1"
-	return count(sql, userID)
+/*GetUserSubmittedStoriesByKeyset returns the page of user's stories ordered by submission time. Hidden stories are only returned to their author.*/
+func GetUserSubmittedStoriesByKeyset(userID, viewerUserID int, keyset *Keyset) (*KeysetPage, error) {
+	sql := "SELECT " + storyColumns + ", users.username, stories.submittedon FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1 AND " + visibleStoriesToSQL
+	page, err := queryStoryKeyset(sql, "stories.submittedon", keyset, userID, viewerUserID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query user's posted stories. UserID: %d", userID), err}
+	}
+	return page, nil
 }
 
-/*GetUserSubmittedStories get user's stories from db according to userID*/
-func GetUserSubmittedStories(userID int, pageNumber int, pageRowCount int) (*[]Story, error) {
+/*GetUserSubmittedStories get user's stories from db according to userID. Hidden stories are only returned if the viewer is their author, use 0 for anonymous viewers.*/
+func GetUserSubmittedStories(userID, viewerUserID int, pageNumber int, pageRowCount int) (*[]Story, error) {
 	db, err := connectToDB()
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
-	sql := "SELECT stories.*, users.username, stories.calculatestoryrank FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1 ORDER BY submittedon DESC LIMIT $2 OFFSET $3"
-	rows, err := db.Query(sql, userID, pageRowCount, (pageNumber-1)*pageRowCount)
+	sql := "SELECT " + storyColumns + ", users.username, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1 AND " + visibleStoriesToSQL + " ORDER BY submittedon DESC LIMIT $3 OFFSET $4"
+	rows, err := db.Query(sql, userID, viewerUserID, pageRowCount, (pageNumber-1)*pageRowCount)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot query user's posted stories. UserID: %d, PageNumber: %d, PageRowCount: %d", userID, pageNumber, pageRowCount), err}
 	}
@@ -349,34 +530,6 @@ func GetUserSubmittedStories(userID int, pageNumber int, pageRowCount int) (*[]S
 	return stories, nil
 }
 
-/*GetUserSubmittedStoriesCount gets the total number of user's submissions.*/
-func GetUserSubmittedStoriesCount(userID int) (int, error) {
-	sql := "SELECT COUNT(stories.id) FROM stories INNER JOIN users ON users.id = stories.userid WHERE stories.userid = $1"
-	return count(sql, userID)
-}
-
-/*CalculateStoryPenalty calculates story's penalty. If commentCount rises, penalty downs*/
-func CalculateStoryPenalty(commentCount int) int {
-	penalty := 40
-	for i := 0; i < 40; i++ {
-		if commentCount == i {
-			return penalty - i
-		}
-	}
-	return 0
-}
-
-/*CalculateStoryRank calcualte story's rank according to this formula :
-	 	  ((upVotes-downVotes)-1)^0.8
-Score =	———————————————————————————————— * penalty
-		((submittedTime-time.Now)+2)^1.8
-More details : http://www.righto.com/2013/11/how-hacker-news-ranking-really-works.html*/
-func CalculateStoryRank(penalty, votes, timeDiff int) int {
-	floatScore := (math.Pow(float64(votes-1), 0.8) / math.Pow(float64(timeDiff+2), 1.8)) * float64(penalty)
-
-	return int(floatScore)
-}
-
 func count(sql string, args ...interface{}) (int, error) {
 	var count int
 	db, err := connectToDB()
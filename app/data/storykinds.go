@@ -0,0 +1,172 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	/*StoryKindLink represents the stories which share a link*/
+	StoryKindLink = "link"
+	/*StoryKindAsk represents the stories which ask a question to the community*/
+	StoryKindAsk = "ask"
+	/*StoryKindShow represents the stories which show something the user made*/
+	StoryKindShow = "show"
+
+	/*DefaultVoteExponent represents the exponent applied to the votes of a story while ranking*/
+	DefaultVoteExponent = 0.8
+	/*DefaultTimeExponent represents the exponent applied to the age of a story while ranking*/
+	DefaultTimeExponent = 0.1
+	/*DefaultRankMultiplier represents the multiplier applied to the rank of a story*/
+	DefaultRankMultiplier = 1.0
+)
+
+/*StoryKind represents a kind of story and its ranking parameters*/
+type StoryKind struct {
+	CustomerID     int
+	Name           string
+	TitlePrefix    string
+	VoteExponent   float64
+	TimeExponent   float64
+	RankMultiplier float64
+	BuiltIn        bool
+	Customized     bool
+	CreatedOn      time.Time
+}
+
+/*IsBuiltInStoryKind returns true if the kind name is one of link, ask or show*/
+func IsBuiltInStoryKind(name string) bool {
+	return name == StoryKindLink || name == StoryKindAsk || name == StoryKindShow
+}
+
+func builtInStoryKinds(customerID int) []StoryKind {
+	kinds := []StoryKind{}
+	for _, kind := range []struct{ name, prefix string }{
+		{StoryKindLink, ""},
+		{StoryKindAsk, "Ask:"},
+		{StoryKindShow, "Show:"},
+	} {
+		kinds = append(kinds, StoryKind{
+			CustomerID:     customerID,
+			Name:           kind.name,
+			TitlePrefix:    kind.prefix,
+			VoteExponent:   DefaultVoteExponent,
+			TimeExponent:   DefaultTimeExponent,
+			RankMultiplier: DefaultRankMultiplier,
+			BuiltIn:        true,
+		})
+	}
+	return kinds
+}
+
+/*GetStoryKinds returns the built-in kinds followed by the kinds defined by the customer. Ranking parameters saved by the customer override the defaults of built-in kinds.*/
+func GetStoryKinds(customerID int) (*[]StoryKind, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	sql := "SELECT customerid, name, COALESCE(titleprefix, ''), voteexponent, timeexponent, rankmultiplier, createdon FROM storykinds WHERE customerid = $1 ORDER BY name"
+	rows, err := db.Query(sql, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query story kinds. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	kinds := builtInStoryKinds(customerID)
+	for rows.Next() {
+		var kind StoryKind
+		err = rows.Scan(&kind.CustomerID, &kind.Name, &kind.TitlePrefix, &kind.VoteExponent, &kind.TimeExponent, &kind.RankMultiplier, &kind.CreatedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read story kind row. CustomerID: %d", customerID), err}
+		}
+		if IsBuiltInStoryKind(kind.Name) {
+			for i := range kinds {
+				if kinds[i].Name == kind.Name {
+					kinds[i].VoteExponent = kind.VoteExponent
+					kinds[i].TimeExponent = kind.TimeExponent
+					kinds[i].RankMultiplier = kind.RankMultiplier
+					kinds[i].Customized = true
+					kinds[i].CreatedOn = kind.CreatedOn
+				}
+			}
+			continue
+		}
+		kind.Customized = true
+		kinds = append(kinds, kind)
+	}
+	return &kinds, nil
+}
+
+/*SaveStoryKind creates the kind or updates its title prefix and ranking parameters if it exists*/
+func SaveStoryKind(kind *StoryKind) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", kind.CustomerID), err}
+	}
+	defer db.Close()
+	sql := "INSERT INTO storykinds (customerid, name, titleprefix, voteexponent, timeexponent, rankmultiplier, createdon) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (customerid, name) DO UPDATE SET titleprefix = EXCLUDED.titleprefix, voteexponent = EXCLUDED.voteexponent, timeexponent = EXCLUDED.timeexponent, rankmultiplier = EXCLUDED.rankmultiplier"
+	_, err = db.Exec(sql, kind.CustomerID, kind.Name, nullString(kind.TitlePrefix), kind.VoteExponent, kind.TimeExponent, kind.RankMultiplier, kind.CreatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save story kind. CustomerID: %d, Name: %s", kind.CustomerID, kind.Name), err}
+	}
+	return nil
+}
+
+/*DeleteStoryKind removes the kind defined by the customer. Built-in kinds are reset to default ranking parameters. Stories keep their kind.*/
+func DeleteStoryKind(customerID int, name string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Name: %s", customerID, name), err}
+	}
+	defer db.Close()
+	sql := "DELETE FROM storykinds WHERE customerid = $1 AND name = $2"
+	_, err = db.Exec(sql, customerID, name)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot delete story kind. CustomerID: %d, Name: %s", customerID, name), err}
+	}
+	return nil
+}
+
+/*DetectStoryKind returns the kind whose title prefix the title starts with. Stories without url are ask stories and the rest are link stories if no prefix matches.*/
+func DetectStoryKind(title, url string, kinds []StoryKind) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	for _, kind := range kinds {
+		if kind.TitlePrefix == "" {
+			continue
+		}
+		if strings.HasPrefix(title, strings.ToLower(kind.TitlePrefix)) {
+			return kind.Name
+		}
+	}
+	if strings.TrimSpace(url) == "" {
+		return StoryKindAsk
+	}
+	return StoryKindLink
+}
+
+/*GetStoriesByKind returns the ranked stories of the customer which are of given kind*/
+func GetStoriesByKind(customerID int, kind string, pageNumber, pageRowCount int) (*[]Story, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sql := "SELECT " + storyColumns + ", users.UserName, stories.rank FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.kind = $2 ORDER BY stories.rank DESC LIMIT $3 OFFSET $4"
+	rows, err := db.Query(sql, customerID, kind, pageRowCount, (pageNumber-1)*pageRowCount)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot get stories by kind. Kind: %s, PageNumber: %d, PageRowCount: %d", kind, pageNumber, pageRowCount), err}
+	}
+	stories, err := MapSQLRowsToStories(rows)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot read rows. Kind: %s, PageNumber: %d, PageRowCount: %d", kind, pageNumber, pageRowCount), err}
+	}
+	return stories, nil
+}
+
+/*GetCustomerStoriesCountByKind returns the number of customer's stories which are of given kind*/
+func GetCustomerStoriesCountByKind(customerID int, kind string) (int, error) {
+	sql := "SELECT COUNT(*) FROM stories INNER JOIN users ON users.id = stories.userid WHERE users.customerid = $1 AND " + visibleStoriesSQL + " AND stories.kind = $2"
+	return count(sql, customerID, kind)
+}
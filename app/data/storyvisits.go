@@ -0,0 +1,41 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+/*GetStoryVisit returns the last time the user visited the story page. Returns nil if user has never visited it.*/
+func GetStoryVisit(userID, storyID int) (*time.Time, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d, StoryID: %d", userID, storyID), err}
+	}
+	defer db.Close()
+	query := "SELECT visitedon FROM storyvisits WHERE userid = $1 AND storyid = $2"
+	var visitedOn time.Time
+	err = db.QueryRow(query, userID, storyID).Scan(&visitedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read story visit. UserID: %d, StoryID: %d", userID, storyID), err}
+	}
+	return &visitedOn, nil
+}
+
+/*SaveStoryVisit inserts or updates the last time the user visited the story page*/
+func SaveStoryVisit(userID, storyID int, visitedOn time.Time) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d, StoryID: %d", userID, storyID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO storyvisits (userid, storyid, visitedon) VALUES ($1, $2, $3) ON CONFLICT (userid, storyid) DO UPDATE SET visitedon = EXCLUDED.visitedon"
+	_, err = db.Exec(query, userID, storyID, visitedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save story visit. UserID: %d, StoryID: %d", userID, storyID), err}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+/*Tag represents a tag of the customer's vocabulary which stories can be tagged with*/
+type Tag struct {
+	ID          int
+	CustomerID  int
+	Name        string
+	Description string
+	CreatedOn   time.Time
+}
+
+/*CreateTag adds the tag to customer's vocabulary*/
+func CreateTag(tag *Tag) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", tag.CustomerID), err}
+	}
+	defer db.Close()
+	sql := "INSERT INTO tags (customerid, name, description, createdon) VALUES ($1, $2, $3, $4) ON CONFLICT (customerid, name) DO UPDATE SET description = EXCLUDED.description RETURNING id"
+	err = db.QueryRow(sql, tag.CustomerID, tag.Name, nullString(tag.Description), tag.CreatedOn).Scan(&tag.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot create tag. CustomerID: %d, Name: %s", tag.CustomerID, tag.Name), err}
+	}
+	return nil
+}
+
+/*DeleteTag removes the tag from customer's vocabulary. Stories keep the tag but it is not listed anymore.*/
+func DeleteTag(customerID, tagID int) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, TagID: %d", customerID, tagID), err}
+	}
+	defer db.Close()
+	sql := "DELETE FROM tags WHERE id = $1 AND customerid = $2"
+	_, err = db.Exec(sql, tagID, customerID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot delete tag. CustomerID: %d, TagID: %d", customerID, tagID), err}
+	}
+	return nil
+}
+
+/*GetTagsByCustomerID returns the tag vocabulary of the customer ordered by name*/
+func GetTagsByCustomerID(customerID int) (*[]Tag, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	sql := "SELECT id, customerid, name, COALESCE(description, ''), createdon FROM tags WHERE customerid = $1 ORDER BY name"
+	rows, err := db.Query(sql, customerID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query tags. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		err = rows.Scan(&tag.ID, &tag.CustomerID, &tag.Name, &tag.Description, &tag.CreatedOn)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read tag row. CustomerID: %d", customerID), err}
+		}
+		tags = append(tags, tag)
+	}
+	return &tags, nil
+}
+
+/*GetHiddenTags returns the tags which user does not want to see on front page*/
+func GetHiddenTags(userID int) ([]string, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	sql := "SELECT tag FROM hiddentags WHERE userid = $1 ORDER BY tag"
+	rows, err := db.Query(sql, userID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query hidden tags. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		err = rows.Scan(&tag)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read hidden tag row. UserID: %d", userID), err}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+/*SaveHiddenTags replaces the hidden tags of the user*/
+func SaveHiddenTags(userID int, tags []string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot begin transaction. UserID: %d", userID), err}
+	}
+	_, err = tx.Exec("DELETE FROM hiddentags WHERE userid = $1", userID)
+	if err != nil {
+		tx.Rollback()
+		return &DBError{fmt.Sprintf("Cannot delete hidden tags. UserID: %d", userID), err}
+	}
+	if len(tags) > 0 {
+		_, err = tx.Exec("INSERT INTO hiddentags (userid, tag) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING", userID, pq.Array(tags))
+		if err != nil {
+			tx.Rollback()
+			return &DBError{fmt.Sprintf("Cannot insert hidden tags. UserID: %d", userID), err}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot commit transaction. UserID: %d", userID), err}
+	}
+	return nil
+}
@@ -2,14 +2,23 @@ package data
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 
 	/* To install postgresql driver. Check more here: https://www.calhoun.io/why-we-import-sql-drivers-with-the-blank-identifier/ */
 	_ "github.com/lib/pq"
 )
 
+const (
+	/*RoleMember represents the members who can post, comment and vote*/
+	RoleMember = "member"
+	/*RoleAdmin represents the members who can also manage the platform like its owner*/
+	RoleAdmin = "admin"
+)
+
 /*User represents the user in database*/
 type User struct {
 	ID           int
@@ -161,6 +170,58 @@ func ExistsUserByEmail(email string) (exists bool, err error) {
 	return exists, nil
 }
 
+/*GetExistingEmails returns which of the given email addresses already have an account. The addresses are compared and returned in lower case.*/
+func GetExistingEmails(emails []string) (map[string]bool, error) {
+	query := "SELECT LOWER(email) FROM users WHERE LOWER(email) = ANY($1)"
+	return getEmailSet(query, lowerEmails(emails))
+}
+
+func getEmailSet(query string, args ...interface{}) (map[string]bool, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{"DB connection error while reading email addresses", err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{"Cannot query email addresses", err}
+	}
+	defer rows.Close()
+	emails := make(map[string]bool)
+	for rows.Next() {
+		var email string
+		err = rows.Scan(&email)
+		if err != nil {
+			return nil, &DBError{"Cannot read email address row", err}
+		}
+		emails[email] = true
+	}
+	return emails, nil
+}
+
+func lowerEmails(emails []string) interface{} {
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+	return pq.Array(lowered)
+}
+
+/*SetUserRole changes the role of the user*/
+func SetUserRole(userID int, role string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "UPDATE users SET role = $2 WHERE id = $1"
+	_, err = db.Exec(query, userID, role)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot set user role. UserID: %d, Role: %s", userID, role), err}
+	}
+	return nil
+}
+
 /*ExistsUserByUserName checks if user associated with user name exists on database*/
 func ExistsUserByUserName(userName string) (exists bool, err error) {
 	exists = false
@@ -214,6 +275,16 @@ func GetUserByID(userID int) (user *User, err error) {
 	return user, nil
 }
 
+/*ExistsUserInCustomer checks whether the user is a member of the customer's platform*/
+func ExistsUserInCustomer(customerID, userID int) (bool, error) {
+	sql := "SELECT COUNT(id) FROM users WHERE id = $1 AND customerid = $2"
+	userCount, err := count(sql, userID, customerID)
+	if err != nil {
+		return false, err
+	}
+	return userCount > 0, nil
+}
+
 /*GetUsersByCustomerID retunrs users list by provided customerID parameter*/
 func GetUsersByCustomerID(customerID int) (*[]User, error) {
 	db, err := connectToDB()
@@ -339,7 +410,7 @@ func GetCustomerDomainByUserName(userName string) (*string, error) {
 	return domain, nil
 }
 
-/*IsUserAdmin return true, if user is admin*/
+/*IsUserAdmin return true, if user is the owner of the customer or has the admin role*/
 func IsUserAdmin(userID int) (isAdmin bool, err error) {
 	isAdmin = false
 	db, err := connectToDB()
@@ -347,8 +418,9 @@ func IsUserAdmin(userID int) (isAdmin bool, err error) {
 		return isAdmin, err
 	}
 	defer db.Close()
-	sql := "SELECT COUNT(*) AS count FROM customers INNER JOIN users ON users.email = customers.email WHERE users.id = $1"
-	row := db.QueryRow(sql, userID)
+	sql := "SELECT COUNT(*) AS count FROM users LEFT JOIN customers ON customers.email = users.email WHERE users.id = $1" +
+		" AND (customers.id IS NOT NULL OR users.role = $2)"
+	row := db.QueryRow(sql, userID, RoleAdmin)
 	recordCount := 0
 	err = row.Scan(&recordCount)
 	if err != nil {
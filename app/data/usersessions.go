@@ -0,0 +1,64 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+/*UserSession represents a sign in of a user. Sign ins can not be ended before they expire since auth tokens are not kept.*/
+type UserSession struct {
+	ID         int
+	UserID     int
+	CustomerID int
+	SignedInOn time.Time
+	ExpiresOn  time.Time
+	IPAddress  string
+	UserAgent  string
+}
+
+/*IsActive returns true if the auth token of the sign in has not expired yet*/
+func (session *UserSession) IsActive() bool {
+	return session.ExpiresOn.After(time.Now())
+}
+
+/*CreateUserSession records a sign in of the user*/
+func CreateUserSession(session *UserSession) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. UserID: %d", session.UserID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO usersessions (userid, customerid, signedinon, expireson, ipaddress, useragent) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err = db.QueryRow(query, session.UserID, session.CustomerID, session.SignedInOn, session.ExpiresOn,
+		nullString(session.IPAddress), nullString(session.UserAgent)).Scan(&session.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot create user session. UserID: %d", session.UserID), err}
+	}
+	return nil
+}
+
+/*GetUserSessions returns the latest sign ins of the user, the latest first*/
+func GetUserSessions(userID, limit int) (*[]UserSession, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d", userID), err}
+	}
+	defer db.Close()
+	query := "SELECT id, userid, customerid, signedinon, expireson, COALESCE(ipaddress, ''), COALESCE(useragent, '') FROM usersessions" +
+		" WHERE userid = $1 ORDER BY signedinon DESC LIMIT $2"
+	rows, err := db.Query(query, userID, limit)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query user sessions. UserID: %d", userID), err}
+	}
+	defer rows.Close()
+	sessions := []UserSession{}
+	for rows.Next() {
+		var session UserSession
+		err = rows.Scan(&session.ID, &session.UserID, &session.CustomerID, &session.SignedInOn, &session.ExpiresOn, &session.IPAddress, &session.UserAgent)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read user session row. UserID: %d", userID), err}
+		}
+		sessions = append(sessions, session)
+	}
+	return &sessions, nil
+}
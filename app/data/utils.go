@@ -55,6 +55,44 @@ func connectToDB() (*sql.DB, error) {
 	return db, err
 }
 
+func nullInt(i int) sql.NullInt32 {
+	if i == 0 {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{
+		Int32: int32(i),
+		Valid: true,
+	}
+}
+
+func nullString(value string) sql.NullString {
+	if value == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{
+		String: value,
+		Valid:  true,
+	}
+}
+
+func nullTime(t *time.Time) pq.NullTime {
+	if t == nil {
+		return pq.NullTime{}
+	}
+	return pq.NullTime{
+		Time:  *t,
+		Valid: true,
+	}
+}
+
+// timePointer returns the time of a nullable column or nil if it is null
+func timePointer(t pq.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
 /*MapSQLRowToUser creates an user struct object by sql row*/
 func MapSQLRowToUser(row *sql.Row) (user *User, err error) {
 	var _user User
@@ -110,29 +148,90 @@ func MapSQLRowToCustomer(row *sql.Row) (customer *Customer, err error) {
 	return customer, nil
 }
 
+/*MapSQLRowsToCustomers creates a customer struct array by sql rows*/
+func MapSQLRowsToCustomers(rows *sql.Rows) (customers *[]Customer, err error) {
+	_customers := []Customer{}
+	for rows.Next() {
+		var customer Customer
+		var domain sql.NullString
+		var title sql.NullString
+		err = rows.Scan(
+			&customer.ID,
+			&customer.Name,
+			&customer.Email,
+			&customer.RegisteredOn,
+			&domain,
+			&customer.LogoImage,
+			&title)
+		if err != nil {
+			return nil, &DBError{"Cannot read customer row.", err}
+		}
+		customer.Domain = CustomerDefaultDomain
+		if domain.Valid {
+			customer.Domain = domain.String
+		}
+		customer.Title = title.String
+		_customers = append(_customers, customer)
+	}
+	return &_customers, nil
+}
+
 /*MapSQLRowToInviteCodeInfo creates an invite code info struct object by sql row*/
 func MapSQLRowToInviteCodeInfo(row *sql.Row) (inviteCodeInfo *InviteCodeInfo, err error) {
 	var _inviteCodeInfo InviteCodeInfo
+	var expiresOn, lastSentOn pq.NullTime
 	err = row.Scan(
 		&_inviteCodeInfo.Code,
 		&_inviteCodeInfo.InviterUserID,
 		&_inviteCodeInfo.InvitedEmailAddress,
 		&_inviteCodeInfo.Used,
-		&_inviteCodeInfo.CreatedOn)
+		&_inviteCodeInfo.CreatedOn,
+		&expiresOn,
+		&_inviteCodeInfo.Revoked,
+		&lastSentOn,
+		&_inviteCodeInfo.FullName,
+		&_inviteCodeInfo.Role)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, &DBError{fmt.Sprintf("Cannot map sql row to invite code info struct"), err}
 	}
+	_inviteCodeInfo.ExpiresOn = timePointer(expiresOn)
+	_inviteCodeInfo.LastSentOn = timePointer(lastSentOn)
 	inviteCodeInfo = &_inviteCodeInfo
 	return inviteCodeInfo, nil
 }
 
+// storyMetadataColumns holds the nullable link metadata columns of a story row while scanning
+type storyMetadataColumns struct {
+	Description  sql.NullString
+	ImageURL     sql.NullString
+	SiteName     sql.NullString
+	CanonicalURL sql.NullString
+	PublishedOn  pq.NullTime
+}
+
+func (columns *storyMetadataColumns) toLinkMetadata() LinkMetadata {
+	metadata := LinkMetadata{
+		Description:  columns.Description.String,
+		ImageURL:     columns.ImageURL.String,
+		SiteName:     columns.SiteName.String,
+		CanonicalURL: columns.CanonicalURL.String,
+	}
+	if columns.PublishedOn.Valid {
+		publishedOn := columns.PublishedOn.Time
+		metadata.PublishedOn = &publishedOn
+	}
+	return metadata
+}
+
 /*MapSQLRowToStory creates a story struct by sql rows*/
 func MapSQLRowToStory(rows *sql.Row) (story *Story, err error) {
 	var _story Story
 	var username string
+	var metadata storyMetadataColumns
+	var canonicalURL sql.NullString
 	err = rows.Scan(
 		&_story.ID,
 		&_story.URL,
@@ -144,40 +243,70 @@ func MapSQLRowToStory(rows *sql.Row) (story *Story, err error) {
 		&_story.SubmittedOn,
 		pq.Array(&_story.Tags),
 		&_story.DownVotes,
+		&metadata.Description,
+		&metadata.ImageURL,
+		&metadata.SiteName,
+		&metadata.CanonicalURL,
+		&metadata.PublishedOn,
+		&canonicalURL,
+		&_story.Kind,
+		&_story.Hidden,
+		&_story.AuthorShadowbanned,
 		&username)
 	if err != nil {
 		return nil, &DBError{fmt.Sprintf("Cannot read rows"), err}
 	}
 	_story.UserName = username
+	_story.CanonicalURL = canonicalURL.String
+	_story.Metadata = metadata.toLinkMetadata()
 	story = &_story
 	return story, nil
 }
 
+// scanStory reads the story columns and user name of the row followed by the extra columns
+func scanStory(rows *sql.Rows, story *Story, extra ...interface{}) error {
+	var metadata storyMetadataColumns
+	var canonicalURL sql.NullString
+	columns := []interface{}{
+		&story.ID,
+		&story.URL,
+		&story.Title,
+		&story.Text,
+		&story.UpVotes,
+		&story.CommentCount,
+		&story.UserID,
+		&story.SubmittedOn,
+		pq.Array(&story.Tags),
+		&story.DownVotes,
+		&metadata.Description,
+		&metadata.ImageURL,
+		&metadata.SiteName,
+		&metadata.CanonicalURL,
+		&metadata.PublishedOn,
+		&canonicalURL,
+		&story.Kind,
+		&story.Hidden,
+		&story.AuthorShadowbanned,
+		&story.UserName,
+	}
+	err := rows.Scan(append(columns, extra...)...)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot read rows"), err}
+	}
+	story.CanonicalURL = canonicalURL.String
+	story.Metadata = metadata.toLinkMetadata()
+	return nil
+}
+
 /*MapSQLRowsToStories creates a story struct array by sql rows*/
 func MapSQLRowsToStories(rows *sql.Rows) (stories *[]Story, err error) {
 	_stories := []Story{}
-	var username string
-	var rank float64
 	for rows.Next() {
 		var story Story
-		err = rows.Scan(
-			&story.ID,
-			&story.URL,
-			&story.Title,
-			&story.Text,
-			&story.UpVotes,
-			&story.CommentCount,
-			&story.UserID,
-			&story.SubmittedOn,
-			pq.Array(&story.Tags),
-			&story.DownVotes,
-			&username,
-			&rank)
+		err = scanStory(rows, &story, &story.Rank)
 		if err != nil {
-			return nil, &DBError{fmt.Sprintf("Cannot read rows"), err}
+			return nil, err
 		}
-		story.UserName = username
-		story.CalculateStoryRank = rank
 		_stories = append(_stories, story)
 	}
 	return &_stories, nil
@@ -186,25 +315,12 @@ func MapSQLRowsToStories(rows *sql.Rows) (stories *[]Story, err error) {
 /*MapSQLRowsToRecentStories creates a recent story struct array by sql rows*/
 func MapSQLRowsToRecentStories(rows *sql.Rows) (stories *[]Story, err error) {
 	_stories := []Story{}
-	var username string
 	for rows.Next() {
 		var story Story
-		err = rows.Scan(
-			&story.ID,
-			&story.URL,
-			&story.Title,
-			&story.Text,
-			&story.UpVotes,
-			&story.CommentCount,
-			&story.UserID,
-			&story.SubmittedOn,
-			pq.Array(&story.Tags),
-			&story.DownVotes,
-			&username)
+		err = scanStory(rows, &story)
 		if err != nil {
-			return nil, &DBError{fmt.Sprintf("Cannot read rows"), err}
+			return nil, err
 		}
-		story.UserName = username
 		_stories = append(_stories, story)
 	}
 	return &_stories, nil
@@ -226,6 +342,8 @@ func MapSQLRowsToComments(rows *sql.Rows) (comments *[]Comment, err error) {
 			&comment.CommentedOn,
 			&comment.ID,
 			&comment.DownVotes,
+			&comment.Hidden,
+			&comment.AuthorShadowbanned,
 			&comment.UserName)
 		if err != nil {
 			return nil, &DBError{"Cannot read comment row.", err}
@@ -285,6 +403,8 @@ func MapSQLRowsToReplies(rows *sql.Rows) (replies *[]Reply, err error) {
 			&comment.UserID,
 			&comment.CommentedOn,
 			&comment.ID,
+			&comment.Hidden,
+			&comment.AuthorShadowbanned,
 			&storyTitle,
 			&storyID,
 			&userName)
@@ -0,0 +1,375 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"linkwind/app/enums"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	/*VoteFlagReciprocal represents the users who upvote each other repeatedly*/
+	VoteFlagReciprocal = "reciprocal"
+	/*VoteFlagNewAccountBurst represents the upvotes which a story receives from fresh accounts in a short period*/
+	VoteFlagNewAccountBurst = "burst"
+	/*VoteFlagSameIP represents the upvotes which a story receives from different users with the same ip address*/
+	VoteFlagSameIP = "sameip"
+
+	/*VoteFlagOpen represents the flags which are waiting for a decision*/
+	VoteFlagOpen = "open"
+	/*VoteFlagNullified represents the flags whose votes are removed*/
+	VoteFlagNullified = "nullified"
+	/*VoteFlagDismissed represents the flags whose votes are found legitimate*/
+	VoteFlagDismissed = "dismissed"
+
+	/*VoteTargetStory represents the votes given to stories*/
+	VoteTargetStory = "story"
+	/*VoteTargetComment represents the votes given to comments*/
+	VoteTargetComment = "comment"
+)
+
+/*VoteRules represents the requirements a user of the customer must meet to vote*/
+type VoteRules struct {
+	CustomerID        int
+	MinAccountAgeDays int
+	MinKarma          int
+	UpdatedOn         time.Time
+}
+
+/*VoteFlag represents a suspicious voting pattern which is detected by vote analysis and the votes which are part of it*/
+type VoteFlag struct {
+	ID          int
+	CustomerID  int
+	Reason      string
+	Fingerprint string
+	Evidence    string
+	UserIDs     []int
+	UserNames   []string
+	StoryID     int
+	StoryTitle  string
+	Status      string
+	DetectedOn  time.Time
+	ResolvedOn  *time.Time
+	Votes       []FlaggedVote
+}
+
+/*FlaggedVote represents a vote to a story or comment which is part of a vote flag*/
+type FlaggedVote struct {
+	TargetType string
+	TargetID   int
+	UserID     int
+	VoteType   enums.VoteType
+}
+
+/*ReciprocalVoters represents two users who upvoted each other's stories and comments*/
+type ReciprocalVoters struct {
+	UserID             int
+	UserName           string
+	OtherUserID        int
+	OtherUserName      string
+	VoteCount          int
+	OtherUserVoteCount int
+}
+
+/*StoryVoteCluster represents the upvotes of a story which are grouped by a suspicious common property like the hour or ip address*/
+type StoryVoteCluster struct {
+	StoryID      int
+	Key          string
+	UserIDs      []int
+	InviterNames []string
+}
+
+/*GetVoteRules returns the vote rules of the customer. Returns the rules without requirements if customer has not set them yet.*/
+func GetVoteRules(customerID int) (*VoteRules, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT customerid, minaccountagedays, minkarma, updatedon FROM voterules WHERE customerid = $1"
+	rules := &VoteRules{}
+	err = db.QueryRow(query, customerID).Scan(&rules.CustomerID, &rules.MinAccountAgeDays, &rules.MinKarma, &rules.UpdatedOn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &VoteRules{CustomerID: customerID}, nil
+		}
+		return nil, &DBError{fmt.Sprintf("Cannot read vote rules. CustomerID: %d", customerID), err}
+	}
+	return rules, nil
+}
+
+/*SaveVoteRules inserts or updates the vote rules of the customer*/
+func SaveVoteRules(rules *VoteRules) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", rules.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO voterules (customerid, minaccountagedays, minkarma, updatedon) VALUES ($1, $2, $3, $4) ON CONFLICT (customerid) DO UPDATE SET minaccountagedays = EXCLUDED.minaccountagedays, minkarma = EXCLUDED.minkarma, updatedon = EXCLUDED.updatedon"
+	_, err = db.Exec(query, rules.CustomerID, rules.MinAccountAgeDays, rules.MinKarma, rules.UpdatedOn)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot save vote rules. CustomerID: %d", rules.CustomerID), err}
+	}
+	return nil
+}
+
+// customerUpvotesSQL selects the voter, the author and the target of the upvotes which customer's stories and comments received since $2
+const customerUpvotesSQL = "SELECT storyvotes.userid AS voterid, stories.userid AS authorid FROM storyvotes INNER JOIN stories ON stories.id = storyvotes.storyid INNER JOIN users ON users.id = stories.userid" +
+	" WHERE users.customerid = $1 AND storyvotes.votetype = 1 AND storyvotes.votedon >= $2 AND storyvotes.userid <> stories.userid" +
+	" UNION ALL SELECT commentvotes.userid, comments.userid FROM commentvotes INNER JOIN comments ON comments.id = commentvotes.commentid INNER JOIN users ON users.id = comments.userid" +
+	" WHERE users.customerid = $1 AND commentvotes.votetype = 1 AND commentvotes.votedon >= $2 AND commentvotes.userid <> comments.userid"
+
+/*GetReciprocalVoters returns the users of the customer who upvoted each other at least minVotes times since given time*/
+func GetReciprocalVoters(customerID int, since time.Time, minVotes int) (*[]ReciprocalVoters, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "WITH pairs AS (SELECT voterid, authorid, COUNT(*) AS votecount FROM (" + customerUpvotesSQL + ") upvotes GROUP BY voterid, authorid)" +
+		" SELECT a.voterid, voters.username, a.authorid, authors.username, a.votecount, b.votecount FROM pairs a" +
+		" INNER JOIN pairs b ON a.voterid = b.authorid AND a.authorid = b.voterid" +
+		" INNER JOIN users voters ON voters.id = a.voterid INNER JOIN users authors ON authors.id = a.authorid" +
+		" WHERE a.voterid < a.authorid AND a.votecount >= $3 AND b.votecount >= $3"
+	rows, err := db.Query(query, customerID, since, minVotes)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query reciprocal voters. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	voters := []ReciprocalVoters{}
+	for rows.Next() {
+		var pair ReciprocalVoters
+		err = rows.Scan(&pair.UserID, &pair.UserName, &pair.OtherUserID, &pair.OtherUserName, &pair.VoteCount, &pair.OtherUserVoteCount)
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read reciprocal voters row. CustomerID: %d", customerID), err}
+		}
+		voters = append(voters, pair)
+	}
+	return &voters, nil
+}
+
+/*GetUpvotesBetweenUsers returns the upvotes which the users gave to each other's stories and comments since given time*/
+func GetUpvotesBetweenUsers(userID, otherUserID int, since time.Time) (*[]FlaggedVote, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. UserID: %d, OtherUserID: %d", userID, otherUserID), err}
+	}
+	defer db.Close()
+	query := "SELECT 'story', storyvotes.storyid, storyvotes.userid, storyvotes.votetype FROM storyvotes INNER JOIN stories ON stories.id = storyvotes.storyid" +
+		" WHERE storyvotes.votetype = 1 AND storyvotes.votedon >= $3 AND ((storyvotes.userid = $1 AND stories.userid = $2) OR (storyvotes.userid = $2 AND stories.userid = $1))" +
+		" UNION ALL SELECT 'comment', commentvotes.commentid, commentvotes.userid, commentvotes.votetype FROM commentvotes INNER JOIN comments ON comments.id = commentvotes.commentid" +
+		" WHERE commentvotes.votetype = 1 AND commentvotes.votedon >= $3 AND ((commentvotes.userid = $1 AND comments.userid = $2) OR (commentvotes.userid = $2 AND comments.userid = $1))"
+	rows, err := db.Query(query, userID, otherUserID, since)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query upvotes between users. UserID: %d, OtherUserID: %d", userID, otherUserID), err}
+	}
+	return mapSQLRowsToFlaggedVotes(rows)
+}
+
+/*GetNewAccountVoteBursts returns the stories of the customer which received at least minVotes upvotes in an hour from accounts younger than maxAccountAge since given time. Keys of the clusters are the hours.*/
+func GetNewAccountVoteBursts(customerID int, since time.Time, maxAccountAge time.Duration, minVotes int) (*[]StoryVoteCluster, error) {
+	query := "SELECT storyvotes.storyid, to_char(date_trunc('hour', storyvotes.votedon), 'YYYY-MM-DD HH24:00 TZ'), array_agg(storyvotes.userid ORDER BY storyvotes.userid)," +
+		" COALESCE(array_agg(DISTINCT inviters.username) FILTER (WHERE inviters.username IS NOT NULL), '{}')" +
+		" FROM storyvotes INNER JOIN users ON users.id = storyvotes.userid" + inviterJoins + " LEFT JOIN users inviters ON inviters.id = " + inviterIDSQL +
+		" WHERE users.customerid = $1 AND storyvotes.votetype = 1 AND storyvotes.votedon >= $2 AND EXTRACT(EPOCH FROM storyvotes.votedon - users.registeredon) < $3" +
+		" GROUP BY storyvotes.storyid, date_trunc('hour', storyvotes.votedon) HAVING COUNT(*) >= $4"
+	return getStoryVoteClusters(query, customerID, since, maxAccountAge.Seconds(), minVotes)
+}
+
+/*GetSameIPVoteClusters returns the stories of the customer which received upvotes from at least minUsers different users with the same ip address since given time. Keys of the clusters are the ip addresses.*/
+func GetSameIPVoteClusters(customerID int, since time.Time, minUsers int) (*[]StoryVoteCluster, error) {
+	query := "SELECT storyvotes.storyid, storyvotes.ipaddress, array_agg(storyvotes.userid ORDER BY storyvotes.userid), '{}'::text[]" +
+		" FROM storyvotes INNER JOIN users voters ON voters.id = storyvotes.userid" +
+		" WHERE voters.customerid = $1 AND storyvotes.votetype = 1 AND storyvotes.votedon >= $2 AND storyvotes.ipaddress IS NOT NULL" +
+		" GROUP BY storyvotes.storyid, storyvotes.ipaddress HAVING COUNT(DISTINCT storyvotes.userid) >= $3"
+	return getStoryVoteClusters(query, customerID, since, minUsers)
+}
+
+func getStoryVoteClusters(query string, customerID int, args ...interface{}) (*[]StoryVoteCluster, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	rows, err := db.Query(query, append([]interface{}{customerID}, args...)...)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query story vote clusters. CustomerID: %d", customerID), err}
+	}
+	defer rows.Close()
+	clusters := []StoryVoteCluster{}
+	for rows.Next() {
+		var cluster StoryVoteCluster
+		var userIDs []int64
+		err = rows.Scan(&cluster.StoryID, &cluster.Key, pq.Array(&userIDs), pq.Array(&cluster.InviterNames))
+		if err != nil {
+			return nil, &DBError{fmt.Sprintf("Cannot read story vote cluster row. CustomerID: %d", customerID), err}
+		}
+		for _, id := range userIDs {
+			cluster.UserIDs = append(cluster.UserIDs, int(id))
+		}
+		clusters = append(clusters, cluster)
+	}
+	return &clusters, nil
+}
+
+/*SaveVoteFlag inserts the flag with its votes. If the same pattern is flagged before and still open, its evidence, users and votes are updated. Resolved flags are not reopened.*/
+func SaveVoteFlag(flag *VoteFlag) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, Fingerprint: %s", flag.CustomerID, flag.Fingerprint), err}
+	}
+	defer db.Close()
+	tran, err := db.Begin()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot begin transaction. CustomerID: %d, Fingerprint: %s", flag.CustomerID, flag.Fingerprint), err}
+	}
+	userIDs := make([]int64, len(flag.UserIDs))
+	for i, id := range flag.UserIDs {
+		userIDs[i] = int64(id)
+	}
+	query := "INSERT INTO voteflags (customerid, reason, fingerprint, evidence, userids, storyid, status, detectedon) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)" +
+		" ON CONFLICT (customerid, fingerprint) DO UPDATE SET evidence = EXCLUDED.evidence, userids = EXCLUDED.userids WHERE voteflags.status = $7 RETURNING id"
+	err = tran.QueryRow(query, flag.CustomerID, flag.Reason, flag.Fingerprint, flag.Evidence, pq.Array(userIDs), nullInt(flag.StoryID), VoteFlagOpen, flag.DetectedOn).Scan(&flag.ID)
+	if err != nil {
+		tran.Rollback()
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return &DBError{fmt.Sprintf("Cannot save vote flag. CustomerID: %d, Fingerprint: %s", flag.CustomerID, flag.Fingerprint), err}
+	}
+	query = "INSERT INTO voteflagvotes (flagid, targettype, targetid, userid, votetype) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING"
+	for _, vote := range flag.Votes {
+		_, err = tran.Exec(query, flag.ID, vote.TargetType, vote.TargetID, vote.UserID, vote.VoteType)
+		if err != nil {
+			tran.Rollback()
+			return &DBError{fmt.Sprintf("Cannot save flagged vote. FlagID: %d, TargetType: %s, TargetID: %d", flag.ID, vote.TargetType, vote.TargetID), err}
+		}
+	}
+	err = tran.Commit()
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot commit transaction. FlagID: %d", flag.ID), err}
+	}
+	return nil
+}
+
+// voteFlagColumns selects the flag with the names of its users and the title of its story
+const voteFlagColumns = "voteflags.id, voteflags.customerid, voteflags.reason, voteflags.fingerprint, voteflags.evidence, voteflags.userids," +
+	" ARRAY(SELECT users.username FROM users WHERE users.id = ANY(voteflags.userids) ORDER BY users.username)," +
+	" COALESCE(voteflags.storyid, 0), COALESCE(stories.title, ''), voteflags.status, voteflags.detectedon, voteflags.resolvedon"
+
+/*GetVoteFlags returns the latest flags of the customer which are in given status*/
+func GetVoteFlags(customerID int, status string, count int) (*[]VoteFlag, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", customerID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + voteFlagColumns + " FROM voteflags LEFT JOIN stories ON stories.id = voteflags.storyid WHERE voteflags.customerid = $1 AND voteflags.status = $2 ORDER BY voteflags.detectedon DESC LIMIT $3"
+	rows, err := db.Query(query, customerID, status, count)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query vote flags. CustomerID: %d, Status: %s", customerID, status), err}
+	}
+	defer rows.Close()
+	flags := []VoteFlag{}
+	for rows.Next() {
+		flag, err := scanVoteFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	return &flags, nil
+}
+
+/*GetVoteFlagByID returns the flag of the customer with its votes. Returns nil if there is no such flag.*/
+func GetVoteFlagByID(customerID, flagID int) (*VoteFlag, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, FlagID: %d", customerID, flagID), err}
+	}
+	defer db.Close()
+	query := "SELECT " + voteFlagColumns + " FROM voteflags LEFT JOIN stories ON stories.id = voteflags.storyid WHERE voteflags.customerid = $1 AND voteflags.id = $2"
+	rows, err := db.Query(query, customerID, flagID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query vote flag. CustomerID: %d, FlagID: %d", customerID, flagID), err}
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, nil
+	}
+	flag, err := scanVoteFlag(rows)
+	if err != nil {
+		return nil, err
+	}
+	query = "SELECT targettype, targetid, userid, votetype FROM voteflagvotes WHERE flagid = $1"
+	voteRows, err := db.Query(query, flagID)
+	if err != nil {
+		return nil, &DBError{fmt.Sprintf("Cannot query flagged votes. FlagID: %d", flagID), err}
+	}
+	votes, err := mapSQLRowsToFlaggedVotes(voteRows)
+	if err != nil {
+		return nil, err
+	}
+	flag.Votes = *votes
+	return flag, nil
+}
+
+/*ResolveVoteFlag sets the status of the flag as nullified or dismissed*/
+func ResolveVoteFlag(flagID int, status string) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. FlagID: %d", flagID), err}
+	}
+	defer db.Close()
+	query := "UPDATE voteflags SET status = $2, resolvedon = $3 WHERE id = $1"
+	_, err = db.Exec(query, flagID, status, time.Now())
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot resolve vote flag. FlagID: %d, Status: %s", flagID, status), err}
+	}
+	return nil
+}
+
+func scanVoteFlag(rows *sql.Rows) (*VoteFlag, error) {
+	flag := &VoteFlag{}
+	var userIDs []int64
+	var resolvedOn sql.NullTime
+	err := rows.Scan(
+		&flag.ID,
+		&flag.CustomerID,
+		&flag.Reason,
+		&flag.Fingerprint,
+		&flag.Evidence,
+		pq.Array(&userIDs),
+		pq.Array(&flag.UserNames),
+		&flag.StoryID,
+		&flag.StoryTitle,
+		&flag.Status,
+		&flag.DetectedOn,
+		&resolvedOn)
+	if err != nil {
+		return nil, &DBError{"Cannot read vote flag row.", err}
+	}
+	for _, id := range userIDs {
+		flag.UserIDs = append(flag.UserIDs, int(id))
+	}
+	if resolvedOn.Valid {
+		flag.ResolvedOn = &resolvedOn.Time
+	}
+	return flag, nil
+}
+
+func mapSQLRowsToFlaggedVotes(rows *sql.Rows) (*[]FlaggedVote, error) {
+	defer rows.Close()
+	votes := []FlaggedVote{}
+	for rows.Next() {
+		var vote FlaggedVote
+		err := rows.Scan(&vote.TargetType, &vote.TargetID, &vote.UserID, &vote.VoteType)
+		if err != nil {
+			return nil, &DBError{"Cannot read flagged vote row.", err}
+		}
+		votes = append(votes, vote)
+	}
+	return &votes, nil
+}
@@ -0,0 +1,224 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+/*Webhook represents the url which tenant events are delivered to*/
+type Webhook struct {
+	ID         int
+	CustomerID int
+	URL        string
+	Secret     string
+	Events     []string
+	Active     bool
+	CreatedOn  time.Time
+}
+
+/*WebhookDelivery represents an attempt to deliver an event to a webhook*/
+type WebhookDelivery struct {
+	ID           int
+	WebhookID    int
+	Event        string
+	Payload      string
+	ResponseCode int
+	Error        string
+	Attempts     int
+	CreatedOn    time.Time
+	DeliveredOn  *time.Time
+}
+
+/*CreateWebhook creates a webhook on database*/
+func CreateWebhook(webhook *Webhook) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d", webhook.CustomerID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO webhooks (customerid, url, secret, events, active, createdon) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err = db.QueryRow(
+		query,
+		webhook.CustomerID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		webhook.CreatedOn).Scan(&webhook.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot create webhook. CustomerID: %d, URL: %s", webhook.CustomerID, webhook.URL), err}
+	}
+	return nil
+}
+
+/*DeleteWebhook deletes customer's webhook from database*/
+func DeleteWebhook(customerID, webhookID int) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. CustomerID: %d, WebhookID: %d", customerID, webhookID), err}
+	}
+	defer db.Close()
+	query := "DELETE FROM webhooks WHERE id = $1 AND customerid = $2"
+	_, err = db.Exec(query, webhookID, customerID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot delete webhook. CustomerID: %d, WebhookID: %d", customerID, webhookID), err}
+	}
+	return nil
+}
+
+/*GetWebhooksByCustomerID returns all webhooks of the customer*/
+func GetWebhooksByCustomerID(customerID int) (*[]Webhook, error) {
+	query := "SELECT id, customerid, url, secret, events, active, createdon FROM webhooks WHERE customerid = $1 ORDER BY createdon DESC"
+	return queryWebhooks(query, customerID)
+}
+
+/*GetActiveWebhooksByEvent returns customer's active webhooks which subscribed to given event*/
+func GetActiveWebhooksByEvent(customerID int, event string) (*[]Webhook, error) {
+	query := "SELECT id, customerid, url, secret, events, active, createdon FROM webhooks WHERE customerid = $1 AND active = true AND $2 = ANY(events)"
+	return queryWebhooks(query, customerID, event)
+}
+
+/*GetWebhookByID gets customer's webhook by id from database*/
+func GetWebhookByID(customerID, webhookID int) (*Webhook, error) {
+	query := "SELECT id, customerid, url, secret, events, active, createdon FROM webhooks WHERE id = $1 AND customerid = $2"
+	webhooks, err := queryWebhooks(query, webhookID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(*webhooks) == 0 {
+		return nil, nil
+	}
+	return &(*webhooks)[0], nil
+}
+
+func queryWebhooks(query string, args ...interface{}) (*[]Webhook, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{"Cannot query webhooks.", err}
+	}
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook := Webhook{}
+		err = rows.Scan(
+			&webhook.ID,
+			&webhook.CustomerID,
+			&webhook.URL,
+			&webhook.Secret,
+			pq.Array(&webhook.Events),
+			&webhook.Active,
+			&webhook.CreatedOn)
+		if err != nil {
+			return nil, &DBError{"Cannot read webhook row.", err}
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return &webhooks, nil
+}
+
+/*CreateWebhookDelivery creates a delivery record for the event which will be sent to webhook*/
+func CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. WebhookID: %d", delivery.WebhookID), err}
+	}
+	defer db.Close()
+	query := "INSERT INTO webhookdeliveries (webhookid, event, payload, attempts, createdon) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = db.QueryRow(
+		query,
+		delivery.WebhookID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Attempts,
+		delivery.CreatedOn).Scan(&delivery.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot create webhook delivery. WebhookID: %d, Event: %s", delivery.WebhookID, delivery.Event), err}
+	}
+	return nil
+}
+
+/*UpdateWebhookDeliveryResult updates the result of the latest delivery attempt*/
+func UpdateWebhookDeliveryResult(delivery *WebhookDelivery) error {
+	db, err := connectToDB()
+	if err != nil {
+		return &DBError{fmt.Sprintf("DB connection error. DeliveryID: %d", delivery.ID), err}
+	}
+	defer db.Close()
+	query := "UPDATE webhookdeliveries SET responsecode = $1, error = $2, attempts = $3, deliveredon = $4 WHERE id = $5"
+	_, err = db.Exec(
+		query,
+		nullInt(delivery.ResponseCode),
+		nullString(delivery.Error),
+		delivery.Attempts,
+		delivery.DeliveredOn,
+		delivery.ID)
+	if err != nil {
+		return &DBError{fmt.Sprintf("Cannot update webhook delivery. DeliveryID: %d", delivery.ID), err}
+	}
+	return nil
+}
+
+/*GetWebhookDeliveries returns the latest deliveries of the webhook*/
+func GetWebhookDeliveries(webhookID, count int) (*[]WebhookDelivery, error) {
+	query := "SELECT id, webhookid, event, payload, responsecode, error, attempts, createdon, deliveredon FROM webhookdeliveries WHERE webhookid = $1 ORDER BY createdon DESC LIMIT $2"
+	return queryWebhookDeliveries(query, webhookID, count)
+}
+
+/*GetWebhookDeliveryByID gets the delivery of the customer's webhook by id*/
+func GetWebhookDeliveryByID(customerID, deliveryID int) (*WebhookDelivery, error) {
+	query := "SELECT webhookdeliveries.id, webhookid, event, payload, responsecode, error, attempts, webhookdeliveries.createdon, deliveredon FROM webhookdeliveries INNER JOIN webhooks ON webhooks.id = webhookdeliveries.webhookid WHERE webhookdeliveries.id = $1 AND webhooks.customerid = $2"
+	deliveries, err := queryWebhookDeliveries(query, deliveryID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(*deliveries) == 0 {
+		return nil, nil
+	}
+	return &(*deliveries)[0], nil
+}
+
+func queryWebhookDeliveries(query string, args ...interface{}) (*[]WebhookDelivery, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, &DBError{"Cannot query webhook deliveries.", err}
+	}
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		delivery := WebhookDelivery{}
+		var responseCode sql.NullInt32
+		var deliveryError sql.NullString
+		var deliveredOn sql.NullTime
+		err = rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&delivery.Payload,
+			&responseCode,
+			&deliveryError,
+			&delivery.Attempts,
+			&delivery.CreatedOn,
+			&deliveredOn)
+		if err != nil {
+			return nil, &DBError{"Cannot read webhook delivery row.", err}
+		}
+		delivery.ResponseCode = int(responseCode.Int32)
+		delivery.Error = deliveryError.String
+		if deliveredOn.Valid {
+			delivery.DeliveredOn = &deliveredOn.Time
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return &deliveries, nil
+}
@@ -9,3 +9,83 @@ const (
 	/*DownVote represents the negative vote for stories and comments.*/
 	DownVote VoteType = 2
 )
+
+/*DigestFrequency represents how often a user receives the top stories digest email.*/
+type DigestFrequency int
+
+const (
+	/*DigestNever represents that the user does not want to receive digest emails.*/
+	DigestNever DigestFrequency = 0
+	/*DigestDaily represents the digest email which is sent once a day.*/
+	DigestDaily DigestFrequency = 1
+	/*DigestWeekly represents the digest email which is sent once a week.*/
+	DigestWeekly DigestFrequency = 2
+)
+
+/*WebhookEvent represents the tenant events which can be delivered to registered webhooks.*/
+type WebhookEvent string
+
+const (
+	/*StoryCreated represents the event which is emitted when a story is submitted.*/
+	StoryCreated WebhookEvent = "story.created"
+	/*CommentCreated represents the event which is emitted when a comment or reply is written.*/
+	CommentCreated WebhookEvent = "comment.created"
+	/*VoteCast represents the event which is emitted when a story or comment is voted.*/
+	VoteCast WebhookEvent = "vote.cast"
+	/*UserJoined represents the event which is emitted when a new user signs up.*/
+	UserJoined WebhookEvent = "user.joined"
+)
+
+/*WebhookEvents contains all events which webhooks can subscribe to.*/
+var WebhookEvents = []WebhookEvent{StoryCreated, CommentCreated, VoteCast, UserJoined}
+
+/*RankingAlgorithm represents the algorithm which orders the stories on front page.*/
+type RankingAlgorithm string
+
+const (
+	/*GravityRanking represents the Hacker News style ranking which decays votes by age with a gravity.*/
+	GravityRanking RankingAlgorithm = "gravity"
+	/*HotRanking represents the Reddit hot ranking which adds logarithm of votes to submission time.*/
+	HotRanking RankingAlgorithm = "hot"
+	/*WilsonRanking represents the ranking by lower bound of Wilson score confidence interval of votes.*/
+	WilsonRanking RankingAlgorithm = "wilson"
+	/*TimeRanking represents the ranking by submission time only.*/
+	TimeRanking RankingAlgorithm = "time"
+)
+
+/*RankingAlgorithms contains all algorithms which customers can select.*/
+var RankingAlgorithms = []RankingAlgorithm{GravityRanking, HotRanking, WilsonRanking, TimeRanking}
+
+/*CommentSort represents the order of the comments on story page.*/
+type CommentSort string
+
+const (
+	/*BestComments represents the order by lower bound of Wilson score confidence interval of comment votes.*/
+	BestComments CommentSort = "best"
+	/*NewestComments represents the order which lists the latest comments first.*/
+	NewestComments CommentSort = "newest"
+	/*OldestComments represents the order which lists the earliest comments first.*/
+	OldestComments CommentSort = "oldest"
+	/*ControversialComments represents the order which lists the comments with evenly split votes first.*/
+	ControversialComments CommentSort = "controversial"
+)
+
+/*CommentSorts contains all orders which users can select for comments.*/
+var CommentSorts = []CommentSort{BestComments, NewestComments, OldestComments, ControversialComments}
+
+/*KarmaSource represents the source of the karma entries in karma ledger.*/
+type KarmaSource string
+
+const (
+	/*StoryVoteKarma represents the karma which is earned or lost by upvotes of the user's stories.*/
+	StoryVoteKarma KarmaSource = "storyvote"
+	/*CommentVoteKarma represents the karma which is earned or lost by upvotes of the user's comments.*/
+	CommentVoteKarma KarmaSource = "commentvote"
+	/*AdjustmentKarma represents the karma which is given or taken by an admin as a moderation decision.*/
+	AdjustmentKarma KarmaSource = "adjustment"
+	/*BonusKarma represents the karma which is granted by an admin as a reward.*/
+	BonusKarma KarmaSource = "bonus"
+)
+
+/*KarmaAdjustmentSources contains the sources which admins can issue karma entries with.*/
+var KarmaAdjustmentSources = []KarmaSource{AdjustmentKarma, BonusKarma}
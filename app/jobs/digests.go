@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/shared"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	/*DigestStoryCount represents the number of top stories included in a digest mail*/
+	DigestStoryCount    = 10
+	digestCheckInterval = time.Hour
+)
+
+/*DigestPeriod returns the display name and the duration which the given digest frequency covers*/
+func DigestPeriod(frequency enums.DigestFrequency) (string, time.Duration) {
+	if frequency == enums.DigestWeekly {
+		return "weekly", 7 * 24 * time.Hour
+	}
+	return "daily", 24 * time.Hour
+}
+
+/*GetDigestStories returns customer's top stories for the period of given digest frequency*/
+func GetDigestStories(customerID int, frequency enums.DigestFrequency, now time.Time) (*[]data.Story, error) {
+	_, period := DigestPeriod(frequency)
+	return data.GetTopStoriesSince(customerID, now.Add(-period), DigestStoryCount)
+}
+
+/*StartDigestJob starts the background job which sends digest mails to the subscribed users of every customer*/
+func StartDigestJob() {
+	go func() {
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+		for {
+			sendDigests(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func sendDigests(now time.Time) {
+	customers, err := data.GetCustomers()
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	for _, customer := range *customers {
+		sendCustomerDigests(&customer, enums.DigestDaily, now)
+		sendCustomerDigests(&customer, enums.DigestWeekly, now)
+	}
+}
+
+func sendCustomerDigests(customer *data.Customer, frequency enums.DigestFrequency, now time.Time) {
+	periodName, period := DigestPeriod(frequency)
+	subscribers, err := data.GetDigestSubscribers(customer.ID, frequency)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	if len(*subscribers) == 0 {
+		return
+	}
+	stories, err := GetDigestStories(customer.ID, frequency, now)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	if len(*stories) == 0 {
+		return
+	}
+	var domain *string
+	if customer.Domain != data.CustomerDefaultDomain {
+		domain = &customer.Domain
+	}
+	for _, subscriber := range *subscribers {
+		if subscriber.LastSentOn != nil && now.Sub(*subscriber.LastSentOn) < period {
+			continue
+		}
+		token, err := shared.GenerateUnsubscribeToken(subscriber.UserID)
+		if err != nil {
+			sentry.CaptureException(err)
+			continue
+		}
+		err = shared.SendDigestMail(shared.DigestMailInfo{
+			Email:            subscriber.Email,
+			UserName:         subscriber.UserName,
+			Domain:           domain,
+			Platform:         customer.Name,
+			PeriodName:       periodName,
+			Stories:          *stories,
+			UnsubscribeToken: token,
+		})
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("Cannot send digest mail. UserID: %d, Error: %v", subscriber.UserID, err))
+			continue
+		}
+		err = data.MarkDigestAsSent(subscriber.UserID, now)
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+}
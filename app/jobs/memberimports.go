@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const memberImportInterval = time.Minute
+
+var memberImportQueued = make(chan struct{}, 1)
+
+/*StartMemberImportJob starts the background job which sends the queued invitation mails of the member imports of every customer*/
+func StartMemberImportJob() {
+	go func() {
+		ticker := time.NewTicker(memberImportInterval)
+		defer ticker.Stop()
+		for {
+			sendMemberImports()
+			select {
+			case <-ticker.C:
+			case <-memberImportQueued:
+			}
+		}
+	}()
+}
+
+/*NotifyMemberImportQueued makes the member import job send the queued invitation mails without waiting for its next run*/
+func NotifyMemberImportQueued() {
+	select {
+	case memberImportQueued <- struct{}{}:
+	default:
+	}
+}
+
+func sendMemberImports() {
+	memberImports, err := data.GetSendingMemberImports()
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	for _, memberImport := range *memberImports {
+		sendMemberImport(&memberImport)
+	}
+}
+
+func sendMemberImport(memberImport *data.MemberImport) {
+	customer, err := data.GetCustomerByID(memberImport.CustomerID)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	rows, err := data.GetQueuedMemberImportRows(memberImport.ID)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	var domain *string
+	if customer.Domain != data.CustomerDefaultDomain {
+		domain = &customer.Domain
+	}
+	// the invites are sent on behalf of the platform if the admin who imported them is removed
+	inviterName := memberImport.CreatedByName
+	if inviterName == "" {
+		inviterName = customer.Name
+	}
+	for _, row := range *rows {
+		err = shared.SendEmailInvitation(shared.InviteMailInfo{
+			Domain:     domain,
+			InviteCode: row.InviteCode,
+			Email:      row.Email,
+			UserName:   inviterName,
+			Memo:       memberImport.Memo,
+			Platform:   customer.Name,
+		})
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("Cannot send invitation mail. ImportID: %d, RowID: %d, Error: %v", memberImport.ID, row.ID, err))
+			err = data.MarkMemberImportRowFailed(row.ID, "The invitation mail could not be sent. The invite can be sent again on the My Invites page of "+inviterName+".")
+		} else {
+			err = data.MarkMemberImportRowSent(&row)
+		}
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+	}
+	err = data.CompleteMemberImport(memberImport.ID)
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+}
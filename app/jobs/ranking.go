@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/ranking"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	/*RankRefreshWindow represents how long the ranks of stories are refreshed after they are submitted. Older stories keep their last rank.*/
+	RankRefreshWindow   = 14 * 24 * time.Hour
+	rankRefreshInterval = 5 * time.Minute
+)
+
+/*StartRankingJob starts the background job which refreshes the precalculated ranks of recent stories of every customer*/
+func StartRankingJob() {
+	go func() {
+		ticker := time.NewTicker(rankRefreshInterval)
+		defer ticker.Stop()
+		for {
+			refreshRanks(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func refreshRanks(now time.Time) {
+	customers, err := data.GetCustomers()
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	for _, customer := range *customers {
+		err = ranking.RefreshCustomerRanks(customer.ID, now.Add(-RankRefreshWindow))
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/voting"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const voteAnalysisInterval = time.Hour
+
+/*StartVoteAnalysisJob starts the background job which flags the suspicious voting patterns of every customer*/
+func StartVoteAnalysisJob() {
+	go func() {
+		ticker := time.NewTicker(voteAnalysisInterval)
+		defer ticker.Stop()
+		for {
+			analyzeVotes(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func analyzeVotes(now time.Time) {
+	customers, err := data.GetCustomers()
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	for _, customer := range *customers {
+		_, err = voting.AnalyzeVotes(customer.ID, now.Add(-voting.AnalysisWindow))
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"linkwind/app/controllers"
+	"linkwind/app/data"
+	"linkwind/app/jobs"
 	"linkwind/app/middlewares"
 	"linkwind/app/shared"
 	"log"
@@ -49,6 +52,13 @@ func init() {
 }
 
 func main() {
+	rebuildKarmaFlag := flag.Bool("rebuild-karma", false, "derives karma of all users from the karma ledger and exits")
+	flag.Parse()
+	if *rebuildKarmaFlag {
+		rebuildKarma()
+		return
+	}
+
 	router := http.NewServeMux()
 	configuredRouter := configureRouter(router)
 
@@ -57,6 +67,11 @@ func main() {
 		sentry.CaptureException(err)
 		panic(err)
 	}
+	jobs.StartDigestJob()
+	jobs.StartRankingJob()
+	jobs.StartVoteAnalysisJob()
+	jobs.StartMemberImportJob()
+
 	fmt.Println(fmt.Sprintf("Application is work on port %d", port))
 	// Start our HTTP server
 	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), configuredRouter); err != nil {
@@ -65,16 +80,39 @@ func main() {
 	}
 }
 
+// rebuildKarma appends the missing karma entries of votes to the ledger and corrects karma of the users of every customer
+func rebuildKarma() {
+	customers, err := data.GetCustomers()
+	if err != nil {
+		log.Fatalf("Cannot get customers. Error: %v", err)
+	}
+	for _, customer := range *customers {
+		corrected, err := data.RebuildKarma(customer.ID)
+		if err != nil {
+			log.Fatalf("Cannot rebuild karma. CustomerID: %d, Error: %v", customer.ID, err)
+		}
+		fmt.Println(fmt.Sprintf("Karma of %d users is corrected. CustomerID: %d", corrected, customer.ID))
+	}
+}
+
 func configureRouter(router *http.ServeMux) http.Handler {
 
 	routes := []RouteData{
 		{"/", controllers.StoriesHandler, false},
 		{"/recent", controllers.RecentStoriesHandler, false},
+		{"/t/", controllers.TagStoriesHandler, false},
+		{"/ask", controllers.AskStoriesHandler, false},
+		{"/show", controllers.ShowStoriesHandler, false},
+		{"/k/", controllers.KindStoriesHandler, false},
+		{"/search", controllers.SearchHandler, false},
+		{"/api/search", controllers.SearchAPIHandler, false},
+		{"/api/stories", controllers.StoriesAPIHandler, false},
 		{"/signup", controllers.SignUpHandler, false},
 		{"/signin", controllers.SignInHandler, false},
 		{"/signout", controllers.SignOutHandler, false},
 		{"/reset-password", controllers.ResetPasswordHandler, false},
 		{"/set-new-password", controllers.SetNewPasswordHandler, false},
+		{"/confirm-email", controllers.ConfirmEmailHandler, false},
 		{"/stories/detail", controllers.StoryDetailHandler, false},
 		{"/exists-custom-domain", controllers.ExistsCustomDomain, false},
 		{"/customer-signup", controllers.CustomerSignUpHandler, false},
@@ -82,17 +120,46 @@ func configureRouter(router *http.ServeMux) http.Handler {
 		{"/faq", controllers.FAQHandler, false},
 		{"/privacy", controllers.PrivacyHandler, false},
 		{"/auth", controllers.SetAuthTokenHandler, false},
-		{"/invitecodes/generate", controllers.GenerateInviteCodeHandler, false},
+		{"/digest/unsubscribe", controllers.DigestUnsubscribeHandler, false},
+		{"/feeds/stories", controllers.StoriesFeedHandler, false},
+		{"/feeds/recent", controllers.RecentStoriesFeedHandler, false},
+		{"/feeds/users", controllers.UserStoriesFeedHandler, false},
+		{"/feeds/comments", controllers.StoryCommentsFeedHandler, false},
 		{"/users/profile", controllers.UserProfileHandler, true},
+		{"/users/karma", controllers.KarmaHistoryHandler, true},
 		{"/change-password", controllers.ChangePasswordHandler, true},
 		{"/profile-edit", controllers.UserProfileHandler, true},
 		{"/users/invite", controllers.InviteUserHandler, true},
+		{"/users/invites", controllers.MyInvitesHandler, true},
+		{"/users/tree", controllers.InviteTreeHandler, true},
 		{"/admin", controllers.AdminHandler, true},
+		{"/admin/digest/preview", controllers.DigestPreviewHandler, true},
+		{"/admin/tags", controllers.TagsHandler, true},
+		{"/admin/kinds", controllers.StoryKindsHandler, true},
+		{"/admin/ranking", controllers.RankingHandler, true},
+		{"/admin/votes", controllers.VoteIntegrityHandler, true},
+		{"/admin/reports", controllers.ReportsHandler, true},
+		{"/admin/screening", controllers.ScreeningHandler, true},
+		{"/admin/posting", controllers.PostingRulesHandler, true},
+		{"/admin/members", controllers.MembersHandler, true},
+		{"/admin/members/export", controllers.MembersExportHandler, true},
+		{"/admin/moderation", controllers.ModerationHandler, true},
+		{"/admin/invites", controllers.InviteSettingsHandler, true},
+		{"/admin/imports", controllers.MemberImportsHandler, true},
+		{"/admin/imports/report", controllers.MemberImportReportHandler, true},
+		{"/admin/webhooks", controllers.WebhooksHandler, true},
+		{"/admin/webhooks/deliveries", controllers.WebhookDeliveriesHandler, true},
+		{"/admin/webhooks/redeliver", controllers.WebhookRedeliverHandler, true},
+		{"/users/digest", controllers.DigestSettingsHandler, true},
+		{"/users/tags", controllers.HiddenTagsHandler, true},
 		{"/stories/vote", controllers.VoteStoryHandler, true},
 		{"/stories/remove/vote", controllers.RemoveStoryVoteHandler, true},
 		{"/stories/save", controllers.SaveStoryHandler, true},
+		{"/flag", controllers.FlagHandler, true},
 		{"/stories/unsave", controllers.UnSaveStoryHandler, true},
 		{"/submit", controllers.SubmitStoryHandler, true},
+		{"/submit/preview", controllers.SubmitPreviewHandler, true},
+		{"/stories/edit", controllers.EditStoryHandler, true},
 		{"/comments/add", controllers.AddCommentHandler, true},
 		{"/comments/vote", controllers.VoteCommentHandler, true},
 		{"/comments/remove/vote", controllers.RemoveCommentVoteHandler, true},
@@ -121,8 +188,11 @@ func configureRouter(router *http.ServeMux) http.Handler {
 	authMiddleware := middlewares.AuthMiddleWare(authorizedPaths)
 	authHandledRouter := authMiddleware(router)
 
+	ipBanMiddleware := middlewares.IPBanMiddleware()
+	ipBanHandledRouter := ipBanMiddleware(authHandledRouter)
+
 	notFoundMiddleware := middlewares.NotFoundMiddleware(allPaths)
-	notFoundHandledRouter := notFoundMiddleware(authHandledRouter)
+	notFoundHandledRouter := notFoundMiddleware(ipBanHandledRouter)
 
 	customerMiddleware := middlewares.CustomerMiddleware()
 	customerHandledRouter := customerMiddleware(notFoundHandledRouter)
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+/*IPBanMiddleware rejects the requests which come from the ip addresses and networks banned by the customer. The client address is the peer address of the connection unless it is a trusted proxy, so a banned client cannot bypass the ban by sending X-Forwarded-For.*/
+func IPBanMiddleware() func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+
+			customer := shared.GetCustomerFromContext(r)
+			if customer == nil || isStaticPath(strings.ToLower(r.URL.Path)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// GetIPAddress reads X-Forwarded-For only from the proxies in TRUSTED_PROXIES
+			ip := net.ParseIP(shared.GetIPAddress(r))
+			if ip != nil && caching.IsIPBanned(getIPBans(customer.ID), ip) {
+				http.Error(w, "Your network is banned.", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func getIPBans(customerID int) []caching.IPBanNetwork {
+	if bans, ok := caching.GetIPBans(customerID); ok {
+		return bans
+	}
+	ipBans, err := data.GetIPBans(customerID)
+	if err != nil {
+		panic(err)
+	}
+	bans := []caching.IPBanNetwork{}
+	for _, ban := range *ipBans {
+		_, network, err := net.ParseCIDR(ban.CIDR)
+		if err != nil {
+			// the bans are validated before they are saved, so a broken one is only reported
+			sentry.CaptureException(err)
+			continue
+		}
+		bans = append(bans, caching.IPBanNetwork{Network: network, EndsOn: ban.EndsOn})
+	}
+	caching.SetIPBans(customerID, bans)
+	return bans
+}
@@ -37,6 +37,10 @@ func pathExists(paths []string, urlPath string) bool {
 		if urlPath == path {
 			return true
 		}
+		// Paths ending with slash match the whole subtree like http.ServeMux does, except the root path.
+		if path != "/" && strings.HasSuffix(path, "/") && strings.HasPrefix(urlPath, path) {
+			return true
+		}
 	}
 	return false
 }
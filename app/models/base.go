@@ -50,11 +50,22 @@ func generateSignedInUserViewModel(userClaims *shared.SignedInUserClaims) *Signe
 	}
 }
 
-// Paging represents the paging model
+// Paging represents the paging model. Listings which are paginated by cursors set the opaque cursors and the urls of next and previous pages instead of page numbers.
 type Paging struct {
-	CurrentPage    int
-	PreviousPage   int
-	NextPage       int
-	IsFinalPage    bool
-	TotalPageCount int
+	CurrentPage    int    `json:"currentpage,omitempty"`
+	PreviousPage   int    `json:"previouspage,omitempty"`
+	NextPage       int    `json:"nextpage,omitempty"`
+	IsFinalPage    bool   `json:"isfinalpage,omitempty"`
+	TotalPageCount int    `json:"totalpagecount,omitempty"`
+	NextCursor     string `json:"nextcursor,omitempty"`
+	PreviousCursor string `json:"previouscursor,omitempty"`
+	NextURL        string `json:"nexturl,omitempty"`
+	PreviousURL    string `json:"previousurl,omitempty"`
+}
+
+// FeedViewModel contains the rss and atom feed urls of a page to be discovered by feed readers
+type FeedViewModel struct {
+	Title   string
+	RSSURL  string
+	AtomURL string
 }
@@ -2,18 +2,31 @@ package models
 
 // CommentViewModel represents the individual comment information
 type CommentViewModel struct {
-	ID              int
-	UserID          int
-	UserName        string
-	StoryID         int
-	Points          int
-	Comment         string
-	CommentedOnText string
-	IsUpvoted       bool
-	IsDownvoted     bool
-	ShowDownvoteBtn bool
-	IsRoot          bool
-	ParentID        int
-	ChildComments   []CommentViewModel
-	SignedInUser    *SignedInUserViewModel
+	ID                int
+	UserID            int
+	UserName          string
+	StoryID           int
+	Points            int
+	Comment           string
+	CommentedOnText   string
+	IsUpvoted         bool
+	IsDownvoted       bool
+	ShowDownvoteBtn   bool
+	IsRoot            bool
+	ParentID          int
+	Depth             int
+	ContinueThreadURL string
+	IsCollapsed       bool
+	HiddenReplyCount  int
+	IsNew             bool
+	IsHidden          bool
+	ChildComments     []CommentViewModel
+	SignedInUser      *SignedInUserViewModel
+}
+
+// CommentSortViewModel represents a comment order option on story page
+type CommentSortViewModel struct {
+	Name       string
+	URL        string
+	IsSelected bool
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"html/template"
 	"image"
 	"linkwind/app/shared"
 	"strings"
@@ -160,3 +161,23 @@ func (model *PrivacyViewModel) SetSignedInUser(userClaims *shared.SignedInUserCl
 	}
 	model.SignedInUser = generateSignedInUserViewModel(userClaims)
 }
+
+/*DigestPreviewViewModel contains the rendered digest mail to preview on admin page */
+type DigestPreviewViewModel struct {
+	PeriodName string
+	Content    template.HTML
+	BaseViewModel
+}
+
+/*SetLayout sets digest preview view model layout members.*/
+func (model *DigestPreviewViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets digest preview view model signed in user members.*/
+func (model *DigestPreviewViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
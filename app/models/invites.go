@@ -0,0 +1,149 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+)
+
+const (
+	maxInviteExpiryDays    = 365
+	maxInvitesPerMonth     = 1000
+	maxInviteKarmaSettings = 100000
+	maxAllowedDomainCount  = 500
+	maxInviteLinkUses      = 100000
+)
+
+/*InviteViewModel represents an invite in the invite lists*/
+type InviteViewModel struct {
+	Code            string
+	Email           string
+	InviterUserName string
+	Status          string
+	CreatedOn       string
+	ExpiresOn       string
+	CanResend       bool
+	CanRevoke       bool
+}
+
+/*MyInvitesViewModel represents the data which is needed on the page which lists the invites of the signed in user*/
+type MyInvitesViewModel struct {
+	Invites        []InviteViewModel
+	Restriction    string
+	Remaining      int
+	SuccessMessage string
+	ErrorMessage   string
+	BaseViewModel
+}
+
+/*SetLayout sets my invites page view model layout members.*/
+func (model *MyInvitesViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets my invites page view model signed in user members.*/
+func (model *MyInvitesViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*InviteLinkViewModel represents a reusable invite link in the invite link list*/
+type InviteLinkViewModel struct {
+	Code          string
+	URL           string
+	CreatedByName string
+	CreatedOn     string
+	ExpiresOn     string
+	MaxUses       int
+	UseCount      int
+	Status        string
+	CanRevoke     bool
+}
+
+/*InviteSettingsViewModel represents the data which is needed on invite settings admin page*/
+type InviteSettingsViewModel struct {
+	ExpiryDays            int
+	MemberInvitesPerMonth int
+	AdminInvitesPerMonth  int
+	MinInviteKarma        int
+	KarmaPerExtraInvite   int
+	SignupMode            string
+	SignupModes           []string
+	AllowedDomains        string
+	LinkMaxUses           int
+	LinkExpiryDays        int
+	Invites               []InviteViewModel
+	InviteLinks           []InviteLinkViewModel
+	SignupRequests        []data.SignupRequest
+	Errors                map[string]string
+	SuccessMessage        string
+	BaseViewModel
+}
+
+/*SetLayout sets invite settings page view model layout members.*/
+func (model *InviteSettingsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets invite settings page view model signed in user members.*/
+func (model *InviteSettingsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the InviteSettingsViewModel*/
+func (model *InviteSettingsViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if model.ExpiryDays < 0 || model.ExpiryDays > maxInviteExpiryDays {
+		model.Errors["ExpiryDays"] = fmt.Sprintf("Days must be between 0 and %d", maxInviteExpiryDays)
+	}
+	quotas := map[string]int{
+		"MemberInvitesPerMonth": model.MemberInvitesPerMonth,
+		"AdminInvitesPerMonth":  model.AdminInvitesPerMonth,
+	}
+	for field, quota := range quotas {
+		if quota < 0 || quota > maxInvitesPerMonth {
+			model.Errors[field] = fmt.Sprintf("Quota must be between 0 and %d", maxInvitesPerMonth)
+		}
+	}
+	if model.MinInviteKarma < 0 || model.MinInviteKarma > maxInviteKarmaSettings {
+		model.Errors["MinInviteKarma"] = fmt.Sprintf("Karma must be between 0 and %d", maxInviteKarmaSettings)
+	}
+	if model.KarmaPerExtraInvite < 0 || model.KarmaPerExtraInvite > maxInviteKarmaSettings {
+		model.Errors["KarmaPerExtraInvite"] = fmt.Sprintf("Karma must be between 0 and %d", maxInviteKarmaSettings)
+	}
+	switch model.SignupMode {
+	case data.SignupInviteOnly, data.SignupInviteLinks, data.SignupApproval:
+	case data.SignupAllowedDomains:
+		if len(model.DomainList()) == 0 {
+			model.Errors["AllowedDomains"] = "Enter the email domains which can sign up"
+		}
+	default:
+		model.Errors["SignupMode"] = "Select a signup mode"
+	}
+	if len(model.DomainList()) > maxAllowedDomainCount {
+		model.Errors["AllowedDomains"] = fmt.Sprintf("There can be at most %d allowed domains", maxAllowedDomainCount)
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidateInviteLink validates the invite link form of InviteSettingsViewModel*/
+func (model *InviteSettingsViewModel) ValidateInviteLink() bool {
+	model.Errors = make(map[string]string)
+	if model.LinkMaxUses < 0 || model.LinkMaxUses > maxInviteLinkUses {
+		model.Errors["LinkMaxUses"] = fmt.Sprintf("Uses must be between 0 and %d", maxInviteLinkUses)
+	}
+	if model.LinkExpiryDays < 0 || model.LinkExpiryDays > maxInviteExpiryDays {
+		model.Errors["LinkExpiryDays"] = fmt.Sprintf("Days must be between 0 and %d", maxInviteExpiryDays)
+	}
+	return len(model.Errors) == 0
+}
+
+/*DomainList returns the email domains which are entered one per line*/
+func (model *InviteSettingsViewModel) DomainList() []string {
+	return splitDomainLines(model.AllowedDomains)
+}
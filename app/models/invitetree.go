@@ -0,0 +1,61 @@
+package models
+
+import (
+	"linkwind/app/shared"
+)
+
+/*InviteTreeRow represents a member in the invite tree. Depth is the number of inviters above the member.*/
+type InviteTreeRow struct {
+	UserName     string
+	Karma        int
+	RegisteredOn string
+	Depth        int
+	ViaLink      bool
+	InviteeCount int
+	BanKinds     []string
+}
+
+/*InviterStats represents the invite chain statistics of a member who invited others*/
+type InviterStats struct {
+	UserName     string
+	InviteeCount int
+	SubtreeSize  int
+	BannedCount  int
+	AverageKarma int
+}
+
+/*InviteTreeViewModel represents the data which is needed on the invite tree page. The statistics are only set for admins.*/
+type InviteTreeViewModel struct {
+	Rows         []InviteTreeRow
+	IsAdmin      bool
+	MemberCount  int
+	InvitedCount int
+	LinkCount    int
+	RootCount    int
+	MaxDepth     int
+	InvitesSent  int
+	InvitesUsed  int
+	TopInviters  []InviterStats
+	BaseViewModel
+}
+
+/*SetLayout sets invite tree page view model layout members.*/
+func (model *InviteTreeViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets invite tree page view model signed in user members.*/
+func (model *InviteTreeViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*AcceptanceRate returns the percentage of the personal invites which are used*/
+func (model *InviteTreeViewModel) AcceptanceRate() int {
+	if model.InvitesSent == 0 {
+		return 0
+	}
+	return model.InvitesUsed * 100 / model.InvitesSent
+}
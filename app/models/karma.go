@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/enums"
+	"linkwind/app/shared"
+	"strings"
+)
+
+const (
+	maxKarmaAdjustmentPoints = 1000
+	maxKarmaReasonLength     = 500
+)
+
+/*KarmaEntryViewModel represents an entry of the karma history*/
+type KarmaEntryViewModel struct {
+	Source        enums.KarmaSource
+	Description   string
+	Points        int
+	StoryID       int
+	StoryTitle    string
+	CommentID     int
+	URL           string
+	Reason        string
+	AdminUserName string
+	CreatedOnText string
+}
+
+/*KarmaHistoryViewModel represents the data which is needed on karma history page of a user*/
+type KarmaHistoryViewModel struct {
+	UserName        string
+	Karma           int
+	Entries         []KarmaEntryViewModel
+	PreviousPageURL string
+	NextPageURL     string
+	IsAdmin         bool
+	Sources         []enums.KarmaSource
+	Source          enums.KarmaSource
+	Points          int
+	Reason          string
+	Errors          map[string]string
+	SuccessMessage  string
+	BaseViewModel
+}
+
+/*SetLayout sets karma history page view model layout members.*/
+func (model *KarmaHistoryViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets karma history page view model signed in user members.*/
+func (model *KarmaHistoryViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the karma adjustment of KarmaHistoryViewModel*/
+func (model *KarmaHistoryViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	model.Reason = strings.TrimSpace(model.Reason)
+
+	if model.Source != enums.AdjustmentKarma && model.Source != enums.BonusKarma {
+		model.Errors["Source"] = "Please select a type"
+	}
+	if model.Points == 0 || model.Points < -maxKarmaAdjustmentPoints || model.Points > maxKarmaAdjustmentPoints {
+		model.Errors["Points"] = fmt.Sprintf("Points must be between -%[1]d and %[1]d and cannot be 0", maxKarmaAdjustmentPoints)
+	} else if model.Source == enums.BonusKarma && model.Points < 0 {
+		model.Errors["Points"] = "Bonus points must be positive"
+	}
+	if model.Reason == "" {
+		model.Errors["Reason"] = "Reason is required!"
+	} else if len(model.Reason) > maxKarmaReasonLength {
+		model.Errors["Reason"] = fmt.Sprintf("Reason cannot be longer than %d characters", maxKarmaReasonLength)
+	}
+	return len(model.Errors) == 0
+}
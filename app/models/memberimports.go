@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"strings"
+)
+
+const (
+	/*MaxMemberImportRows represents the maximum number of addresses which can be imported with a file*/
+	MaxMemberImportRows  = 1000
+	maxImportNameLength  = 50
+	maxImportEmailLength = 50
+	maxImportMemoLength  = 1000
+	utf8ByteOrderMark    = "\ufeff"
+)
+
+/*MemberImportsViewModel represents the data which is needed on the admin page which uploads and lists member imports*/
+type MemberImportsViewModel struct {
+	Imports        []data.MemberImport
+	MaxRows        int
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets member imports page view model layout members.*/
+func (model *MemberImportsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets member imports page view model signed in user members.*/
+func (model *MemberImportsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*MemberImportViewModel represents the data which is needed on the preview and status page of a member import*/
+type MemberImportViewModel struct {
+	Import         data.MemberImport
+	Rows           []data.MemberImportRow
+	Memo           string
+	Restriction    string
+	Remaining      int
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets member import page view model layout members.*/
+func (model *MemberImportViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets member import page view model signed in user members.*/
+func (model *MemberImportViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the send form of MemberImportViewModel*/
+func (model *MemberImportViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if model.Import.Status != data.ImportPreview {
+		model.Errors["General"] = "The invites of this import are already sent."
+	} else if model.Import.ValidCount == 0 {
+		model.Errors["General"] = "There is no valid address to invite."
+	} else if model.Restriction != "" {
+		model.Errors["General"] = model.Restriction
+	} else if model.Remaining >= 0 && model.Remaining < model.Import.ValidCount {
+		model.Errors["General"] = fmt.Sprintf("You can send %d more invitations this month but this import has %d valid addresses.",
+			model.Remaining, model.Import.ValidCount)
+	}
+	if len(model.Memo) > maxImportMemoLength {
+		model.Errors["Memo"] = fmt.Sprintf("Memo cannot be longer than %d characters", maxImportMemoLength)
+	}
+	return len(model.Errors) == 0
+}
+
+/*IsSending returns true while the invitation mails of the import are being sent*/
+func (model *MemberImportViewModel) IsSending() bool {
+	return model.Import.Status == data.ImportSending
+}
+
+/*ReadMemberImportRows reads the addresses, names and roles of a csv file and validates every row. The file may start with a header which names the email, name and role columns. Otherwise they are read in that order. Returns a message instead of rows if the file itself cannot be imported.*/
+func ReadMemberImportRows(file io.Reader, customerID int) ([]data.MemberImportRow, string, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, "The file is not a valid csv file: " + err.Error(), nil
+	}
+	emailColumn, nameColumn, roleColumn := 0, 1, 2
+	lineOffset := 1
+	if len(records) > 0 {
+		if len(records[0]) > 0 {
+			records[0][0] = strings.TrimPrefix(records[0][0], utf8ByteOrderMark)
+		}
+		if columns := importHeaderColumns(records[0]); columns != nil {
+			emailColumn, nameColumn, roleColumn = columns[0], columns[1], columns[2]
+			records = records[1:]
+			lineOffset = 2
+		}
+	}
+	if len(records) == 0 {
+		return nil, "The file does not have any addresses.", nil
+	}
+	if len(records) > MaxMemberImportRows {
+		return nil, fmt.Sprintf("The file can have at most %d addresses. Please split it into smaller files.", MaxMemberImportRows), nil
+	}
+	rows := make([]data.MemberImportRow, len(records))
+	emails := []string{}
+	firstLines := make(map[string]int)
+	for i, record := range records {
+		row := &rows[i]
+		row.LineNumber = i + lineOffset
+		row.Email = strings.TrimSpace(importField(record, emailColumn))
+		row.FullName = strings.TrimSpace(importField(record, nameColumn))
+		row.Role = strings.ToLower(strings.TrimSpace(importField(record, roleColumn)))
+		if row.Role == "" {
+			row.Role = data.RoleMember
+		}
+		row.Status = data.ImportRowInvalid
+		switch {
+		case row.Email == "":
+			row.Message = "Email address is required"
+		case len(row.Email) > maxImportEmailLength || !shared.IsEmailAdressValid(row.Email):
+			row.Message = "Invalid email address"
+		case len(row.FullName) > maxImportNameLength:
+			row.Message = fmt.Sprintf("Name cannot be longer than %d characters", maxImportNameLength)
+		case row.Role != data.RoleMember && row.Role != data.RoleAdmin:
+			row.Message = fmt.Sprintf("Role must be %s or %s", data.RoleMember, data.RoleAdmin)
+		default:
+			key := strings.ToLower(row.Email)
+			if line, exists := firstLines[key]; exists {
+				row.Status = data.ImportRowDuplicate
+				row.Message = fmt.Sprintf("Same address as row %d", line)
+				continue
+			}
+			firstLines[key] = row.LineNumber
+			row.Status = data.ImportRowValid
+			emails = append(emails, row.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return rows, "", nil
+	}
+	members, err := data.GetExistingEmails(emails)
+	if err != nil {
+		return nil, "", err
+	}
+	invited, err := data.GetPendingInviteEmails(customerID, emails)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != data.ImportRowValid {
+			continue
+		}
+		key := strings.ToLower(row.Email)
+		if members[key] {
+			row.Status = data.ImportRowMember
+			row.Message = "Already has an account"
+		} else if invited[key] {
+			row.Status = data.ImportRowInvited
+			row.Message = "Already has a pending invite"
+		}
+	}
+	return rows, "", nil
+}
+
+// importHeaderColumns returns the indexes of the email, name and role columns if the record is a header. Missing columns are -1.
+func importHeaderColumns(record []string) []int {
+	columns := []int{-1, -1, -1}
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "email", "e-mail", "email address":
+			columns[0] = i
+		case "name", "full name", "fullname":
+			columns[1] = i
+		case "role":
+			columns[2] = i
+		}
+	}
+	if columns[0] < 0 {
+		return nil
+	}
+	return columns
+}
+
+func importField(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return record[column]
+}
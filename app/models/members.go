@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+)
+
+/*MemberSorts contains the orders which the member directory can be listed in*/
+var MemberSorts = []string{data.MemberSortJoined, data.MemberSortKarma, data.MemberSortActivity}
+
+/*MemberRoles contains the roles which admins can give to the members*/
+var MemberRoles = []string{data.RoleMember, data.RoleAdmin}
+
+/*MembersViewModel represents the data which is needed on the member directory admin page*/
+type MembersViewModel struct {
+	Members        []data.Member
+	MemberCount    int
+	Search         string
+	Sort           string
+	Sorts          []string
+	Paging         *Paging
+	ExportURL      string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets members page view model layout members.*/
+func (model *MembersViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets members page view model signed in user members.*/
+func (model *MembersViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*MemberViewModel represents the data which is needed on the admin page of a member. CanManage is false for the owner of the platform and the signed in admin themselves.*/
+type MemberViewModel struct {
+	Member         data.Member
+	Activity       data.MemberActivity
+	InvitedBy      string
+	InvitedViaLink bool
+	Stories        []data.Story
+	Comments       []data.Comment
+	Invites        []data.InviteCodeInfo
+	Sessions       []data.UserSession
+	Roles          []string
+	CanManage      bool
+	Role           string
+	Days           int
+	Reason         string
+	Confirm        string
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets member page view model layout members.*/
+func (model *MemberViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets member page view model signed in user members.*/
+func (model *MemberViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*ValidateRole validates the role form of MemberViewModel*/
+func (model *MemberViewModel) ValidateRole() bool {
+	model.Errors = make(map[string]string)
+	if !model.CanManage {
+		model.Errors["General"] = "The role of this member cannot be changed."
+	} else if model.Role != data.RoleMember && model.Role != data.RoleAdmin {
+		model.Errors["Role"] = "Select a role"
+	} else if model.Role == model.Member.Role {
+		model.Errors["Role"] = fmt.Sprintf("%s is already %s", model.Member.UserName, roleTitle(model.Role))
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidatePasswordReset validates the reset password form of MemberViewModel*/
+func (model *MemberViewModel) ValidatePasswordReset() bool {
+	model.Errors = make(map[string]string)
+	if !model.CanManage {
+		model.Errors["General"] = "A reset password link cannot be sent to this member."
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidateSuspension validates the suspension form of MemberViewModel*/
+func (model *MemberViewModel) ValidateSuspension() bool {
+	model.Errors = make(map[string]string)
+	if !model.CanManage || model.Member.Role == data.RoleAdmin {
+		model.Errors["General"] = "Admins cannot be suspended. Change their role first."
+	}
+	if model.Days < 1 || model.Days > maxBanDays {
+		model.Errors["Days"] = fmt.Sprintf("Suspensions must last between 1 and %d days", maxBanDays)
+	}
+	if len(model.Reason) > maxBanReasonLength {
+		model.Errors["Reason"] = fmt.Sprintf("Reason cannot be longer than %d characters", maxBanReasonLength)
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidateDelete validates the delete form of MemberViewModel. The user name of the member must be typed to delete them. Members who invited others are kept so that the invite tree still reaches their invitees.*/
+func (model *MemberViewModel) ValidateDelete() bool {
+	model.Errors = make(map[string]string)
+	if !model.CanManage {
+		model.Errors["General"] = "This member cannot be deleted."
+	} else if model.Activity.InviteeCount > 0 {
+		model.Errors["General"] = fmt.Sprintf("%s invited %d members. Ban them and their invitees from the invite tree instead.", model.Member.UserName, model.Activity.InviteeCount)
+	} else if model.Confirm != model.Member.UserName {
+		model.Errors["Confirm"] = "Type the user name of the member to delete them"
+	}
+	return len(model.Errors) == 0
+}
+
+func roleTitle(role string) string {
+	if role == data.RoleAdmin {
+		return "an admin"
+	}
+	return "a member"
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"net"
+	"strings"
+)
+
+const (
+	maxBanDays         = 3650
+	maxBanReasonLength = 500
+)
+
+/*ModerationViewModel represents the data which is needed on moderation admin page*/
+type ModerationViewModel struct {
+	Bans           []data.UserBan
+	IPBans         []data.IPBan
+	Log            []data.ModerationLogEntry
+	Kinds          []string
+	UserName       string
+	Kind           string
+	Days           int
+	Reason         string
+	Subtree        bool
+	CIDR           string
+	IPDays         int
+	IPReason       string
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets moderation page view model layout members.*/
+func (model *ModerationViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets moderation page view model signed in user members.*/
+func (model *ModerationViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*ValidateUserBan validates the user ban form of ModerationViewModel*/
+func (model *ModerationViewModel) ValidateUserBan() bool {
+	model.Errors = make(map[string]string)
+	if strings.TrimSpace(model.UserName) == "" {
+		model.Errors["UserName"] = "User name is required"
+	}
+	switch model.Kind {
+	case data.UserBanSuspension:
+		if model.Days < 1 || model.Days > maxBanDays {
+			model.Errors["Days"] = fmt.Sprintf("Suspensions must last between 1 and %d days", maxBanDays)
+		}
+	case data.UserBanPermanent, data.UserBanShadow:
+		if model.Days != 0 {
+			model.Errors["Days"] = "Only suspensions have an end date"
+		}
+	default:
+		model.Errors["Kind"] = "Select a ban kind"
+	}
+	if len(model.Reason) > maxBanReasonLength {
+		model.Errors["Reason"] = fmt.Sprintf("Reason cannot be longer than %d characters", maxBanReasonLength)
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidateIPBan validates the ip ban form of ModerationViewModel*/
+func (model *ModerationViewModel) ValidateIPBan() bool {
+	model.Errors = make(map[string]string)
+	if model.Network() == "" {
+		model.Errors["CIDR"] = "Enter an ip address or a network in CIDR notation like 203.0.113.0/24"
+	}
+	if model.IPDays < 0 || model.IPDays > maxBanDays {
+		model.Errors["IPDays"] = fmt.Sprintf("Days must be between 0 and %d", maxBanDays)
+	}
+	if len(model.IPReason) > maxBanReasonLength {
+		model.Errors["IPReason"] = fmt.Sprintf("Reason cannot be longer than %d characters", maxBanReasonLength)
+	}
+	return len(model.Errors) == 0
+}
+
+/*Network returns the entered ip address or network in CIDR notation. A single ip address becomes a network of its own. Returns empty string if it is not valid.*/
+func (model *ModerationViewModel) Network() string {
+	value := strings.TrimSpace(model.CIDR)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return ""
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32"
+		}
+		return ip.String() + "/128"
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return ""
+	}
+	return network.String()
+}
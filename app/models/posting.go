@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/shared"
+)
+
+const (
+	maxProbationDays     = 365
+	maxProbationKarma    = 100000
+	maxRestrictedDomains = 500
+	maxPostsPerPeriod    = 1000
+)
+
+/*PostingRulesViewModel represents the data which is needed on posting rules admin page*/
+type PostingRulesViewModel struct {
+	ProbationDays     int
+	ProbationKarma    int
+	RestrictedDomains string
+	StoriesPerHour    int
+	StoriesPerDay     int
+	CommentsPerHour   int
+	CommentsPerDay    int
+	KarmaPerExtraPost int
+	Errors            map[string]string
+	SuccessMessage    string
+	BaseViewModel
+}
+
+/*SetLayout sets posting rules page view model layout members.*/
+func (model *PostingRulesViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets posting rules page view model signed in user members.*/
+func (model *PostingRulesViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*DomainList returns the restricted domains which are entered one per line. Urls are reduced to their domains.*/
+func (model *PostingRulesViewModel) DomainList() []string {
+	return splitDomainLines(model.RestrictedDomains)
+}
+
+/*Validate validates the PostingRulesViewModel*/
+func (model *PostingRulesViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if model.ProbationDays < 0 || model.ProbationDays > maxProbationDays {
+		model.Errors["ProbationDays"] = fmt.Sprintf("Days must be between 0 and %d", maxProbationDays)
+	}
+	if model.ProbationKarma < 0 || model.ProbationKarma > maxProbationKarma {
+		model.Errors["ProbationKarma"] = fmt.Sprintf("Karma must be between 0 and %d", maxProbationKarma)
+	}
+	if len(model.DomainList()) > maxRestrictedDomains {
+		model.Errors["RestrictedDomains"] = fmt.Sprintf("There can be at most %d restricted domains", maxRestrictedDomains)
+	}
+	limits := map[string]int{
+		"StoriesPerHour":  model.StoriesPerHour,
+		"StoriesPerDay":   model.StoriesPerDay,
+		"CommentsPerHour": model.CommentsPerHour,
+		"CommentsPerDay":  model.CommentsPerDay,
+	}
+	for field, limit := range limits {
+		if limit < 0 || limit > maxPostsPerPeriod {
+			model.Errors[field] = fmt.Sprintf("Limit must be between 0 and %d", maxPostsPerPeriod)
+		}
+	}
+	if model.KarmaPerExtraPost < 0 || model.KarmaPerExtraPost > maxProbationKarma {
+		model.Errors["KarmaPerExtraPost"] = fmt.Sprintf("Karma must be between 0 and %d", maxProbationKarma)
+	}
+	return len(model.Errors) == 0
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/enums"
+	"linkwind/app/shared"
+)
+
+const maxCommentPenalty = 1000
+
+/*RankingViewModel represents the data which is needed on ranking settings admin page*/
+type RankingViewModel struct {
+	Algorithm      string
+	Gravity        float64
+	CommentPenalty int
+	Algorithms     []enums.RankingAlgorithm
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets ranking page view model layout members.*/
+func (model *RankingViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets ranking page view model signed in user members.*/
+func (model *RankingViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*IsSelected returns whether the algorithm is the selected one*/
+func (model *RankingViewModel) IsSelected(algorithm enums.RankingAlgorithm) bool {
+	return model.Algorithm == string(algorithm)
+}
+
+/*Validate validates the RankingViewModel*/
+func (model *RankingViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	found := false
+	for _, algorithm := range enums.RankingAlgorithms {
+		if model.IsSelected(algorithm) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		model.Errors["Algorithm"] = "Please select a ranking algorithm."
+	}
+	if model.Gravity <= 0 || model.Gravity > maxRankingParameter {
+		model.Errors["Gravity"] = fmt.Sprintf("Gravity must be greater than 0 and not greater than %g", maxRankingParameter)
+	}
+	if model.CommentPenalty < 0 || model.CommentPenalty > maxCommentPenalty {
+		model.Errors["CommentPenalty"] = fmt.Sprintf("Comment penalty must be between 0 and %d", maxCommentPenalty)
+	}
+	return len(model.Errors) == 0
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"strings"
+)
+
+const (
+	maxReportReasonCount   = 10
+	maxReportReasonLength  = 50
+	maxReportHideThreshold = 100
+)
+
+/*FlagViewModel represents the data which is needed on the page a user flags a story or comment on*/
+type FlagViewModel struct {
+	TargetType     string
+	TargetID       int
+	StoryID        int
+	StoryTitle     string
+	Text           string
+	Reasons        []string
+	Reason         string
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets flag page view model layout members.*/
+func (model *FlagViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets flag page view model signed in user members.*/
+func (model *FlagViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the FlagViewModel*/
+func (model *FlagViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	for _, reason := range model.Reasons {
+		if reason == model.Reason {
+			return true
+		}
+	}
+	model.Errors["Reason"] = "Please select a reason"
+	return false
+}
+
+/*ReportsViewModel represents the data which is needed on report queue admin page*/
+type ReportsViewModel struct {
+	Items          []data.ReportQueueItem
+	Reasons        string
+	HideThreshold  int
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets report queue page view model layout members.*/
+func (model *ReportsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets report queue page view model signed in user members.*/
+func (model *ReportsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*ReasonList returns the reasons which are entered one per line without duplicates*/
+func (model *ReportsViewModel) ReasonList() []string {
+	reasons := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(model.Reasons, "\n") {
+		reason := strings.ToLower(strings.TrimSpace(line))
+		if reason == "" || seen[reason] {
+			continue
+		}
+		seen[reason] = true
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+/*Validate validates the report settings of ReportsViewModel*/
+func (model *ReportsViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	reasons := model.ReasonList()
+	if len(reasons) == 0 {
+		model.Errors["Reasons"] = "At least one reason is required!"
+	} else if len(reasons) > maxReportReasonCount {
+		model.Errors["Reasons"] = fmt.Sprintf("There can be at most %d reasons", maxReportReasonCount)
+	}
+	for _, reason := range reasons {
+		if len(reason) > maxReportReasonLength {
+			model.Errors["Reasons"] = fmt.Sprintf("Reasons cannot be longer than %d characters", maxReportReasonLength)
+		}
+	}
+	if model.HideThreshold < 0 || model.HideThreshold > maxReportHideThreshold {
+		model.Errors["HideThreshold"] = fmt.Sprintf("Threshold must be between 0 and %d", maxReportHideThreshold)
+	}
+	return len(model.Errors) == 0
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"strings"
+)
+
+const (
+	maxBannedDomainCount  = 500
+	maxBannedPhraseCount  = 500
+	maxBannedPhraseLength = 100
+	maxNewAccountDays     = 365
+	maxNewAccountLinks    = 100
+	minSpamThreshold      = 0.5
+)
+
+/*ScreeningViewModel represents the data which is needed on screening admin page*/
+type ScreeningViewModel struct {
+	Items              []data.HeldContent
+	Status             string
+	Statuses           []string
+	BannedDomains      string
+	BannedPhrases      string
+	NewAccountDays     int
+	MaxNewAccountLinks int
+	MinReputation      float64
+	SpamThreshold      float64
+	SpamDocuments      int
+	HamDocuments       int
+	Errors             map[string]string
+	SuccessMessage     string
+	BaseViewModel
+}
+
+/*SetLayout sets screening page view model layout members.*/
+func (model *ScreeningViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets screening page view model signed in user members.*/
+func (model *ScreeningViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*DomainList returns the banned domains which are entered one per line. Urls are reduced to their domains.*/
+func (model *ScreeningViewModel) DomainList() []string {
+	return splitDomainLines(model.BannedDomains)
+}
+
+/*PhraseList returns the banned phrases which are entered one per line*/
+func (model *ScreeningViewModel) PhraseList() []string {
+	return splitUniqueLines(model.BannedPhrases)
+}
+
+/*Validate validates the screening settings of ScreeningViewModel*/
+func (model *ScreeningViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if len(model.DomainList()) > maxBannedDomainCount {
+		model.Errors["BannedDomains"] = fmt.Sprintf("There can be at most %d banned domains", maxBannedDomainCount)
+	}
+	phrases := model.PhraseList()
+	if len(phrases) > maxBannedPhraseCount {
+		model.Errors["BannedPhrases"] = fmt.Sprintf("There can be at most %d banned phrases", maxBannedPhraseCount)
+	}
+	for _, phrase := range phrases {
+		if len(phrase) > maxBannedPhraseLength {
+			model.Errors["BannedPhrases"] = fmt.Sprintf("Phrases cannot be longer than %d characters", maxBannedPhraseLength)
+		}
+	}
+	if model.NewAccountDays < 0 || model.NewAccountDays > maxNewAccountDays {
+		model.Errors["NewAccountDays"] = fmt.Sprintf("Days must be between 0 and %d", maxNewAccountDays)
+	}
+	if model.MaxNewAccountLinks < 0 || model.MaxNewAccountLinks > maxNewAccountLinks {
+		model.Errors["MaxNewAccountLinks"] = fmt.Sprintf("Links must be between 0 and %d", maxNewAccountLinks)
+	}
+	if model.MinReputation < 0 || model.MinReputation > 1 {
+		model.Errors["MinReputation"] = "Reputation must be between 0 and 1"
+	}
+	if model.SpamThreshold < minSpamThreshold || model.SpamThreshold > 1 {
+		model.Errors["SpamThreshold"] = fmt.Sprintf("Threshold must be between %.1f and 1", minSpamThreshold)
+	}
+	return len(model.Errors) == 0
+}
+
+// splitUniqueLines returns the trimmed and lowercased non empty lines without duplicates
+func splitUniqueLines(text string) []string {
+	lines := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitDomainLines returns the domains which are entered one per line. Urls are reduced to their domains.
+func splitDomainLines(text string) []string {
+	domains := []string{}
+	for _, line := range splitUniqueLines(text) {
+		if !strings.Contains(line, "://") {
+			line = "http://" + line
+		}
+		if domain := shared.URLDomain(line); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
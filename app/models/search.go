@@ -0,0 +1,63 @@
+package models
+
+import (
+	"html/template"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"time"
+)
+
+/*SearchViewModel represents the search form, filters and the results of the search page*/
+type SearchViewModel struct {
+	Query           string
+	Author          string
+	Tag             string
+	Kind            string
+	Type            string
+	From            string
+	To              string
+	Tags            []data.Tag
+	Kinds           []data.StoryKind
+	Results         []SearchResultViewModel
+	Searched        bool
+	PreviousPageURL string
+	NextPageURL     string
+	Errors          map[string]string
+	BaseViewModel
+}
+
+/*SetLayout sets search page view model layout members.*/
+func (model *SearchViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets search page view model signed in user members.*/
+func (model *SearchViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*SearchResultViewModel represents a story or comment on search page with highlighted matches*/
+type SearchResultViewModel struct {
+	Type            string        `json:"type"`
+	StoryID         int           `json:"storyid"`
+	CommentID       int           `json:"commentid,omitempty"`
+	Title           template.HTML `json:"title"`
+	Headline        template.HTML `json:"headline"`
+	URL             string        `json:"url,omitempty"`
+	Host            string        `json:"host,omitempty"`
+	UserName        string        `json:"username"`
+	Points          int           `json:"points"`
+	CommentCount    int           `json:"commentcount"`
+	CreatedOn       time.Time     `json:"createdon"`
+	SubmittedOnText string        `json:"-"`
+}
+
+/*SearchResponse represents the json response of search api*/
+type SearchResponse struct {
+	Results  []SearchResultViewModel `json:"results"`
+	Page     int                     `json:"page"`
+	NextPage int                     `json:"nextpage,omitempty"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"html/template"
+	"linkwind/app/data"
 	"linkwind/app/shared"
 	"strings"
 )
@@ -12,6 +13,7 @@ type StoryPageViewModel struct {
 	IsAuthenticated bool
 	Stories         []StoryViewModel
 	Page            *Paging
+	Feed            *FeedViewModel
 	BaseViewModel
 }
 
@@ -33,13 +35,81 @@ func (model *StoryPageViewModel) SetSignedInUser(userClaims *shared.SignedInUser
 
 /*StorySubmitModel represents the data to submit a story.*/
 type StorySubmitModel struct {
-	URL    string
-	Title  string
-	Text   string
-	Errors map[string]string
+	URL              string
+	Title            string
+	Text             string
+	Tags             []string
+	AvailableTags    []data.Tag
+	Kind             string
+	AvailableKinds   []data.StoryKind
+	ConfirmDuplicate bool
+	Duplicate        *DuplicateStoryViewModel
+	PostingNotice    string
+	Errors           map[string]string
 	BaseViewModel
 }
 
+/*IsTagSelected returns whether the tag is selected on submit form*/
+func (model *StorySubmitModel) IsTagSelected(tag string) bool {
+	return containsTag(model.Tags, tag)
+}
+
+/*StoryEditModel represents the data to edit a story.*/
+type StoryEditModel struct {
+	ID             int
+	URL            string
+	Title          string
+	Text           string
+	Tags           []string
+	AvailableTags  []data.Tag
+	Kind           string
+	AvailableKinds []data.StoryKind
+	Errors         map[string]string
+	BaseViewModel
+}
+
+/*IsTagSelected returns whether the tag is selected on edit form*/
+func (model *StoryEditModel) IsTagSelected(tag string) bool {
+	return containsTag(model.Tags, tag)
+}
+
+/*Validate validates the StoryEditModel*/
+func (model *StoryEditModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if strings.TrimSpace(model.Title) == "" {
+		model.Errors["Title"] = "Please enter a title."
+	}
+	if message := ValidateStoryTags(model.Tags, model.AvailableTags); message != "" {
+		model.Errors["Tags"] = message
+	}
+	if model.Kind == "" {
+		model.Errors["Kind"] = "Please select a kind."
+	} else if message := ValidateStoryKind(model.Kind, model.AvailableKinds); message != "" {
+		model.Errors["Kind"] = message
+	}
+	return len(model.Errors) == 0
+}
+
+/*SetLayout sets story edit model layout members.*/
+func (model *StoryEditModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets story edit model signed in user members.*/
+func (model *StoryEditModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*DuplicateStoryViewModel represents the story which was submitted with the same url before*/
+type DuplicateStoryViewModel struct {
+	ID              int
+	Title           string
+	SubmittedOnText string
+}
+
 /*Validate validates the StorySubmitModel*/
 func (model *StorySubmitModel) Validate() bool {
 	model.Errors = make(map[string]string)
@@ -55,6 +125,14 @@ func (model *StorySubmitModel) Validate() bool {
 		model.Errors["Title"] = "Please enter a title."
 		return false
 	}
+	if message := ValidateStoryTags(model.Tags, model.AvailableTags); message != "" {
+		model.Errors["Tags"] = message
+		return false
+	}
+	if message := ValidateStoryKind(model.Kind, model.AvailableKinds); message != "" {
+		model.Errors["Kind"] = message
+		return false
+	}
 	return true
 }
 
@@ -73,10 +151,17 @@ func (model *StorySubmitModel) SetSignedInUser(userClaims *shared.SignedInUserCl
 
 /*StoryDetailPageViewModel represents the story page view model that contains indivual story informations*/
 type StoryDetailPageViewModel struct {
-	Title           string
-	Story           *StoryViewModel
-	Comments        *[]CommentViewModel
-	IsAuthenticated bool
+	Title              string
+	Story              *StoryViewModel
+	Comments           *[]CommentViewModel
+	IsThread           bool
+	ParentThreadURL    string
+	CommentSorts       []CommentSortViewModel
+	NewCommentCount    int
+	IsHidden           bool
+	CommentRestriction string
+	IsAuthenticated    bool
+	Feed               *FeedViewModel
 	BaseViewModel
 }
 
@@ -98,19 +183,29 @@ func (model *StoryDetailPageViewModel) SetSignedInUser(userClaims *shared.Signed
 
 // StoryViewModel represents the an indiviudual story information
 type StoryViewModel struct {
-	ID              int
-	Title           string
-	URL             string
-	Text            template.HTML
-	Host            string
-	UserID          int
-	UserName        string
-	Points          int
-	CommentCount    int
-	SubmittedOnText string
-	IsSaved         bool
-	IsUpvoted       bool
-	IsDownvoted     bool
-	ShowDownvoteBtn bool
-	SignedInUser    *SignedInUserViewModel
+	ID              int                    `json:"id"`
+	Title           string                 `json:"title"`
+	URL             string                 `json:"url,omitempty"`
+	Text            template.HTML          `json:"text,omitempty"`
+	Host            string                 `json:"host,omitempty"`
+	UserID          int                    `json:"userid"`
+	UserName        string                 `json:"username"`
+	Points          int                    `json:"points"`
+	CommentCount    int                    `json:"commentcount"`
+	SubmittedOnText string                 `json:"submittedon"`
+	Description     string                 `json:"description,omitempty"`
+	SiteName        string                 `json:"sitename,omitempty"`
+	Tags            []string               `json:"tags"`
+	Kind            string                 `json:"kind"`
+	IsSaved         bool                   `json:"issaved"`
+	IsUpvoted       bool                   `json:"isupvoted"`
+	IsDownvoted     bool                   `json:"isdownvoted"`
+	ShowDownvoteBtn bool                   `json:"-"`
+	SignedInUser    *SignedInUserViewModel `json:"-"`
+}
+
+/*StoriesResponse represents the json response of stories api*/
+type StoriesResponse struct {
+	Stories []StoryViewModel `json:"stories"`
+	Paging  *Paging          `json:"paging"`
 }
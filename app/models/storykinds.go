@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"strings"
+)
+
+const (
+	maxKindNameLength        = 25
+	maxKindTitlePrefixLength = 25
+	maxRankingParameter      = 10.0
+)
+
+/*StoryKindsViewModel represents the data which is needed on story kinds admin page*/
+type StoryKindsViewModel struct {
+	Kinds          []data.StoryKind
+	Name           string
+	TitlePrefix    string
+	VoteExponent   float64
+	TimeExponent   float64
+	RankMultiplier float64
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets story kinds page view model layout members.*/
+func (model *StoryKindsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets story kinds page view model signed in user members.*/
+func (model *StoryKindsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the StoryKindsViewModel*/
+func (model *StoryKindsViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	model.Name = strings.ToLower(strings.TrimSpace(model.Name))
+	model.TitlePrefix = strings.TrimSpace(model.TitlePrefix)
+
+	if model.Name == "" {
+		model.Errors["Name"] = "Name is required!"
+	} else if len(model.Name) > maxKindNameLength {
+		model.Errors["Name"] = fmt.Sprintf("Name cannot be longer than %d characters", maxKindNameLength)
+	} else if !tagNameRegex.MatchString(model.Name) {
+		model.Errors["Name"] = "Name can contain only letters, numbers and hyphens"
+	}
+	if len(model.TitlePrefix) > maxKindTitlePrefixLength {
+		model.Errors["TitlePrefix"] = fmt.Sprintf("Title prefix cannot be longer than %d characters", maxKindTitlePrefixLength)
+	}
+	if model.VoteExponent <= 0 || model.VoteExponent > maxRankingParameter ||
+		model.TimeExponent <= 0 || model.TimeExponent > maxRankingParameter ||
+		model.RankMultiplier <= 0 || model.RankMultiplier > maxRankingParameter {
+		model.Errors["Ranking"] = fmt.Sprintf("Ranking parameters must be greater than 0 and not greater than %g", maxRankingParameter)
+	}
+	return len(model.Errors) == 0
+}
+
+/*ValidateStoryKind checks the selected kind is one of the available kinds. Empty kind means auto detection. Returns the error message if any.*/
+func ValidateStoryKind(selected string, kinds []data.StoryKind) string {
+	if selected == "" {
+		return ""
+	}
+	for _, kind := range kinds {
+		if kind.Name == selected {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Unknown story kind: %s", selected)
+}
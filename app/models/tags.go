@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"regexp"
+	"strings"
+)
+
+const (
+	/*MaxStoryTags represents the maximum number of tags a story can be tagged with*/
+	MaxStoryTags            = 3
+	maxTagNameLength        = 25
+	maxTagDescriptionLength = 100
+)
+
+var tagNameRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+/*TagsViewModel represents the data which is needed on tag vocabulary admin page*/
+type TagsViewModel struct {
+	Tags           []data.Tag
+	Name           string
+	Description    string
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets tags page view model layout members.*/
+func (model *TagsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets tags page view model signed in user members.*/
+func (model *TagsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the TagsViewModel*/
+func (model *TagsViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	model.Name = strings.ToLower(strings.TrimSpace(model.Name))
+	model.Description = strings.TrimSpace(model.Description)
+
+	if model.Name == "" {
+		model.Errors["Name"] = "Name is required!"
+	} else if len(model.Name) > maxTagNameLength {
+		model.Errors["Name"] = fmt.Sprintf("Name cannot be longer than %d characters", maxTagNameLength)
+	} else if !tagNameRegex.MatchString(model.Name) {
+		model.Errors["Name"] = "Name can contain only letters, numbers and hyphens"
+	}
+	if len(model.Description) > maxTagDescriptionLength {
+		model.Errors["Description"] = fmt.Sprintf("Description cannot be longer than %d characters", maxTagDescriptionLength)
+	}
+	return len(model.Errors) == 0
+}
+
+/*HiddenTagsViewModel represents the data which is needed on hidden tags settings page*/
+type HiddenTagsViewModel struct {
+	Tags           []data.Tag
+	HiddenTags     []string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets hidden tags page view model layout members.*/
+func (model *HiddenTagsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets hidden tags page view model signed in user members.*/
+func (model *HiddenTagsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*IsHidden returns whether the tag is hidden by user*/
+func (model *HiddenTagsViewModel) IsHidden(tag string) bool {
+	return containsTag(model.HiddenTags, tag)
+}
+
+/*ValidateStoryTags checks the selected tags are in the vocabulary and not more than MaxStoryTags. Returns the error message if any.*/
+func ValidateStoryTags(selected []string, vocabulary []data.Tag) string {
+	if len(selected) > MaxStoryTags {
+		return fmt.Sprintf("You can select at most %d tags.", MaxStoryTags)
+	}
+	for _, tag := range selected {
+		found := false
+		for _, t := range vocabulary {
+			if t.Name == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("Unknown tag: %s", tag)
+		}
+	}
+	return ""
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
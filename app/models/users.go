@@ -2,6 +2,7 @@ package models
 
 import (
 	"linkwind/app/data"
+	"linkwind/app/enums"
 	"linkwind/app/shared"
 	"strings"
 )
@@ -14,6 +15,8 @@ type UserProfileViewModel struct {
 	Karma          int
 	RegisteredOn   string
 	UserName       string
+	InvitedBy      string
+	InvitedViaLink bool
 	Errors         map[string]string
 	SuccessMessage string
 	IsAdmin        bool
@@ -51,6 +54,8 @@ type InviteUserViewModel struct {
 	EmailAddress   string
 	SuccessMessage string
 	Memo           string
+	Restriction    string
+	Remaining      int
 	Errors         map[string]string
 	BaseViewModel
 }
@@ -89,3 +94,23 @@ func (model *InviteUserViewModel) Validate(email string) (bool, error) {
 	}
 	return len(model.Errors) == 0, nil
 }
+
+/*DigestSettingsViewModel represents the data which is needed on digest settings UI.*/
+type DigestSettingsViewModel struct {
+	Frequency      enums.DigestFrequency
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets digest settings view model layout members.*/
+func (model *DigestSettingsViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets digest settings view model signed in user members.*/
+func (model *DigestSettingsViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+)
+
+const (
+	maxVoteAccountAgeDays = 365
+	maxVoteKarma          = 10000
+)
+
+/*VoteIntegrityViewModel represents the data which is needed on vote integrity admin page*/
+type VoteIntegrityViewModel struct {
+	MinAccountAgeDays int
+	MinKarma          int
+	Status            string
+	Statuses          []string
+	Flags             []data.VoteFlag
+	Errors            map[string]string
+	SuccessMessage    string
+	BaseViewModel
+}
+
+/*SetLayout sets vote integrity page view model layout members.*/
+func (model *VoteIntegrityViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets vote integrity page view model signed in user members.*/
+func (model *VoteIntegrityViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the vote rules of VoteIntegrityViewModel*/
+func (model *VoteIntegrityViewModel) Validate() bool {
+	model.Errors = make(map[string]string)
+	if model.MinAccountAgeDays < 0 || model.MinAccountAgeDays > maxVoteAccountAgeDays {
+		model.Errors["MinAccountAgeDays"] = fmt.Sprintf("Minimum account age must be between 0 and %d days", maxVoteAccountAgeDays)
+	}
+	if model.MinKarma < 0 || model.MinKarma > maxVoteKarma {
+		model.Errors["MinKarma"] = fmt.Sprintf("Minimum karma must be between 0 and %d", maxVoteKarma)
+	}
+	return len(model.Errors) == 0
+}
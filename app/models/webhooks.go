@@ -0,0 +1,101 @@
+package models
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/shared"
+	"net/url"
+	"strings"
+)
+
+/*WebhooksViewModel represents the data which is needed on webhooks admin page*/
+type WebhooksViewModel struct {
+	Webhooks       []data.Webhook
+	Events         []enums.WebhookEvent
+	URL            string
+	SelectedEvents []string
+	Errors         map[string]string
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets webhooks page view model layout members.*/
+func (model *WebhooksViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets webhooks page view model signed in user members.*/
+func (model *WebhooksViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
+
+/*Validate validates the WebhooksViewModel*/
+func (model *WebhooksViewModel) Validate() bool {
+	const maxURLLength = 500
+
+	model.Errors = make(map[string]string)
+
+	if strings.TrimSpace(model.URL) == "" {
+		model.Errors["URL"] = "URL is required!"
+	} else if len(model.URL) > maxURLLength {
+		model.Errors["URL"] = "URL cannot be longer than 500 characters"
+	} else {
+		u, err := url.ParseRequestURI(model.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			model.Errors["URL"] = "URL should be a valid http or https address"
+		}
+	}
+
+	if len(model.SelectedEvents) == 0 {
+		model.Errors["Events"] = "Select at least one event"
+	}
+	for _, selected := range model.SelectedEvents {
+		if !isWebhookEvent(selected) {
+			model.Errors["Events"] = "Unknown event: " + selected
+		}
+	}
+	return len(model.Errors) == 0
+}
+
+/*IsSelected returns whether the event is selected on create webhook form*/
+func (model *WebhooksViewModel) IsSelected(event enums.WebhookEvent) bool {
+	for _, selected := range model.SelectedEvents {
+		if selected == string(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range enums.WebhookEvents {
+		if string(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+/*WebhookDeliveriesViewModel represents the data which is needed on webhook deliveries page*/
+type WebhookDeliveriesViewModel struct {
+	Webhook        *data.Webhook
+	Deliveries     []data.WebhookDelivery
+	SuccessMessage string
+	BaseViewModel
+}
+
+/*SetLayout sets webhook deliveries page view model layout members.*/
+func (model *WebhookDeliveriesViewModel) SetLayout(platformName string, logo string, title string) {
+	model.Layout = generateLayoutViewModel(platformName, logo, title)
+}
+
+/*SetSignedInUser sets webhook deliveries page view model signed in user members.*/
+func (model *WebhookDeliveriesViewModel) SetSignedInUser(userClaims *shared.SignedInUserClaims) {
+	if userClaims == nil {
+		return
+	}
+	model.SignedInUser = generateSignedInUserViewModel(userClaims)
+}
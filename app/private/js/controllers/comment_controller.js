@@ -53,6 +53,9 @@ export default class extends Controller {
         if (res.ok) {
           return res.text();
         }
+        if (res.status === 403) {
+          res.text().then(message => alert(message));
+        }
         return ''
       })
       .then(res => {
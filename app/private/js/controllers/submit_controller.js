@@ -0,0 +1,35 @@
+import {
+  Controller,
+} from "stimulus";
+
+export default class extends Controller {
+  static targets = ["url", "title", "description"];
+
+  preview() {
+    const url = this.urlTarget.value.trim();
+    if (url === "") {
+      return;
+    }
+    fetch(`/submit/preview?url=${encodeURIComponent(url)}`)
+      .then((res) => {
+        if (!res.ok) {
+          throw new Error(res.statusText);
+        }
+        return res.json();
+      })
+      .then((res) => {
+        if (this.titleTarget.value.trim() === "" && res.Title) {
+          this.titleTarget.value = res.Title;
+        }
+        if (res.Description) {
+          this.descriptionTarget.textContent = res.Description;
+          this.descriptionTarget.classList.remove("hidden");
+        } else {
+          this.descriptionTarget.classList.add("hidden");
+        }
+      })
+      .catch(() => {
+        this.descriptionTarget.classList.add("hidden");
+      });
+  }
+}
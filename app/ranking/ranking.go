@@ -0,0 +1,127 @@
+package ranking
+
+import (
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"math"
+	"time"
+)
+
+// hotRankingEpoch is the reference time of Reddit hot ranking
+const hotRankingEpoch = 1134028003
+
+// hotRankingPeriod is the number of seconds which weighs as much as ten times more votes in hot ranking
+const hotRankingPeriod = 45000
+
+// wilsonZ is the z-score of 95% confidence level
+const wilsonZ = 1.96
+
+/*Parameters represents the tunable parameters of a ranking. Parameters which an algorithm does not use are ignored.*/
+type Parameters struct {
+	VoteExponent   float64
+	Gravity        float64
+	CommentPenalty int
+	Multiplier     float64
+}
+
+/*Ranker calculates the rank of a story. Stories with higher ranks are listed first.*/
+type Ranker interface {
+	Rank(story *data.Story, parameters *Parameters, now time.Time) float64
+}
+
+/*GravityRanker ranks like Hacker News: votes decay by the age of the story with a gravity. Stories with few comments are boosted by comment penalty.*/
+type GravityRanker struct{}
+
+/*Rank calculates votes^VoteExponent / (age+1)^Gravity * penalty * Multiplier where age is in seconds*/
+func (ranker *GravityRanker) Rank(story *data.Story, parameters *Parameters, now time.Time) float64 {
+	votes := story.UpVotes - story.DownVotes
+	if votes <= 0 {
+		votes = 1
+	}
+	age := now.Sub(story.SubmittedOn).Seconds()
+	if age < 0 {
+		age = 0
+	}
+	up := math.Pow(float64(votes), parameters.VoteExponent)
+	down := math.Pow(age+1, parameters.Gravity)
+	return up / down * float64(CommentPenalty(story.CommentCount, parameters.CommentPenalty)) * parameters.Multiplier
+}
+
+/*CommentPenalty returns penalty - commentCount for stories with less comments than penalty, otherwise 1. Zero penalty disables it.*/
+func CommentPenalty(commentCount, penalty int) int {
+	if commentCount < penalty {
+		return penalty - commentCount
+	}
+	return 1
+}
+
+/*HotRanker ranks like Reddit hot: logarithm of votes is added to the submission time so that rank does not decay but newer stories need less votes*/
+type HotRanker struct{}
+
+/*Rank calculates sign * log10(max(|votes|, 1) * Multiplier) + (submission time - epoch) / 45000*/
+func (ranker *HotRanker) Rank(story *data.Story, parameters *Parameters, now time.Time) float64 {
+	votes := float64(story.UpVotes - story.DownVotes)
+	order := math.Log10(math.Max(math.Abs(votes), 1) * parameters.Multiplier)
+	sign := 0.0
+	if votes > 0 {
+		sign = 1
+	} else if votes < 0 {
+		sign = -1
+	}
+	seconds := float64(story.SubmittedOn.Unix() - hotRankingEpoch)
+	return sign*order + seconds/hotRankingPeriod
+}
+
+/*WilsonRanker ranks by lower bound of Wilson score confidence interval of upvote ratio regardless of the age of the story*/
+type WilsonRanker struct{}
+
+/*Rank calculates the lower bound of 95% confidence interval of upvote ratio multiplied by Multiplier*/
+func (ranker *WilsonRanker) Rank(story *data.Story, parameters *Parameters, now time.Time) float64 {
+	return WilsonLowerBound(story.UpVotes, story.DownVotes) * parameters.Multiplier
+}
+
+/*WilsonLowerBound calculates the lower bound of 95% confidence interval of upvote ratio. Returns 0 if there is no vote.*/
+func WilsonLowerBound(upVotes, downVotes int) float64 {
+	n := float64(upVotes + downVotes)
+	if n == 0 {
+		return 0
+	}
+	phat := float64(upVotes) / n
+	z2 := wilsonZ * wilsonZ
+	return (phat + z2/(2*n) - wilsonZ*math.Sqrt((phat*(1-phat)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+/*Controversy calculates how evenly the votes are split like Reddit: (upvotes+downvotes)^balance where balance is the ratio of the minority votes to the majority votes. Returns 0 if either side has no vote.*/
+func Controversy(upVotes, downVotes int) float64 {
+	if upVotes <= 0 || downVotes <= 0 {
+		return 0
+	}
+	magnitude := float64(upVotes + downVotes)
+	balance := float64(downVotes) / float64(upVotes)
+	if upVotes < downVotes {
+		balance = float64(upVotes) / float64(downVotes)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+/*TimeRanker ranks by submission time only. Parameters are ignored.*/
+type TimeRanker struct{}
+
+/*Rank returns the submission time as unix seconds*/
+func (ranker *TimeRanker) Rank(story *data.Story, parameters *Parameters, now time.Time) float64 {
+	return float64(story.SubmittedOn.Unix())
+}
+
+/*NewRanker returns the ranker of the algorithm. Returns GravityRanker for unknown algorithms.*/
+func NewRanker(algorithm enums.RankingAlgorithm) Ranker {
+	switch algorithm {
+	case enums.HotRanking:
+		return &HotRanker{}
+	case enums.WilsonRanking:
+		return &WilsonRanker{}
+	case enums.TimeRanking:
+		return &TimeRanker{}
+	default:
+		return &GravityRanker{}
+	}
+}
@@ -0,0 +1,109 @@
+package ranking
+
+import (
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"time"
+)
+
+const (
+	/*MaxCachedRankedStories represents the number of top story ids which are cached per customer. Pages after them are read from database.*/
+	MaxCachedRankedStories = 500
+	/*RankedStoriesTTL represents how long the cached ranked story ids are used*/
+	RankedStoriesTTL = 10 * time.Minute
+)
+
+/*ParametersForKind returns the ranking parameters of the story kind. Kinds which are saved on story kinds page override the gravity of ranking settings.*/
+func ParametersForKind(settings *data.RankingSettings, kinds []data.StoryKind, kind string) *Parameters {
+	parameters := &Parameters{
+		VoteExponent:   data.DefaultVoteExponent,
+		Gravity:        settings.Gravity,
+		CommentPenalty: settings.CommentPenalty,
+		Multiplier:     data.DefaultRankMultiplier,
+	}
+	for _, k := range kinds {
+		if k.Name != kind {
+			continue
+		}
+		parameters.VoteExponent = k.VoteExponent
+		parameters.Multiplier = k.RankMultiplier
+		if k.Customized {
+			parameters.Gravity = k.TimeExponent
+		}
+		break
+	}
+	return parameters
+}
+
+/*RankStories calculates the ranks of the stories by ranking settings of the customer. Keys of the returned map are story ids.*/
+func RankStories(customerID int, stories []data.Story, now time.Time) (map[int]float64, error) {
+	settings, err := data.GetRankingSettings(customerID)
+	if err != nil {
+		return nil, err
+	}
+	kinds, err := data.GetStoryKinds(customerID)
+	if err != nil {
+		return nil, err
+	}
+	ranker := NewRanker(settings.Algorithm)
+	ranks := make(map[int]float64, len(stories))
+	for i := range stories {
+		parameters := ParametersForKind(settings, *kinds, stories[i].Kind)
+		ranks[stories[i].ID] = ranker.Rank(&stories[i], parameters, now)
+	}
+	return ranks, nil
+}
+
+/*RefreshCustomerRanks recalculates and saves the ranks of the customer's stories which are submitted after given time. Cached ranked story ids and pages of the customer are renewed.*/
+func RefreshCustomerRanks(customerID int, since time.Time) error {
+	stories, err := data.GetStoriesSubmittedSince(customerID, since)
+	if err != nil {
+		return err
+	}
+	ranks, err := RankStories(customerID, *stories, time.Now())
+	if err != nil {
+		return err
+	}
+	err = data.UpdateStoryRanks(ranks)
+	if err != nil {
+		return err
+	}
+	_, err = CacheRankedStories(customerID)
+	if err != nil {
+		return err
+	}
+	caching.InvalidatePages(customerID)
+	return nil
+}
+
+/*RefreshStoryRank recalculates and saves the rank of a single story, e.g. after it is voted*/
+func RefreshStoryRank(customerID, storyID int) error {
+	story, err := data.GetStoryByID(storyID)
+	if err != nil {
+		return err
+	}
+	ranks, err := RankStories(customerID, []data.Story{*story}, time.Now())
+	if err != nil {
+		return err
+	}
+	return data.UpdateStoryRanks(ranks)
+}
+
+/*CacheRankedStories computes the ranked story ids of the customer which front page lists and caches them*/
+func CacheRankedStories(customerID int) (*caching.RankedStories, error) {
+	ids, err := data.GetRankedStoryIDs(customerID, MaxCachedRankedStories)
+	if err != nil {
+		return nil, err
+	}
+	count, err := data.GetCustomerStoriesCount(customerID)
+	if err != nil {
+		return nil, err
+	}
+	stories := &caching.RankedStories{
+		IDs:        ids,
+		Count:      count,
+		ComputedOn: time.Now(),
+	}
+	caching.SetRankedStories(customerID, stories)
+	return stories, nil
+}
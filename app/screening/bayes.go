@@ -0,0 +1,98 @@
+package screening
+
+import (
+	"linkwind/app/data"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	/*MinTrainingDocuments represents how many spam and ham documents the classifier must be trained with before it holds content*/
+	MinTrainingDocuments = 10
+	/*MaxClassifierTokens represents the number of tokens of a document which the classifier looks at*/
+	MaxClassifierTokens = 200
+
+	minTokenLength = 3
+	maxTokenLength = 40
+	// tokens seen in few documents are pulled towards neutral probability
+	tokenStrength   = 1.0
+	neutralSpamProb = 0.5
+)
+
+/*BayesScreener holds the content which the naive Bayes classifier of the customer finds to be spam. The classifier is trained with moderator decisions.*/
+type BayesScreener struct{}
+
+/*Screen calculates the spam probability of the content*/
+func (screener *BayesScreener) Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error {
+	classifier, err := data.GetSpamClassifier(content.CustomerID)
+	if err != nil {
+		return err
+	}
+	if classifier.SpamDocuments < MinTrainingDocuments || classifier.HamDocuments < MinTrainingDocuments {
+		return nil
+	}
+	tokens := Tokenize(content)
+	counts, err := data.GetSpamTokenCounts(content.CustomerID, tokens)
+	if err != nil {
+		return err
+	}
+	verdict.SpamScore = SpamProbability(classifier, counts)
+	if verdict.SpamScore >= settings.SpamThreshold {
+		verdict.Hold("spam probability is %.2f", verdict.SpamScore)
+	}
+	return nil
+}
+
+/*SpamProbability combines the spam probabilities of the tokens assuming that they are independent*/
+func SpamProbability(classifier *data.SpamClassifier, counts map[string]data.SpamTokenCount) float64 {
+	// log odds are summed instead of multiplying probabilities to avoid underflow
+	logOdds := 0.0
+	for _, count := range counts {
+		spamFrequency := float64(count.SpamCount) / float64(classifier.SpamDocuments)
+		hamFrequency := float64(count.HamCount) / float64(classifier.HamDocuments)
+		if spamFrequency+hamFrequency == 0 {
+			continue
+		}
+		seen := float64(count.SpamCount + count.HamCount)
+		probability := spamFrequency / (spamFrequency + hamFrequency)
+		probability = (tokenStrength*neutralSpamProb + seen*probability) / (tokenStrength + seen)
+		logOdds += math.Log(probability) - math.Log(1-probability)
+	}
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+/*Train teaches the classifier of the customer that the content is spam or not*/
+func Train(content *Content, spam bool) error {
+	tokens := Tokenize(content)
+	if len(tokens) == 0 {
+		return nil
+	}
+	return data.TrainSpamClassifier(content.CustomerID, tokens, spam)
+}
+
+/*Tokenize returns the unique lowercased words of the title and text and the domains of the links*/
+func Tokenize(content *Content) []string {
+	tokens := []string{}
+	seen := map[string]bool{}
+	add := func(token string) {
+		if seen[token] || len(tokens) == MaxClassifierTokens {
+			return
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	for _, domain := range content.Domains() {
+		add("domain:" + domain)
+	}
+	text := linkPattern.ReplaceAllString(content.Title+"\n"+content.Text, " ")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) >= minTokenLength && len(word) <= maxTokenLength {
+			add(word)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,226 @@
+package screening
+
+import (
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/shared"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	/*MinReputationSamples represents how many stories linking to a domain must be removed before its reputation is taken into account*/
+	MinReputationSamples = 3
+	/*RepetitionWindow represents how far back the posts of new accounts are checked for repetitions*/
+	RepetitionWindow = 24 * time.Hour
+	/*MaxDomainStories represents how many stories a new account can submit to the same domain in the repetition window without being held*/
+	MaxDomainStories = 2
+	/*MinRepetitiveWords represents the number of words a text must have to be checked for a repeated word*/
+	MinRepetitiveWords = 20
+	/*MaxRepeatedWordRatio represents the share of a single word in a text which is considered repetitive*/
+	MaxRepeatedWordRatio = 0.3
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+`)
+
+/*Content represents a story or comment which is screened before it is published*/
+type Content struct {
+	CustomerID       int
+	Type             string
+	UserID           int
+	UserRegisteredOn time.Time
+	Title            string
+	Text             string
+	URL              string
+}
+
+/*Links returns the url of the story and the links in the text*/
+func (content *Content) Links() []string {
+	links := []string{}
+	if strings.TrimSpace(content.URL) != "" {
+		links = append(links, content.URL)
+	}
+	return append(links, linkPattern.FindAllString(content.Text, -1)...)
+}
+
+/*Domains returns the unique domains of the links*/
+func (content *Content) Domains() []string {
+	domains := []string{}
+	seen := map[string]bool{}
+	for _, link := range content.Links() {
+		domain := shared.URLDomain(link)
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+/*Verdict represents the outcome of screening. Content is held for moderators if there is any reason.*/
+type Verdict struct {
+	Reasons   []string
+	SpamScore float64
+}
+
+/*Held checks whether the content must be held for moderators instead of being published*/
+func (verdict *Verdict) Held() bool {
+	return len(verdict.Reasons) > 0
+}
+
+/*Hold adds a reason to hold the content*/
+func (verdict *Verdict) Hold(format string, args ...interface{}) {
+	verdict.Reasons = append(verdict.Reasons, fmt.Sprintf(format, args...))
+}
+
+/*Screener checks a story or comment by the settings of the customer and adds the reasons to hold it to the verdict*/
+type Screener interface {
+	Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error
+}
+
+/*Pipeline runs the screeners in order. Every screener runs so that moderators see all reasons.*/
+type Pipeline []Screener
+
+/*Screen screens the content by the settings of its customer*/
+func (pipeline Pipeline) Screen(content *Content) (*Verdict, error) {
+	settings, err := data.GetScreeningSettings(content.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	verdict := &Verdict{}
+	for _, screener := range pipeline {
+		err = screener.Screen(content, settings, verdict)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return verdict, nil
+}
+
+/*Default is the pipeline stories and comments are screened by before they are published*/
+var Default = Pipeline{
+	&BannedDomainScreener{},
+	&BannedPhraseScreener{},
+	&DomainReputationScreener{},
+	&NewAccountScreener{},
+	&BayesScreener{},
+}
+
+/*BannedDomainScreener holds the content linking to a banned domain or its subdomains*/
+type BannedDomainScreener struct{}
+
+/*Screen checks the domains of the links against banned domains*/
+func (screener *BannedDomainScreener) Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error {
+	for _, domain := range content.Domains() {
+		if banned := MatchDomain(domain, settings.BannedDomains); banned != "" {
+			verdict.Hold("links to banned domain %s", banned)
+		}
+	}
+	return nil
+}
+
+/*MatchDomain returns the domain in the list which is the same as the domain or its parent. Returns empty string if there is none.*/
+func MatchDomain(domain string, domains []string) string {
+	for _, listed := range domains {
+		if domain == listed || strings.HasSuffix(domain, "."+listed) {
+			return listed
+		}
+	}
+	return ""
+}
+
+/*BannedPhraseScreener holds the content containing a banned phrase. Phrases are matched case insensitively.*/
+type BannedPhraseScreener struct{}
+
+/*Screen checks the title and text against banned phrases*/
+func (screener *BannedPhraseScreener) Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error {
+	text := strings.ToLower(content.Title + "\n" + content.Text)
+	for _, phrase := range settings.BannedPhrases {
+		if strings.Contains(text, phrase) {
+			verdict.Hold("contains banned phrase %q", phrase)
+		}
+	}
+	return nil
+}
+
+/*DomainReputationScreener holds the content linking to domains whose stories are often removed by moderators*/
+type DomainReputationScreener struct{}
+
+/*Screen checks the reputation of the domains of the links*/
+func (screener *DomainReputationScreener) Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error {
+	for _, domain := range content.Domains() {
+		reputation, err := data.GetDomainReputation(content.CustomerID, domain)
+		if err != nil {
+			return err
+		}
+		if reputation.Removed < MinReputationSamples {
+			continue
+		}
+		score := ReputationScore(reputation)
+		if score < settings.MinReputation {
+			verdict.Hold("low reputation of %s (%.2f, %d removed, %d published)", domain, score, reputation.Removed, reputation.Published)
+		}
+	}
+	return nil
+}
+
+/*ReputationScore returns the share of published stories among the moderated stories of the domain. Unknown domains start from 0.5.*/
+func ReputationScore(reputation *data.DomainReputation) float64 {
+	return float64(reputation.Published+1) / float64(reputation.Published+reputation.Removed+2)
+}
+
+/*NewAccountScreener holds the content of new accounts which has too many links or repeats itself*/
+type NewAccountScreener struct{}
+
+/*Screen checks the link count and repetitions if the account is newer than the new account days of the customer*/
+func (screener *NewAccountScreener) Screen(content *Content, settings *data.ScreeningSettings, verdict *Verdict) error {
+	if time.Since(content.UserRegisteredOn) >= time.Duration(settings.NewAccountDays)*24*time.Hour {
+		return nil
+	}
+	links := content.Links()
+	if len(links) > settings.MaxNewAccountLinks {
+		verdict.Hold("new account posted %d links", len(links))
+	}
+	if isRepetitive(content.Text) {
+		verdict.Hold("new account posted repetitive text")
+	}
+	since := time.Now().Add(-RepetitionWindow)
+	if strings.TrimSpace(content.Text) != "" {
+		duplicates, err := data.CountUserDuplicateContent(content.UserID, content.Text, since)
+		if err != nil {
+			return err
+		}
+		if duplicates > 0 {
+			verdict.Hold("new account posted the same text %d times before", duplicates)
+		}
+	}
+	if content.Type == data.ReportTargetStory && strings.TrimSpace(content.URL) != "" {
+		domain := shared.URLDomain(content.URL)
+		domainStories, err := data.CountUserDomainStories(content.UserID, domain, since)
+		if err != nil {
+			return err
+		}
+		if domainStories >= MaxDomainStories {
+			verdict.Hold("new account submitted %d stories to %s before", domainStories, domain)
+		}
+	}
+	return nil
+}
+
+// isRepetitive checks whether a single word makes up a large share of a long enough text
+func isRepetitive(text string) bool {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < MinRepetitiveWords {
+		return false
+	}
+	counts := map[string]int{}
+	for _, word := range words {
+		counts[word]++
+		if float64(counts[word]) > float64(len(words))*MaxRepeatedWordRatio {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package search
+
+import (
+	"linkwind/app/data"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	titleWeight       = 1.0
+	textWeight        = 0.4
+	hostWeight        = 0.2
+	headlineWordCount = 30
+	headlineLeadWords = 10
+)
+
+/*Document represents a story or comment which is indexed by MemorySearcher*/
+type Document struct {
+	CustomerID   int
+	Type         string
+	StoryID      int
+	CommentID    int
+	Title        string
+	Text         string
+	URL          string
+	UserName     string
+	Tags         []string
+	Kind         string
+	Points       int
+	CommentCount int
+	CreatedOn    time.Time
+}
+
+/*MemorySearcher searches the documents kept in memory. Terms are matched as whole words without stemming. It is meant for tests which should not need a database.*/
+type MemorySearcher struct {
+	mutex     sync.RWMutex
+	documents []Document
+}
+
+/*NewMemorySearcher creates a memory searcher with the given documents*/
+func NewMemorySearcher(documents ...Document) *MemorySearcher {
+	searcher := &MemorySearcher{}
+	searcher.Add(documents...)
+	return searcher
+}
+
+/*Add indexes the documents*/
+func (searcher *MemorySearcher) Add(documents ...Document) {
+	searcher.mutex.Lock()
+	defer searcher.mutex.Unlock()
+	searcher.documents = append(searcher.documents, documents...)
+}
+
+/*Search returns the documents which contain all terms of the query ordered by relevance and votes*/
+func (searcher *MemorySearcher) Search(query *data.SearchQuery) (*[]data.SearchResult, error) {
+	searcher.mutex.RLock()
+	defer searcher.mutex.RUnlock()
+
+	results := []data.SearchResult{}
+	terms := tokenize(query.Text)
+	if len(terms) == 0 {
+		return &results, nil
+	}
+	for _, document := range searcher.documents {
+		if !matchesFilters(&document, query) {
+			continue
+		}
+		relevance := scoreDocument(&document, terms)
+		if relevance == 0 {
+			continue
+		}
+		result := data.SearchResult{
+			Type:          document.Type,
+			StoryID:       document.StoryID,
+			CommentID:     document.CommentID,
+			Title:         document.Title,
+			TitleHeadline: document.Title,
+			Headline:      highlight(document.Text, terms, true),
+			URL:           document.URL,
+			UserName:      document.UserName,
+			Points:        document.Points,
+			CommentCount:  document.CommentCount,
+			CreatedOn:     document.CreatedOn,
+			Rank:          relevance * (1 + math.Log(1+math.Max(float64(document.Points), 0))),
+		}
+		if document.Type == data.SearchResultStory {
+			result.TitleHeadline = highlight(document.Title, terms, false)
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank == results[j].Rank {
+			return results[i].CreatedOn.After(results[j].CreatedOn)
+		}
+		return results[i].Rank > results[j].Rank
+	})
+
+	start := (query.PageNumber - 1) * query.PageSize
+	if start < 0 || start >= len(results) {
+		results = []data.SearchResult{}
+		return &results, nil
+	}
+	end := start + query.PageSize
+	if end > len(results) {
+		end = len(results)
+	}
+	results = results[start:end]
+	return &results, nil
+}
+
+func matchesFilters(document *Document, query *data.SearchQuery) bool {
+	if document.CustomerID != query.CustomerID {
+		return false
+	}
+	if query.Type != "" && document.Type != query.Type {
+		return false
+	}
+	if query.Author != "" && document.UserName != query.Author {
+		return false
+	}
+	if query.Kind != "" && document.Kind != query.Kind {
+		return false
+	}
+	if query.Tag != "" {
+		found := false
+		for _, tag := range document.Tags {
+			if tag == query.Tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.From != nil && document.CreatedOn.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && !document.CreatedOn.Before(*query.To) {
+		return false
+	}
+	return true
+}
+
+// scoreDocument returns 0 if any of the terms does not exist in the document
+func scoreDocument(document *Document, terms []string) float64 {
+	var title, host []string
+	if document.Type == data.SearchResultStory {
+		title = tokenize(document.Title)
+		if uri, err := url.Parse(document.URL); err == nil {
+			host = []string{strings.TrimPrefix(strings.ToLower(uri.Hostname()), "www.")}
+		}
+	}
+	text := tokenize(document.Text)
+	score := 0.0
+	for _, term := range terms {
+		termScore := titleWeight*float64(countTerm(title, term)) +
+			textWeight*float64(countTerm(text, term)) +
+			hostWeight*float64(countTerm(host, term))
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
+
+func countTerm(tokens []string, term string) int {
+	count := 0
+	for _, token := range tokens {
+		if token == term {
+			count++
+		}
+	}
+	return count
+}
+
+// tokenize keeps the dots inside words so that hosts like example.com remain as one token
+func tokenize(text string) []string {
+	tokens := []string{}
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '.'
+	}) {
+		token = strings.Trim(token, ".")
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func normalizeWord(word string) string {
+	return strings.Trim(strings.ToLower(word), ".,;:!?\"'()[]{}")
+}
+
+// highlight wraps the words which match any of the terms with highlight markers. Fragment returns only the words around the first match.
+func highlight(text string, terms []string, fragment bool) string {
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		if countTerm(terms, normalizeWord(word)) > 0 {
+			if first == -1 {
+				first = i
+			}
+			words[i] = data.HighlightStart + word + data.HighlightEnd
+		}
+	}
+	if fragment && len(words) > headlineWordCount {
+		start := 0
+		if first > headlineLeadWords {
+			start = first - headlineLeadWords
+		}
+		end := start + headlineWordCount
+		if end > len(words) {
+			end = len(words)
+		}
+		words = words[start:end]
+	}
+	return strings.Join(words, " ")
+}
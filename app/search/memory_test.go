@@ -0,0 +1,155 @@
+package search
+
+import (
+	"linkwind/app/data"
+	"strings"
+	"testing"
+	"time"
+)
+
+var searchNow = time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testDocuments() []Document {
+	return []Document{
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 1, Title: "Go generics are here", Text: "A long read about generics", URL: "https://go.dev/blog/generics",
+			UserName: "alice", Tags: []string{"go"}, Kind: "link", Points: 10, CreatedOn: searchNow.Add(-48 * time.Hour)},
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 2, Title: "Rust async book", Text: "Generics in rust traits", URL: "https://www.rust-lang.org/",
+			UserName: "bob", Tags: []string{"rust"}, Kind: "ask", Points: 3, CreatedOn: searchNow.Add(-24 * time.Hour)},
+		{CustomerID: 1, Type: data.SearchResultComment, StoryID: 1, CommentID: 5, Text: "I waited years for generics in go",
+			UserName: "bob", Points: 1, CreatedOn: searchNow.Add(-12 * time.Hour)},
+		{CustomerID: 2, Type: data.SearchResultStory, StoryID: 3, Title: "Generics on another platform", URL: "https://example.com/",
+			UserName: "carol", Tags: []string{"go"}, Kind: "link", Points: 50, CreatedOn: searchNow},
+	}
+}
+
+func searchIDs(t *testing.T, searcher *MemorySearcher, query *data.SearchQuery) []int {
+	t.Helper()
+	if query.PageNumber == 0 {
+		query.PageNumber = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 10
+	}
+	results, err := searcher.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%+v) error = %v", query, err)
+	}
+	ids := []int{}
+	for _, result := range *results {
+		if result.Type == data.SearchResultComment {
+			ids = append(ids, -result.CommentID)
+			continue
+		}
+		ids = append(ids, result.StoryID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemorySearcherScopesCustomer(t *testing.T) {
+	searcher := NewMemorySearcher(testDocuments()...)
+	for customerID, want := range map[int][]int{1: {1, 2, -5}, 2: {3}, 3: {}} {
+		ids := searchIDs(t, searcher, &data.SearchQuery{CustomerID: customerID, Text: "generics"})
+		if !equalIDs(ids, want) {
+			t.Errorf("customer %d results = %v, want %v", customerID, ids, want)
+		}
+	}
+}
+
+func TestMemorySearcherFilters(t *testing.T) {
+	searcher := NewMemorySearcher(testDocuments()...)
+	from := searchNow.Add(-30 * time.Hour)
+	to := searchNow.Add(-20 * time.Hour)
+	tests := []struct {
+		name  string
+		query data.SearchQuery
+		want  []int
+	}{
+		{"all terms must match", data.SearchQuery{Text: "generics rust"}, []int{2}},
+		{"type", data.SearchQuery{Text: "generics", Type: data.SearchResultComment}, []int{-5}},
+		{"author", data.SearchQuery{Text: "generics", Author: "bob"}, []int{2, -5}},
+		{"kind", data.SearchQuery{Text: "generics", Kind: "ask"}, []int{2}},
+		{"tag", data.SearchQuery{Text: "generics", Tag: "go"}, []int{1}},
+		{"date range", data.SearchQuery{Text: "generics", From: &from, To: &to}, []int{2}},
+		{"host", data.SearchQuery{Text: "go.dev"}, []int{1}},
+		{"empty text", data.SearchQuery{Text: " !? "}, []int{}},
+	}
+	for _, test := range tests {
+		test.query.CustomerID = 1
+		if ids := searchIDs(t, searcher, &test.query); !equalIDs(ids, test.want) {
+			t.Errorf("%s: results = %v, want %v", test.name, ids, test.want)
+		}
+	}
+}
+
+func TestMemorySearcherOrdersByRelevanceAndVotes(t *testing.T) {
+	documents := []Document{
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 1, Title: "Postgres tips", Points: 0, CreatedOn: searchNow.Add(-time.Hour)},
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 2, Title: "Postgres tuning", Points: 40, CreatedOn: searchNow.Add(-2 * time.Hour)},
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 3, Title: "Notes", Text: "postgres", Points: 0, CreatedOn: searchNow},
+		{CustomerID: 1, Type: data.SearchResultStory, StoryID: 4, Title: "Postgres news", Points: -5, CreatedOn: searchNow},
+	}
+	searcher := NewMemorySearcher(documents...)
+	// the title weighs more than the text, votes raise the rank of equally relevant stories and newer stories win the ties
+	want := []int{2, 4, 1, 3}
+	if ids := searchIDs(t, searcher, &data.SearchQuery{CustomerID: 1, Text: "postgres"}); !equalIDs(ids, want) {
+		t.Errorf("results = %v, want %v", ids, want)
+	}
+	if ids := searchIDs(t, searcher, &data.SearchQuery{CustomerID: 1, Text: "postgres", PageNumber: 2, PageSize: 3}); !equalIDs(ids, []int{3}) {
+		t.Errorf("second page = %v, want [3]", ids)
+	}
+	if ids := searchIDs(t, searcher, &data.SearchQuery{CustomerID: 1, Text: "postgres", PageNumber: 3, PageSize: 3}); len(ids) != 0 {
+		t.Errorf("page after the last = %v, want none", ids)
+	}
+}
+
+func TestMemorySearcherHighlights(t *testing.T) {
+	long := "filler " + strings.Repeat("word ", 40) + "Generics, finally. " + strings.Repeat("tail ", 40)
+	searcher := NewMemorySearcher(
+		Document{CustomerID: 1, Type: data.SearchResultStory, StoryID: 1, Title: "Generics <b>now</b>", Text: long},
+		Document{CustomerID: 1, Type: data.SearchResultComment, StoryID: 1, CommentID: 2, Title: "Generics <b>now</b>", Text: "more generics"},
+	)
+	results, err := searcher.Search(&data.SearchQuery{CustomerID: 1, Text: "generics", PageNumber: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(*results) != 2 {
+		t.Fatalf("Search() returned %d results, want 2", len(*results))
+	}
+	story, comment := (*results)[0], (*results)[1]
+	if story.Type != data.SearchResultStory {
+		story, comment = comment, story
+	}
+
+	if want := data.HighlightStart + "Generics" + data.HighlightEnd + " <b>now</b>"; story.TitleHeadline != want {
+		t.Errorf("story title headline = %q, want %q", story.TitleHeadline, want)
+	}
+	words := strings.Fields(story.Headline)
+	if len(words) != headlineWordCount {
+		t.Errorf("story headline has %d words, want %d", len(words), headlineWordCount)
+	}
+	if words[headlineLeadWords] != data.HighlightStart+"Generics,"+data.HighlightEnd {
+		t.Errorf("story headline = %q, want the match after %d lead words", story.Headline, headlineLeadWords)
+	}
+	if comment.TitleHeadline != comment.Title {
+		t.Errorf("comment title headline = %q, want the story title without highlights", comment.TitleHeadline)
+	}
+	if want := "more " + data.HighlightStart + "generics" + data.HighlightEnd; comment.Headline != want {
+		t.Errorf("comment headline = %q, want %q", comment.Headline, want)
+	}
+
+	if got, want := string(HighlightHTML(story.TitleHeadline)), "<mark>Generics</mark> &lt;b&gt;now&lt;/b&gt;"; got != want {
+		t.Errorf("HighlightHTML() = %q, want %q", got, want)
+	}
+}
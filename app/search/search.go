@@ -0,0 +1,32 @@
+package search
+
+import (
+	"html"
+	"html/template"
+	"linkwind/app/data"
+	"strings"
+)
+
+/*Searcher searches the stories and comments of a customer*/
+type Searcher interface {
+	Search(query *data.SearchQuery) (*[]data.SearchResult, error)
+}
+
+/*DatabaseSearcher searches by Postgres full-text search*/
+type DatabaseSearcher struct{}
+
+/*Search returns the results of the query from database*/
+func (searcher *DatabaseSearcher) Search(query *data.SearchQuery) (*[]data.SearchResult, error) {
+	return data.Search(query)
+}
+
+/*Default is the searcher used by search handlers*/
+var Default Searcher = &DatabaseSearcher{}
+
+/*HighlightHTML escapes the headline and wraps the highlighted terms with mark elements*/
+func HighlightHTML(headline string) template.HTML {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, data.HighlightStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, data.HighlightEnd, "</mark>")
+	return template.HTML(escaped)
+}
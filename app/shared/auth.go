@@ -1,6 +1,9 @@
 package shared
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"linkwind/app/data"
 	"net/http"
 	"os"
@@ -118,3 +121,50 @@ func GenerateAuthToken(user data.User, expirationTime time.Time) (string, error)
 	}
 	return tokenString, nil
 }
+
+/*UnsubscribeClaims represents the data in signed unsubscribe link token.*/
+type UnsubscribeClaims struct {
+	UserID  int    `json:"userid"`
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+const digestUnsubscribePurpose = "digest-unsubscribe"
+
+/*GenerateUnsubscribeToken generates a signed token which lets the user unsubscribe from digest emails without signing in.*/
+func GenerateUnsubscribeToken(userID int) (string, error) {
+	claims := &UnsubscribeClaims{
+		UserID:  userID,
+		Purpose: digestUnsubscribePurpose,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+}
+
+/*ParseUnsubscribeToken validates the unsubscribe token and returns the user id in it.*/
+func ParseUnsubscribeToken(tokenString string) (int, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &UnsubscribeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	claims, ok := token.Claims.(*UnsubscribeClaims)
+	if !ok || !token.Valid || claims.Purpose != digestUnsubscribePurpose {
+		return 0, fmt.Errorf("Invalid unsubscribe token")
+	}
+	return claims.UserID, nil
+}
+
+/*GenerateEmailConfirmationToken generates the random token which is mailed to confirm the email address of a new account*/
+func GenerateEmailConfirmationToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
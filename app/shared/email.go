@@ -2,6 +2,8 @@ package shared
 
 import (
 	"fmt"
+	"html"
+	"linkwind/app/data"
 	"net/smtp"
 	"os"
 	"strconv"
@@ -35,6 +37,46 @@ type ResetPasswordMailInfo struct {
 	Token    string
 }
 
+/*DigestMailInfo represents DigestMail parameters*/
+type DigestMailInfo struct {
+	Email            string
+	UserName         string
+	Domain           *string
+	Platform         string
+	PeriodName       string
+	Stories          []data.Story
+	UnsubscribeToken string
+}
+
+/*ReportResolvedMailInfo represents ReportResolvedMail parameters*/
+type ReportResolvedMailInfo struct {
+	Email      string
+	UserName   string
+	Domain     *string
+	Platform   string
+	TargetType string
+	StoryID    int
+	StoryTitle string
+	Status     string
+}
+
+/*SignupApprovedMailInfo represents SignupApprovedMail parameters*/
+type SignupApprovedMailInfo struct {
+	Email    string
+	UserName string
+	Domain   *string
+	Platform string
+}
+
+/*EmailConfirmationMailInfo represents EmailConfirmationMail parameters*/
+type EmailConfirmationMailInfo struct {
+	Email    string
+	UserName string
+	Domain   *string
+	Platform string
+	Token    string
+}
+
 /*SetInviteMailBody combine parameters and return body for UserInviteMail*/
 func SetInviteMailBody(m InviteMailInfo, platformName string) string {
 	content := ""
@@ -159,3 +201,233 @@ func getSMTPConfig() *SMTPConfig {
 		Address:  fmt.Sprintf("%s:%d", server, port),
 	}
 }
+
+/*SendDigestMail sends the top stories digest mail to the subscribed user*/
+func SendDigestMail(m DigestMailInfo) error {
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+
+	platformName := m.Platform
+	if m.Domain != nil {
+		platformName = *m.Domain
+	}
+
+	subject := "Subject: " + "[" + platformName + "] Your " + m.PeriodName + " digest of top stories\n"
+
+	body := GenerateDigestMailBody(m)
+	msg := []byte(subject + mime + "\n" + body)
+
+	from := "www.linkwind.co@gmail.com"
+	to := m.Email
+	smtpConfig := getSMTPConfig()
+
+	err := smtp.SendMail(
+		smtpConfig.Address,
+		smtp.PlainAuth(
+			"",
+			smtpConfig.UserName,
+			smtpConfig.Password,
+			smtpConfig.Server),
+		from,
+		[]string{to},
+		msg)
+
+	if err != nil {
+		return fmt.Errorf("An error occured when send digest mail : %s", err)
+	}
+	return nil
+}
+
+/*GenerateDigestMailBody generates the html body of digest mail. It is also used to preview the digest.*/
+func GenerateDigestMailBody(m DigestMailInfo) string {
+	domain := m.Platform + ".linkwind.co"
+	if m.Domain != nil {
+		domain = *m.Domain
+	}
+
+	content := ""
+	content += "<p>Hello " + html.EscapeString(m.UserName) + "</p>"
+	content += "<p>Here is your " + m.PeriodName + " digest of the top stories on " + html.EscapeString(m.Platform) + ".</p>"
+	if len(m.Stories) == 0 {
+		content += "<p>No stories were submitted in this period.</p>"
+	}
+	content += "<ol>"
+	for _, story := range m.Stories {
+		detailURL := fmt.Sprintf("https://%s/stories/detail?id=%d", domain, story.ID)
+		storyURL := detailURL
+		if story.URL != "" {
+			storyURL = story.URL
+		}
+		content += "<li>"
+		content += "<a href=\"" + html.EscapeString(storyURL) + "\">" + html.EscapeString(story.Title) + "</a><br />"
+		content += fmt.Sprintf("<small>%d points by %s | <a href=\"%s\">%d comments</a></small>",
+			story.UpVotes,
+			html.EscapeString(story.UserName),
+			detailURL,
+			story.CommentCount)
+		content += "</li>"
+	}
+	content += "</ol>"
+
+	if m.UnsubscribeToken != "" {
+		unsubscribeURL := "https://" + domain + "/digest/unsubscribe?token=" + m.UnsubscribeToken
+		content += "<p><small>You are receiving this mail because you subscribed to " + m.PeriodName + " digests. "
+		content += "<a href=" + unsubscribeURL + ">Unsubscribe</a></small></p>"
+	}
+	return content
+}
+
+/*SendReportResolvedMail tells the user who reported a story or comment what the moderators decided*/
+func SendReportResolvedMail(m ReportResolvedMailInfo) error {
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+
+	platformName := m.Platform
+	if m.Domain != nil {
+		platformName = *m.Domain
+	}
+
+	subject := "Subject: " + "[" + platformName + "] Your report is reviewed\n"
+
+	body := generateReportResolvedMailBody(m, platformName)
+	msg := []byte(subject + mime + "\n" + body)
+
+	from := "www.linkwind.co@gmail.com"
+	to := m.Email
+	smtpConfig := getSMTPConfig()
+
+	err := smtp.SendMail(
+		smtpConfig.Address,
+		smtp.PlainAuth(
+			"",
+			smtpConfig.UserName,
+			smtpConfig.Password,
+			smtpConfig.Server),
+		from,
+		[]string{to},
+		msg)
+
+	if err != nil {
+		return fmt.Errorf("An error occured when send report resolved mail : %s", err)
+	}
+	return nil
+}
+
+func generateReportResolvedMailBody(m ReportResolvedMailInfo, platformName string) string {
+	domain := platformName + ".linkwind.co"
+	if m.Domain != nil {
+		domain = *m.Domain
+	}
+	detailURL := fmt.Sprintf("https://%s/stories/detail?id=%d", domain, m.StoryID)
+
+	content := ""
+	content += "<p>Hello " + html.EscapeString(m.UserName) + "</p>"
+	content += "<p>Thank you for reporting the " + m.TargetType + " on <a href=\"" + detailURL + "\">" + html.EscapeString(m.StoryTitle) + "</a>.</p>"
+	switch m.Status {
+	case data.ReportRemoved:
+		content += "<p>The moderators reviewed it and removed the " + m.TargetType + ".</p>"
+	case data.ReportAuthorBanned:
+		content += "<p>The moderators reviewed it, removed the " + m.TargetType + " and banned its author.</p>"
+	default:
+		content += "<p>The moderators reviewed it and found that it does not break the rules of " + html.EscapeString(m.Platform) + ".</p>"
+	}
+	return content
+}
+
+/*SendSignupApprovedMail tells the user whose signup request is approved that the account can sign in*/
+func SendSignupApprovedMail(m SignupApprovedMailInfo) error {
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+
+	platformName := m.Platform
+	if m.Domain != nil {
+		platformName = *m.Domain
+	}
+
+	subject := "Subject: " + "[" + platformName + "] Your account is approved\n"
+
+	body := generateSignupApprovedMailBody(m, platformName)
+	msg := []byte(subject + mime + "\n" + body)
+
+	from := "www.linkwind.co@gmail.com"
+	to := m.Email
+	smtpConfig := getSMTPConfig()
+
+	err := smtp.SendMail(
+		smtpConfig.Address,
+		smtp.PlainAuth(
+			"",
+			smtpConfig.UserName,
+			smtpConfig.Password,
+			smtpConfig.Server),
+		from,
+		[]string{to},
+		msg)
+
+	if err != nil {
+		return fmt.Errorf("An error occured when send signup approved mail : %s", err)
+	}
+	return nil
+}
+
+func generateSignupApprovedMailBody(m SignupApprovedMailInfo, platformName string) string {
+	domain := platformName + ".linkwind.co"
+	if m.Domain != nil {
+		domain = *m.Domain
+	}
+	signInURL := "https://" + domain + "/signin"
+
+	content := ""
+	content += "<p>Hello " + html.EscapeString(m.UserName) + "</p>"
+	content += "<p>The admins of " + html.EscapeString(m.Platform) + " approved your account.</p>"
+	content += "<p>You can sign in by clicking the link below.</p>"
+	content += "<a href=" + signInURL + ">" + signInURL + "</a>"
+	return content
+}
+
+/*SendEmailConfirmationMail sends the link which confirms the email address of the new account*/
+func SendEmailConfirmationMail(m EmailConfirmationMailInfo) error {
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+
+	platformName := m.Platform
+	if m.Domain != nil {
+		platformName = *m.Domain
+	}
+
+	subject := "Subject: " + "[" + platformName + "] Confirm your email address\n"
+
+	body := generateEmailConfirmationMailBody(m, platformName)
+	msg := []byte(subject + mime + "\n" + body)
+
+	from := "www.linkwind.co@gmail.com"
+	to := m.Email
+	smtpConfig := getSMTPConfig()
+
+	err := smtp.SendMail(
+		smtpConfig.Address,
+		smtp.PlainAuth(
+			"",
+			smtpConfig.UserName,
+			smtpConfig.Password,
+			smtpConfig.Server),
+		from,
+		[]string{to},
+		msg)
+
+	if err != nil {
+		return fmt.Errorf("An error occured when send email confirmation mail : %s", err)
+	}
+	return nil
+}
+
+func generateEmailConfirmationMailBody(m EmailConfirmationMailInfo, platformName string) string {
+	domain := platformName + ".linkwind.co"
+	if m.Domain != nil {
+		domain = *m.Domain
+	}
+	confirmURL := "https://" + domain + "/confirm-email?token=" + m.Token
+
+	content := ""
+	content += "<p>Hello " + html.EscapeString(m.UserName) + "</p>"
+	content += "<p>Your account on " + html.EscapeString(m.Platform) + " is created.</p>"
+	content += "<p>Confirm your email address by clicking the link below to activate it.</p>"
+	content += "<a href=" + confirmURL + ">" + confirmURL + "</a>"
+	return content
+}
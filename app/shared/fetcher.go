@@ -0,0 +1,169 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	/*FetcherUserAgent represents the user agent which is sent while fetching submitted urls*/
+	FetcherUserAgent = "Mozilla/5.0 (compatible; LinkwindBot/1.0; +https://linkwind.co)"
+	/*MaxFetchBodySize represents the maximum number of bytes read from a fetched page*/
+	MaxFetchBodySize = 2 << 20
+	/*MaxFetchRedirects represents the maximum number of redirects followed while fetching a page*/
+	MaxFetchRedirects = 5
+
+	fetchDialTimeout           = 5 * time.Second
+	fetchTLSHandshakeTimeout   = 5 * time.Second
+	fetchResponseHeaderTimeout = 10 * time.Second
+	fetchTimeout               = 15 * time.Second
+)
+
+var (
+	/*ErrFetchBodyTooLarge is returned when the fetched page is larger than MaxFetchBodySize*/
+	ErrFetchBodyTooLarge = errors.New("response body is too large")
+	/*ErrFetchAddressBlocked is returned when the url resolves to a private, loopback or link local address*/
+	ErrFetchAddressBlocked = errors.New("address is not allowed")
+)
+
+var blockedNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+var fetchClient = newFetchClient(CheckDialAddress)
+
+// newFetchClient returns the client which checks every address it dials with control
+func newFetchClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: fetchDialTimeout,
+		Control: control,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   fetchTLSHandshakeTimeout,
+		ResponseHeaderTimeout: fetchResponseHeaderTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &http.Client{
+		Transport:     transport,
+		Timeout:       fetchTimeout,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+/*FetchPage downloads the page with the hardened client. It returns the body and the final url after redirects.*/
+func FetchPage(pageURL string) ([]byte, *url.URL, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid url. Error: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil, fmt.Errorf("Unsupported url scheme: %s", u.Scheme)
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", FetcherUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error occured when get url response. Error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("response status code: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > MaxFetchBodySize {
+		return nil, nil, ErrFetchBodyTooLarge
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxFetchBodySize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error occured when read response body. Error: %v", err)
+	}
+	if len(body) > MaxFetchBodySize {
+		return nil, nil, ErrFetchBodyTooLarge
+	}
+	return body, resp.Request.URL, nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > MaxFetchRedirects {
+		return fmt.Errorf("stopped after %d redirects", MaxFetchRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("Unsupported redirect scheme: %s", req.URL.Scheme)
+	}
+	req.Header.Set("User-Agent", FetcherUserAgent)
+	return nil
+}
+
+/*CheckDialAddress is the net.Dialer control which refuses blocked ip addresses. It runs after DNS resolution, so it sees the ip address which is actually connected.*/
+func CheckDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrFetchAddressBlocked, host)
+	}
+	return nil
+}
+
+/*IsBlockedIP checks whether the ip is in private, loopback, link local or another reserved range*/
+func IsBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
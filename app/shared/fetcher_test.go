@@ -0,0 +1,232 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// useTestServerClient lets the fetcher dial the test server which listens on the loopback address. Every other address is still checked.
+func useTestServerClient(t *testing.T, server *httptest.Server) *http.Client {
+	allowed := server.Listener.Addr().String()
+	previous := fetchClient
+	fetchClient = newFetchClient(func(network, address string, c syscall.RawConn) error {
+		if address == allowed {
+			return nil
+		}
+		return CheckDialAddress(network, address, c)
+	})
+	t.Cleanup(func() { fetchClient = previous })
+	return fetchClient
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"10.0.0.1", true},
+		{"172.16.5.4", true},
+		{"192.168.1.1", true},
+		{"100.64.0.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"::", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"93.184.216.34", false},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"2606:4700:4700::1111", false},
+	}
+	for _, test := range tests {
+		if blocked := IsBlockedIP(net.ParseIP(test.ip)); blocked != test.blocked {
+			t.Errorf("IsBlockedIP(%s) = %v, want %v", test.ip, blocked, test.blocked)
+		}
+	}
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.1.2.3:443", "[::1]:8080", "[fe80::1]:80", "169.254.169.254:80", "localhost:80"} {
+		if err := CheckDialAddress("tcp", address, nil); !errors.Is(err, ErrFetchAddressBlocked) {
+			t.Errorf("CheckDialAddress(%s) = %v, want %v", address, err, ErrFetchAddressBlocked)
+		}
+	}
+	if err := CheckDialAddress("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("CheckDialAddress of a public address = %v, want nil", err)
+	}
+}
+
+func TestFetchPageBlocksLoopback(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	_, _, err := FetchPage(server.URL)
+	if !errors.Is(err, ErrFetchAddressBlocked) {
+		t.Fatalf("FetchPage(%s) error = %v, want %v", server.URL, err, ErrFetchAddressBlocked)
+	}
+	if requested {
+		t.Error("the blocked server received the request")
+	}
+}
+
+func TestFetchPageBlocksPrivateAndLinkLocal(t *testing.T) {
+	for _, pageURL := range []string{"http://10.0.0.1/", "http://192.168.0.1:8080/", "http://169.254.169.254/latest/meta-data/", "http://[fe80::1]/"} {
+		if _, _, err := FetchPage(pageURL); !errors.Is(err, ErrFetchAddressBlocked) {
+			t.Errorf("FetchPage(%s) error = %v, want %v", pageURL, err, ErrFetchAddressBlocked)
+		}
+	}
+}
+
+func TestFetchPageRejectsSchemes(t *testing.T) {
+	for _, pageURL := range []string{"file:///etc/passwd", "ftp://example.com/", "gopher://example.com/"} {
+		if _, _, err := FetchPage(pageURL); err == nil {
+			t.Errorf("FetchPage(%s) succeeded, want an error", pageURL)
+		}
+	}
+}
+
+func TestFetchPageUserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+	useTestServerClient(t, server)
+
+	body, finalURL, err := FetchPage(server.URL)
+	if err != nil {
+		t.Fatalf("FetchPage() error = %v", err)
+	}
+	if string(body) != "<html></html>" {
+		t.Errorf("FetchPage() body = %q", body)
+	}
+	if finalURL.Path != "/page" {
+		t.Errorf("FetchPage() final url = %s, want the redirected url", finalURL)
+	}
+	if len(userAgents) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(userAgents))
+	}
+	for _, userAgent := range userAgents {
+		if userAgent != FetcherUserAgent {
+			t.Errorf("User-Agent = %q, want %q", userAgent, FetcherUserAgent)
+		}
+	}
+}
+
+func TestFetchPageBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+		}
+		w.Write([]byte(strings.Repeat("a", size)))
+	}))
+	defer server.Close()
+	useTestServerClient(t, server)
+
+	body, _, err := FetchPage(fmt.Sprintf("%s/?size=%d", server.URL, MaxFetchBodySize))
+	if err != nil {
+		t.Fatalf("FetchPage() of %d bytes error = %v", MaxFetchBodySize, err)
+	}
+	if len(body) != MaxFetchBodySize {
+		t.Errorf("FetchPage() read %d bytes, want %d", len(body), MaxFetchBodySize)
+	}
+	for _, pageURL := range []string{
+		fmt.Sprintf("%s/?size=%d", server.URL, MaxFetchBodySize+1),
+		fmt.Sprintf("%s/?size=%d&chunked=1", server.URL, MaxFetchBodySize+1),
+	} {
+		if _, _, err := FetchPage(pageURL); !errors.Is(err, ErrFetchBodyTooLarge) {
+			t.Errorf("FetchPage(%s) error = %v, want %v", pageURL, err, ErrFetchBodyTooLarge)
+		}
+	}
+}
+
+func TestFetchPageRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		left, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if left > 0 {
+			http.Redirect(w, r, "/"+strconv.Itoa(left-1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "done")
+	}))
+	defer server.Close()
+	useTestServerClient(t, server)
+
+	_, finalURL, err := FetchPage(fmt.Sprintf("%s/%d", server.URL, MaxFetchRedirects))
+	if err != nil {
+		t.Fatalf("FetchPage() with %d redirects error = %v", MaxFetchRedirects, err)
+	}
+	if finalURL.Path != "/0" {
+		t.Errorf("FetchPage() final url = %s, want /0", finalURL)
+	}
+	if _, _, err = FetchPage(fmt.Sprintf("%s/%d", server.URL, MaxFetchRedirects+1)); err == nil {
+		t.Errorf("FetchPage() with %d redirects succeeded, want an error", MaxFetchRedirects+1)
+	}
+}
+
+func TestFetchPageRedirectToBlockedHost(t *testing.T) {
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the blocked server received the redirected request")
+	}))
+	defer blocked.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer server.Close()
+	useTestServerClient(t, server)
+
+	for _, target := range []string{blocked.URL, "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/"} {
+		if _, _, err := FetchPage(server.URL + "/?to=" + target); !errors.Is(err, ErrFetchAddressBlocked) {
+			t.Errorf("FetchPage() redirected to %s error = %v, want %v", target, err, ErrFetchAddressBlocked)
+		}
+	}
+	if _, _, err := FetchPage(server.URL + "/?to=file:///etc/passwd"); err == nil {
+		t.Error("FetchPage() redirected to a file url succeeded, want an error")
+	}
+}
+
+func TestFetchPageTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("slow"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	client := useTestServerClient(t, server)
+	client.Timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	if _, _, err := FetchPage(server.URL); err == nil {
+		t.Fatal("FetchPage() of a stalled body succeeded, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("FetchPage() returned after %v, want the client timeout", elapsed)
+	}
+}
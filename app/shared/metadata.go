@@ -0,0 +1,133 @@
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+/*PageMetadata represents the OpenGraph, twitter card and html metadata of a web page*/
+type PageMetadata struct {
+	Title        string
+	Description  string
+	ImageURL     string
+	SiteName     string
+	CanonicalURL string
+	PublishedOn  *time.Time
+}
+
+var publishedOnLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+/*FetchPageMetadata downloads the web page and extracts its metadata. OpenGraph tags take precedence over twitter card and plain html tags.*/
+func FetchPageMetadata(pageURL string) (*PageMetadata, error) {
+	body, finalURL, err := FetchPage(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r, _, _, err := convertHTMLToUTF8(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Fail to read html. Error: %v", err)
+	}
+
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to parse html. Error: %v", err)
+	}
+
+	return ExtractPageMetadata(doc, finalURL), nil
+}
+
+/*ExtractPageMetadata extracts the metadata from the parsed html document. Relative urls are resolved by base url.*/
+func ExtractPageMetadata(doc *html.Node, baseURL *url.URL) *PageMetadata {
+	tags := map[string]string{}
+	var title, canonical string
+	collectMetaTags(doc, tags, &title, &canonical)
+
+	metadata := &PageMetadata{
+		Title:        firstNonEmpty(tags["og:title"], tags["twitter:title"], title),
+		Description:  firstNonEmpty(tags["og:description"], tags["twitter:description"], tags["description"]),
+		ImageURL:     firstNonEmpty(tags["og:image"], tags["og:image:url"], tags["twitter:image"], tags["twitter:image:src"]),
+		SiteName:     firstNonEmpty(tags["og:site_name"], tags["application-name"]),
+		CanonicalURL: firstNonEmpty(canonical, tags["og:url"]),
+	}
+	metadata.ImageURL = resolveURL(baseURL, metadata.ImageURL)
+	metadata.CanonicalURL = resolveURL(baseURL, metadata.CanonicalURL)
+
+	published := firstNonEmpty(tags["article:published_time"], tags["og:published_time"], tags["date"])
+	for _, layout := range publishedOnLayouts {
+		publishedOn, err := time.Parse(layout, published)
+		if err == nil {
+			metadata.PublishedOn = &publishedOn
+			break
+		}
+	}
+	return metadata
+}
+
+func collectMetaTags(n *html.Node, tags map[string]string, title *string, canonical *string) {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "title":
+			if *title == "" && n.FirstChild != nil {
+				*title = strings.TrimSpace(n.FirstChild.Data)
+			}
+		case "meta":
+			key := strings.ToLower(firstNonEmpty(getAttribute(n, "property"), getAttribute(n, "name")))
+			content := strings.TrimSpace(getAttribute(n, "content"))
+			if key != "" && content != "" {
+				if _, ok := tags[key]; !ok {
+					tags[key] = content
+				}
+			}
+		case "link":
+			if *canonical == "" && strings.ToLower(getAttribute(n, "rel")) == "canonical" {
+				*canonical = strings.TrimSpace(getAttribute(n, "href"))
+			}
+		case "body":
+			// Metadata lives in head. No need to walk the whole document.
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectMetaTags(c, tags, title, canonical)
+	}
+}
+
+func getAttribute(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.ToLower(attr.Key) == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func resolveURL(baseURL *url.URL, ref string) string {
+	if ref == "" || baseURL == nil {
+		return ref
+	}
+	u, err := baseURL.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
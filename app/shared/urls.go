@@ -0,0 +1,127 @@
+package shared
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are removed from every url. Generic keys like ref are left out since sites such as GitHub use them for content.
+var trackingParams = map[string]bool{
+	"fbclid":      true,
+	"gclid":       true,
+	"dclid":       true,
+	"msclkid":     true,
+	"yclid":       true,
+	"igshid":      true,
+	"mc_cid":      true,
+	"mc_eid":      true,
+	"_ga":         true,
+	"_hsenc":      true,
+	"_hsmi":       true,
+	"ref_src":     true,
+	"ref_url":     true,
+	"cmpid":       true,
+	"s_cid":       true,
+	"vero_id":     true,
+	"oly_enc_id":  true,
+	"oly_anon_id": true,
+}
+
+/*CanonicalizeURL normalizes the url to detect the same link submitted with different variants. Scheme and host are lowercased, www prefix, default ports, tracking params, fragment and trailing slash are removed and query params are sorted.*/
+func CanonicalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("Cannot parse url. Error: %v", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("Unsupported url scheme: %s", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("Url has no host: %s", rawURL)
+	}
+	host = strings.TrimSuffix(host, ".")
+	host = strings.TrimPrefix(host, "www.")
+	port := u.Port()
+	if port != "" && port != "80" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	path := u.EscapedPath()
+	for strings.HasSuffix(path, "/") {
+		path = strings.TrimSuffix(path, "/")
+	}
+	for _, index := range []string{"/index.html", "/index.htm", "/index.php"} {
+		path = strings.TrimSuffix(path, index)
+	}
+
+	query := u.Query()
+	for key := range query {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, "utm_") || trackingParams[lowerKey] {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	// Scheme is not a part of canonical url since the same page is usually served over both http and https.
+	canonical := host + path
+	if len(params) > 0 {
+		canonical += "?" + strings.Join(params, "&")
+	}
+	return canonical, nil
+}
+
+/*CanonicalizeSubmittedURL returns the canonical form of the submitted url. The canonical link of the fetched page is preferred if it points to the same site.*/
+func CanonicalizeSubmittedURL(submittedURL string, pageCanonicalURL string) (string, error) {
+	canonical, err := CanonicalizeURL(submittedURL)
+	if err != nil {
+		return "", err
+	}
+	if pageCanonicalURL == "" {
+		return canonical, nil
+	}
+	pageCanonical, err := CanonicalizeURL(pageCanonicalURL)
+	if err != nil {
+		return canonical, nil
+	}
+	if canonicalHost(pageCanonical) != canonicalHost(canonical) {
+		return canonical, nil
+	}
+	return pageCanonical, nil
+}
+
+func canonicalHost(canonical string) string {
+	if i := strings.IndexAny(canonical, "/?"); i >= 0 {
+		return canonical[:i]
+	}
+	return canonical
+}
+
+/*URLDomain returns the lowercased host of the url without www prefix and port. Returns empty string if the url cannot be parsed or has no host.*/
+func URLDomain(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimSuffix(host, ".")
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,89 @@
+package shared
+
+import "testing"
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		canonical string
+	}{
+		{"https://example.com/post", "example.com/post"},
+		{"HTTP://WWW.Example.COM/Post", "example.com/Post"},
+		{"https://www.example.com./post", "example.com/post"},
+		{"http://example.com:80/post", "example.com/post"},
+		{"https://example.com:443/post", "example.com/post"},
+		{"https://example.com:8080/post", "example.com:8080/post"},
+		{"https://example.com/post/", "example.com/post"},
+		{"https://example.com/", "example.com"},
+		{"https://example.com/docs/index.html", "example.com/docs"},
+		{"https://example.com/index.php", "example.com"},
+		{"https://example.com/post#comments", "example.com/post"},
+		{"https://example.com/search?q=go&a=1", "example.com/search?a=1&q=go"},
+		{"https://example.com/search?tag=b&tag=a", "example.com/search?tag=a&tag=b"},
+		{"https://example.com/post?utm_source=x&UTM_Medium=y&fbclid=z&id=3", "example.com/post?id=3"},
+		{"https://example.com/post?gclid=1&_ga=2", "example.com/post"},
+		{"https://github.com/golang/go/blob/main/README.md?ref=release-branch.go1.16", "github.com/golang/go/blob/main/README.md?ref=release-branch.go1.16"},
+		{"https://example.com/a%20b?q=a+b", "example.com/a%20b?q=a+b"},
+	}
+	for _, test := range tests {
+		canonical, err := CanonicalizeURL(test.url)
+		if err != nil {
+			t.Errorf("CanonicalizeURL(%s) error = %v", test.url, err)
+			continue
+		}
+		if canonical != test.canonical {
+			t.Errorf("CanonicalizeURL(%s) = %s, want %s", test.url, canonical, test.canonical)
+		}
+	}
+
+	for _, invalid := range []string{"ftp://example.com/file", "mailto:someone@example.com", "https:///path", "://missing"} {
+		if _, err := CanonicalizeURL(invalid); err == nil {
+			t.Errorf("CanonicalizeURL(%s) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestCanonicalizeURLVariantsMatch(t *testing.T) {
+	variants := []string{
+		"https://blog.example.com/2021/03/post/?utm_campaign=feed&b=2&a=1",
+		"http://www.blog.example.com:80/2021/03/post?a=1&b=2#top",
+		"https://BLOG.example.com/2021/03/post/index.html?b=2&a=1&fbclid=abc",
+	}
+	want, err := CanonicalizeURL(variants[0])
+	if err != nil {
+		t.Fatalf("CanonicalizeURL(%s) error = %v", variants[0], err)
+	}
+	for _, variant := range variants[1:] {
+		if canonical, _ := CanonicalizeURL(variant); canonical != want {
+			t.Errorf("CanonicalizeURL(%s) = %s, want %s", variant, canonical, want)
+		}
+	}
+}
+
+func TestCanonicalizeSubmittedURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		submitted     string
+		pageCanonical string
+		canonical     string
+	}{
+		{"no canonical link", "https://example.com/post?id=1", "", "example.com/post?id=1"},
+		{"canonical link on the same site", "https://example.com/amp/post", "https://www.example.com/post", "example.com/post"},
+		{"canonical link on another site", "https://example.com/post", "https://other.com/post", "example.com/post"},
+		{"canonical link on another port", "https://example.com/post", "https://example.com:8443/post", "example.com/post"},
+		{"invalid canonical link", "https://example.com/post", "ftp://example.com/post", "example.com/post"},
+	}
+	for _, test := range tests {
+		canonical, err := CanonicalizeSubmittedURL(test.submitted, test.pageCanonical)
+		if err != nil {
+			t.Errorf("%s: CanonicalizeSubmittedURL() error = %v", test.name, err)
+			continue
+		}
+		if canonical != test.canonical {
+			t.Errorf("%s: CanonicalizeSubmittedURL() = %s, want %s", test.name, canonical, test.canonical)
+		}
+	}
+	if _, err := CanonicalizeSubmittedURL("javascript:alert(1)", "https://example.com/"); err == nil {
+		t.Error("CanonicalizeSubmittedURL() of an invalid url succeeded, want an error")
+	}
+}
@@ -12,16 +12,17 @@ import (
 	"linkwind/app/caching"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
 	"github.com/getsentry/sentry-go"
-	"golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
@@ -83,34 +84,6 @@ func IsPasswordValid(password string) bool {
 	return number && upperCase && lowerCase && special && eigthOrMore
 }
 
-/*FetchURL send request to url that given as parameter and fetch title from HTML code.*/
-func FetchURL(url string) (title string, err error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("Error occured when get url response. Error: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("response status code: %d", resp.StatusCode)
-		return
-	}
-
-	r, _, _, err := convertHTMLToUTF8(resp.Body)
-
-	doc, err := html.Parse(r)
-	if err != nil {
-		return "", fmt.Errorf("Fail to parse html. Error: %v", err)
-	}
-
-	title, ok := traverse(doc)
-	if !ok {
-		return "", fmt.Errorf("Cannot parse title")
-	}
-	fmt.Println(title)
-	return title, nil
-}
-
 /*
 For more info: https://siongui.github.io/2018/10/27/auto-detect-and-convert-html-encoding-to-utf8-in-go/
 */
@@ -130,33 +103,15 @@ func convertHTMLToUTF8(body io.Reader) (r io.Reader, name string, certain bool,
 
 func determineEncodingFromReader(r io.Reader) (e encoding.Encoding, name string, certain bool, err error) {
 	b, err := bufio.NewReader(r).Peek(1024)
-	if err != nil {
+	if err != nil && err != io.EOF {
 		return
 	}
+	err = nil
 
 	e, name, certain = charset.DetermineEncoding(b, "")
 	return
 }
 
-func traverse(n *html.Node) (string, bool) {
-	if isTitleElement(n) {
-		return n.FirstChild.Data, true
-	}
-
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		result, ok := traverse(c)
-		if ok {
-			return result, ok
-		}
-	}
-
-	return "", false
-}
-
-func isTitleElement(n *html.Node) bool {
-	return n.Type == html.ElementNode && n.Data == "title"
-}
-
 /*ReadFile reads file content from given path.*/
 func ReadFile(filePath string) ([]byte, error) {
 	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0660)
@@ -281,3 +236,64 @@ func GetCustomerFromContext(r *http.Request) *caching.CustomerCtx {
 func GetUserFromContext(r *http.Request) *SignedInUserClaims {
 	return r.Context().Value(UserContextKey).(*SignedInUserClaims)
 }
+
+/*GetIPAddress returns the ip address of the client. X-Forwarded-For header is only read if the request comes from a proxy in TRUSTED_PROXIES, and the last address which is not a trusted proxy is used since the client can write the addresses before it. Returns empty string if the address cannot be parsed.*/
+func GetIPAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrustedProxy(ip); i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			break
+		}
+		ip = forwardedIP
+	}
+	return ip.String()
+}
+
+var trustedProxies []*net.IPNet
+var trustedProxiesOnce sync.Once
+
+// isTrustedProxy returns true if the ip address is in TRUSTED_PROXIES, a comma separated list of ip addresses and networks in CIDR notation
+func isTrustedProxy(ip net.IP) bool {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	})
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxies(value string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, proxy := range strings.Split(value, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("Invalid trusted proxy %s: %v", proxy, err))
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,153 @@
+package voting
+
+import (
+	"fmt"
+	"linkwind/app/caching"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/ranking"
+	"strings"
+	"time"
+)
+
+const (
+	/*AnalysisWindow represents how far back the votes are analyzed*/
+	AnalysisWindow = 7 * 24 * time.Hour
+	/*MinReciprocalVotes represents how many times two users must upvote each other to be flagged*/
+	MinReciprocalVotes = 5
+	/*NewAccountAge represents the age which accounts are considered fresh until*/
+	NewAccountAge = 7 * 24 * time.Hour
+	/*MinBurstVotes represents how many upvotes a story must receive from fresh accounts in an hour to be flagged*/
+	MinBurstVotes = 5
+	/*MinSameIPVoters represents how many users must upvote a story from the same ip address to be flagged*/
+	MinSameIPVoters = 3
+)
+
+/*AnalyzeVotes detects the suspicious voting patterns among the votes of the customer since given time and saves them as vote flags. Returns the number of detected patterns.*/
+func AnalyzeVotes(customerID int, since time.Time) (int, error) {
+	now := time.Now()
+	flags := []*data.VoteFlag{}
+
+	pairs, err := data.GetReciprocalVoters(customerID, since, MinReciprocalVotes)
+	if err != nil {
+		return 0, err
+	}
+	// the week in the fingerprint lets a pair which moderators resolved be flagged again if they keep voting for each other in the next weeks
+	year, week := now.ISOWeek()
+	for _, pair := range *pairs {
+		votes, err := data.GetUpvotesBetweenUsers(pair.UserID, pair.OtherUserID, since)
+		if err != nil {
+			return 0, err
+		}
+		flags = append(flags, &data.VoteFlag{
+			CustomerID:  customerID,
+			Reason:      data.VoteFlagReciprocal,
+			Fingerprint: fmt.Sprintf("%s:%d:%d:%d-W%02d", data.VoteFlagReciprocal, pair.UserID, pair.OtherUserID, year, week),
+			Evidence: fmt.Sprintf("%s upvoted %s %d times and %s upvoted %s %d times since %s.",
+				pair.UserName, pair.OtherUserName, pair.VoteCount, pair.OtherUserName, pair.UserName, pair.OtherUserVoteCount, since.Format("2006-01-02")),
+			UserIDs:    []int{pair.UserID, pair.OtherUserID},
+			DetectedOn: now,
+			Votes:      *votes,
+		})
+	}
+
+	bursts, err := data.GetNewAccountVoteBursts(customerID, since, NewAccountAge, MinBurstVotes)
+	if err != nil {
+		return 0, err
+	}
+	for _, burst := range *bursts {
+		evidence := fmt.Sprintf("%d upvotes from accounts younger than %d days in the hour of %s.", len(burst.UserIDs), int(NewAccountAge.Hours()/24), burst.Key)
+		if len(burst.InviterNames) > 0 {
+			evidence += fmt.Sprintf(" Accounts are invited by %s.", strings.Join(burst.InviterNames, ", "))
+		}
+		flags = append(flags, newStoryClusterFlag(customerID, data.VoteFlagNewAccountBurst, &burst, evidence, now))
+	}
+
+	clusters, err := data.GetSameIPVoteClusters(customerID, since, MinSameIPVoters)
+	if err != nil {
+		return 0, err
+	}
+	for _, cluster := range *clusters {
+		evidence := fmt.Sprintf("%d users upvoted the story from ip address %s.", len(cluster.UserIDs), cluster.Key)
+		flags = append(flags, newStoryClusterFlag(customerID, data.VoteFlagSameIP, &cluster, evidence, now))
+	}
+
+	for _, flag := range flags {
+		err = data.SaveVoteFlag(flag)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(flags), nil
+}
+
+func newStoryClusterFlag(customerID int, reason string, cluster *data.StoryVoteCluster, evidence string, now time.Time) *data.VoteFlag {
+	votes := []data.FlaggedVote{}
+	for _, userID := range cluster.UserIDs {
+		votes = append(votes, data.FlaggedVote{
+			TargetType: data.VoteTargetStory,
+			TargetID:   cluster.StoryID,
+			UserID:     userID,
+			VoteType:   enums.UpVote,
+		})
+	}
+	return &data.VoteFlag{
+		CustomerID:  customerID,
+		Reason:      reason,
+		Fingerprint: fmt.Sprintf("%s:%d:%s", reason, cluster.StoryID, cluster.Key),
+		Evidence:    evidence,
+		UserIDs:     cluster.UserIDs,
+		StoryID:     cluster.StoryID,
+		DetectedOn:  now,
+		Votes:       votes,
+	}
+}
+
+/*NullifyVoteFlag removes the votes of the open flag. Vote counters of stories and comments, karma of their authors and ranks of stories are updated. Votes which are already removed or changed are skipped.*/
+func NullifyVoteFlag(customerID, flagID int) error {
+	flag, err := data.GetVoteFlagByID(customerID, flagID)
+	if err != nil {
+		return err
+	}
+	if flag == nil || flag.Status != data.VoteFlagOpen {
+		return nil
+	}
+	storyIDs := map[int]bool{}
+	for _, vote := range flag.Votes {
+		switch vote.TargetType {
+		case data.VoteTargetStory:
+			voteType, err := data.GetStoryVoteByUser(vote.UserID, vote.TargetID)
+			if err != nil {
+				return err
+			}
+			if voteType == nil || *voteType != vote.VoteType {
+				continue
+			}
+			err = data.RemoveStoryVote(vote.UserID, vote.TargetID, vote.VoteType)
+			if err != nil {
+				return err
+			}
+			storyIDs[vote.TargetID] = true
+		case data.VoteTargetComment:
+			voteType, err := data.GetCommentVoteByUser(vote.UserID, vote.TargetID)
+			if err != nil {
+				return err
+			}
+			if voteType == nil || *voteType != vote.VoteType {
+				continue
+			}
+			err = data.RemoveCommentVote(vote.UserID, vote.TargetID, vote.VoteType)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for storyID := range storyIDs {
+		err = ranking.RefreshStoryRank(customerID, storyID)
+		if err != nil {
+			return err
+		}
+	}
+	caching.InvalidatePages(customerID)
+	return data.ResolveVoteFlag(flagID, data.VoteFlagNullified)
+}
@@ -0,0 +1,258 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"linkwind/app/data"
+	"linkwind/app/enums"
+	"linkwind/app/shared"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	/*SignatureHeader represents the http header which contains the HMAC signature of the payload*/
+	SignatureHeader = "X-Linkwind-Signature"
+	/*EventHeader represents the http header which contains the name of the delivered event*/
+	EventHeader = "X-Linkwind-Event"
+	/*DeliveryHeader represents the http header which contains the id of the delivery*/
+	DeliveryHeader = "X-Linkwind-Delivery"
+
+	maxDeliveryAttempts  = 5
+	initialRetryDelay    = 2 * time.Second
+	deliveryTimeout      = 10 * time.Second
+	deliveryDialTimeout  = 5 * time.Second
+	maxDeliveryRedirects = 3
+)
+
+// client refuses private, loopback and link local addresses like the fetcher, so webhooks cannot reach internal services
+var client = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: deliveryDialTimeout,
+			Control: shared.CheckDialAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: deliveryDialTimeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+	},
+	Timeout:       deliveryTimeout,
+	CheckRedirect: checkRedirect,
+}
+
+/*StoryPayload represents the story data which is sent with story.created event*/
+type StoryPayload struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Text        string    `json:"text"`
+	Kind        string    `json:"kind"`
+	UserID      int       `json:"userid"`
+	UserName    string    `json:"username"`
+	SubmittedOn time.Time `json:"submittedon"`
+}
+
+/*CommentPayload represents the comment data which is sent with comment.created event*/
+type CommentPayload struct {
+	ID          int       `json:"id"`
+	StoryID     int       `json:"storyid"`
+	ParentID    int       `json:"parentid"`
+	UserID      int       `json:"userid"`
+	UserName    string    `json:"username"`
+	Comment     string    `json:"comment"`
+	CommentedOn time.Time `json:"commentedon"`
+}
+
+/*VotePayload represents the vote data which is sent with vote.cast event*/
+type VotePayload struct {
+	Target   string `json:"target"`
+	TargetID int    `json:"targetid"`
+	UserID   int    `json:"userid"`
+	VoteType string `json:"votetype"`
+}
+
+/*UserPayload represents the user data which is sent with user.joined event*/
+type UserPayload struct {
+	ID           int       `json:"id"`
+	UserName     string    `json:"username"`
+	RegisteredOn time.Time `json:"registeredon"`
+}
+
+type eventBody struct {
+	Event     enums.WebhookEvent `json:"event"`
+	CreatedOn time.Time          `json:"createdon"`
+	Data      interface{}        `json:"data"`
+}
+
+/*NewStoryPayload creates the payload of story.created event*/
+func NewStoryPayload(story *data.Story, userName string) *StoryPayload {
+	return &StoryPayload{
+		ID:          story.ID,
+		Title:       story.Title,
+		URL:         story.URL,
+		Text:        story.Text,
+		Kind:        story.Kind,
+		UserID:      story.UserID,
+		UserName:    userName,
+		SubmittedOn: story.SubmittedOn,
+	}
+}
+
+/*NewCommentPayload creates the payload of comment.created event*/
+func NewCommentPayload(comment *data.Comment) *CommentPayload {
+	return &CommentPayload{
+		ID:          comment.ID,
+		StoryID:     comment.StoryID,
+		ParentID:    comment.ParentID,
+		UserID:      comment.UserID,
+		UserName:    comment.UserName,
+		Comment:     comment.Comment,
+		CommentedOn: comment.CommentedOn,
+	}
+}
+
+/*NewVotePayload creates the payload of vote.cast event. Target is either story or comment.*/
+func NewVotePayload(target string, targetID, userID int, voteType enums.VoteType) *VotePayload {
+	payload := &VotePayload{
+		Target:   target,
+		TargetID: targetID,
+		UserID:   userID,
+		VoteType: "upvote",
+	}
+	if voteType == enums.DownVote {
+		payload.VoteType = "downvote"
+	}
+	return payload
+}
+
+/*NewUserPayload creates the payload of user.joined event*/
+func NewUserPayload(user *data.User) *UserPayload {
+	return &UserPayload{
+		ID:           user.ID,
+		UserName:     user.UserName,
+		RegisteredOn: user.RegisteredOn,
+	}
+}
+
+/*GenerateSecret generates a random secret to sign the payloads of a webhook*/
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+/*Sign returns the HMAC-SHA256 signature of the body with given secret*/
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*Emit delivers the event to customer's webhooks which subscribed to it. Deliveries run in background so it never blocks the request.*/
+func Emit(customerID int, event enums.WebhookEvent, payload interface{}) {
+	go func() {
+		webhooks, err := data.GetActiveWebhooksByEvent(customerID, string(event))
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+		if len(*webhooks) == 0 {
+			return
+		}
+		body, err := json.Marshal(&eventBody{
+			Event:     event,
+			CreatedOn: time.Now(),
+			Data:      payload,
+		})
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+		for _, webhook := range *webhooks {
+			go send(webhook, string(event), string(body))
+		}
+	}()
+}
+
+/*Redeliver sends the payload of a previous delivery to the webhook again as a new delivery*/
+func Redeliver(webhook *data.Webhook, delivery *data.WebhookDelivery) {
+	go send(*webhook, delivery.Event, delivery.Payload)
+}
+
+func send(webhook data.Webhook, event string, payload string) {
+	delivery := &data.WebhookDelivery{
+		WebhookID: webhook.ID,
+		Event:     event,
+		Payload:   payload,
+		CreatedOn: time.Now(),
+	}
+	err := data.CreateWebhookDelivery(delivery)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+	delay := initialRetryDelay
+	for delivery.Attempts < maxDeliveryAttempts {
+		delivery.Attempts++
+		delivery.ResponseCode, err = post(&webhook, delivery)
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		succeeded := err == nil && delivery.ResponseCode >= 200 && delivery.ResponseCode < 300
+		if succeeded {
+			now := time.Now()
+			delivery.DeliveredOn = &now
+		}
+		err = data.UpdateWebhookDeliveryResult(delivery)
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+		if succeeded || delivery.Attempts == maxDeliveryAttempts {
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func post(webhook *data.Webhook, delivery *data.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Linkwind-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, fmt.Sprintf("%d", delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > maxDeliveryRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxDeliveryRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("Unsupported redirect scheme: %s", req.URL.Scheme)
+	}
+	return nil
+}